@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const AlignGridPanelWidth float32 = 320
+const AlignGridPanelHeight float32 = 350
+const AlignGridOffsetStep float32 = 1
+const AlignGridSizeStep float32 = 0.5
+const AlignGridRotationStep float32 = 0.5
+
+var AlignGridKindNames = []string{"Square", "Hex (flat top)", "Hex (pointy top)"}
+var AlignGridKinds = map[string]grid.Kind{"Square": grid.Square, "Hex (flat top)": grid.HexFlatTop, "Hex (pointy top)": grid.HexPointyTop}
+
+var AlignGridOverlay *fyne.Container
+
+// AlignGridTool lets the GM match the grid to the one printed on the current map.
+// One finger moves the grid, two fingers pinch to size it and twist to rotate it.
+type AlignGridTool struct {
+	File         *mapFile.MapFile
+	Grid         mapFile.GridSettings
+	OnDeactivate func()
+
+	gestures    *touch.GestureRecognizer
+	previous    *mapFile.GridSettings
+	wasVisible  bool
+	onGridMoved func()
+}
+
+// NewAlignGridTool starts aligning the grid of the map shown on the table
+func NewAlignGridTool(file *mapFile.MapFile, onDeactivate func()) *AlignGridTool {
+
+	tool := &AlignGridTool{File: file, OnDeactivate: onDeactivate, gestures: touch.NewGestureRecognizer()}
+	tool.previous = TableView.MapGrid
+	tool.wasVisible = TableView.GridVisible()
+
+	if file.Metadata.Grid != nil {
+		tool.Grid = *file.Metadata.Grid
+	} else {
+		// start from the grid the table is showing so the lines don't jump
+		zoom := float32(TableView.Zoom())
+		tool.Grid = mapFile.GridSettings{PixelsPerSquare: TableView.CellWidth / zoom}
+	}
+
+	TableView.MapGrid = &tool.Grid
+	TableView.SetGridVisible(true)
+	TableView.RedrawGrid()
+	syncGMView()
+
+	return tool
+}
+
+// HandleTouch turns touches into grid movement instead of map movement
+func (tool *AlignGridTool) HandleTouch(event touch.Event) {
+	for _, gesture := range tool.gestures.Process(event) {
+		tool.handleGesture(gesture)
+	}
+}
+
+func (tool *AlignGridTool) handleGesture(gesture touch.Gesture) {
+
+	zoom := float32(ToolView().Zoom())
+	anchor := ToolView().ScreenToMap(gesture.Position)
+
+	switch gesture.Type {
+	case touch.GesturePan:
+		tool.Move(gesture.Delta.DX/zoom, gesture.Delta.DY/zoom)
+	case touch.GesturePinch:
+		tool.Scale(gesture.Scale, anchor)
+	case touch.GestureRotate:
+		tool.Rotate(gesture.Rotation*180/math.Pi, anchor)
+	}
+}
+
+// Move shifts the grid by a distance in map pixels
+func (tool *AlignGridTool) Move(dx float32, dy float32) {
+	tool.Grid.OffsetX += dx
+	tool.Grid.OffsetY += dy
+
+	tool.update()
+}
+
+// Scale resizes the squares, keeping the grid fixed at anchor
+func (tool *AlignGridTool) Scale(scale float32, anchor fyne.Position) {
+	if scale <= 0 || tool.Grid.PixelsPerSquare*scale < 1 {
+		return
+	}
+
+	tool.Grid.PixelsPerSquare *= scale
+	tool.Grid.OffsetX = anchor.X + (tool.Grid.OffsetX-anchor.X)*scale
+	tool.Grid.OffsetY = anchor.Y + (tool.Grid.OffsetY-anchor.Y)*scale
+
+	tool.update()
+}
+
+// Rotate turns the grid by degrees around anchor
+func (tool *AlignGridTool) Rotate(degrees float32, anchor fyne.Position) {
+	radians := float64(degrees) * math.Pi / 180
+	cos, sin := float32(math.Cos(radians)), float32(math.Sin(radians))
+	dx, dy := tool.Grid.OffsetX-anchor.X, tool.Grid.OffsetY-anchor.Y
+
+	tool.Grid.Rotation = float32(math.Mod(float64(tool.Grid.Rotation+degrees), 360))
+	tool.Grid.OffsetX = anchor.X + dx*cos - dy*sin
+	tool.Grid.OffsetY = anchor.Y + dx*sin + dy*cos
+
+	tool.update()
+}
+
+// SetKind switches between square and hex grids
+func (tool *AlignGridTool) SetKind(kind grid.Kind) {
+	tool.Grid.Type = string(kind)
+
+	tool.update()
+}
+
+// Reset starts the alignment over from the grid the table shows without map settings
+func (tool *AlignGridTool) Reset() {
+	zoom := float32(TableView.Zoom())
+	tool.Grid = mapFile.GridSettings{PixelsPerSquare: TableView.CellWidth / zoom}
+
+	tool.update()
+}
+
+// Save stores the grid next to the map and zooms so one map square is one square on the table
+func (tool *AlignGridTool) Save() {
+	grid := tool.Grid
+	tool.File.Metadata.Grid = &grid
+	if saveError := tool.File.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+
+	fmt.Println("Grid Aligned : ", grid.PixelsPerSquare, grid.OffsetX, grid.OffsetY, grid.Rotation)
+	tool.previous = &grid
+	SetActiveTool(nil)
+	TableView.SetZoomSliderRange()
+}
+
+func (tool *AlignGridTool) update() {
+	TableView.RedrawGrid()
+	syncGMView()
+
+	if tool.onGridMoved != nil {
+		tool.onGridMoved()
+	}
+}
+
+// Deactivate puts back the saved grid and closes the panel
+func (tool *AlignGridTool) Deactivate() {
+	TableView.MapGrid = tool.previous
+	TableView.SetGridVisible(tool.wasVisible)
+	TableView.RedrawGrid()
+	syncGMView()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// ShowAlignGrid opens the align grid panel for the map on the table
+func ShowAlignGrid(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to align the grid to")
+		return false
+	}
+
+	tool := NewAlignGridTool(TableView.CurrentMapFile, nil)
+	AlignGridOverlay = BuildAlignGridOverlay(tool)
+	AddToolOverlay(AlignGridOverlay)
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(AlignGridOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildAlignGridOverlay(tool *AlignGridTool) *fyne.Container {
+
+	readout := widget.NewLabel("")
+	tool.onGridMoved = func() {
+		readout.SetText(strconv.FormatFloat(float64(tool.Grid.PixelsPerSquare), 'f', 1, 32) + " pixels per cell\n" +
+			"offset " + strconv.FormatFloat(float64(tool.Grid.OffsetX), 'f', 1, 32) + ", " + strconv.FormatFloat(float64(tool.Grid.OffsetY), 'f', 1, 32) + "\n" +
+			"rotation " + strconv.FormatFloat(float64(tool.Grid.Rotation), 'f', 1, 32) + "°")
+	}
+	tool.onGridMoved()
+
+	center := func() fyne.Position {
+		screen := ToolScreenSize()
+		return ToolView().ScreenToMap(fyne.NewPos(screen.Width/2, screen.Height/2))
+	}
+
+	nudgeButtons := container.NewGridWithColumns(4,
+		widget.NewButton("←", func() { tool.Move(-AlignGridOffsetStep, 0) }),
+		widget.NewButton("→", func() { tool.Move(AlignGridOffsetStep, 0) }),
+		widget.NewButton("↑", func() { tool.Move(0, -AlignGridOffsetStep) }),
+		widget.NewButton("↓", func() { tool.Move(0, AlignGridOffsetStep) }),
+		widget.NewButton("Smaller", func() {
+			tool.Scale((tool.Grid.PixelsPerSquare-AlignGridSizeStep)/tool.Grid.PixelsPerSquare, center())
+		}),
+		widget.NewButton("Larger", func() {
+			tool.Scale((tool.Grid.PixelsPerSquare+AlignGridSizeStep)/tool.Grid.PixelsPerSquare, center())
+		}),
+		widget.NewButton("⟲", func() { tool.Rotate(-AlignGridRotationStep, center()) }),
+		widget.NewButton("⟳", func() { tool.Rotate(AlignGridRotationStep, center()) }),
+	)
+
+	kindSelect := widget.NewSelect(AlignGridKindNames, func(name string) {
+		tool.SetKind(AlignGridKinds[name])
+	})
+	for _, name := range AlignGridKindNames {
+		if AlignGridKinds[name] == grid.ParseKind(tool.Grid.Type) {
+			kindSelect.SetSelected(name)
+		}
+	}
+
+	saveButton := widget.NewButton("Save", tool.Save)
+	saveButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabel("Drag to move the grid, pinch to size it\nand twist to rotate it."),
+		kindSelect,
+		readout,
+		nudgeButtons,
+		container.NewGridWithColumns(3,
+			widget.NewButton("Cancel", func() { SetActiveTool(nil) }),
+			widget.NewButton("Reset", tool.Reset),
+			saveButton,
+		),
+	)
+	panel.Resize(fyne.NewSize(AlignGridPanelWidth, AlignGridPanelHeight))
+	panel.Move(ToolPanelPosition(AlignGridPanelHeight))
+
+	return container.NewWithoutLayout(panel)
+}
@@ -0,0 +1,222 @@
+package aoe
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/measure"
+)
+
+// Shape is the form of an area of effect
+type Shape string
+
+const (
+	// Cone spreads from its origin, as wide at its end as it is long
+	Cone Shape = "cone"
+	// Sphere and Cylinder cover a circle around their origin, Size is the radius
+	Sphere   Shape = "sphere"
+	Cylinder Shape = "cylinder"
+	// Line runs from its origin for Size, one cell wide unless Width is set
+	Line Shape = "line"
+	// Cube is a square centered on its origin, Size is the length of a side
+	Cube Shape = "cube"
+)
+
+// Shapes lists the shapes in the order they are offered to the GM
+var Shapes = []Shape{Cone, Sphere, Cylinder, Line, Cube}
+
+// Coverage is the rule for which grid cells an area of effect affects
+type Coverage string
+
+const (
+	// Center affects the cells whose middle is inside the area
+	Center Coverage = "center"
+	// Overlap affects every cell the area covers any part of
+	Overlap Coverage = "overlap"
+)
+
+var Coverages = []Coverage{Center, Overlap}
+
+// circleCorners is how many corners the polygon of a sphere or cylinder has
+const circleCorners int = 48
+
+// overlapMargin is how far into a cell an area has to reach to count as overlapping it, as a share of the cell,
+// so areas that only touch a cell's edge don't affect it
+const overlapMargin float32 = 0.02
+
+// ParseShape returns the shape with the given name, falling back to a sphere
+func ParseShape(name string) Shape {
+	for _, shape := range Shapes {
+		if string(shape) == name {
+			return shape
+		}
+	}
+
+	return Sphere
+}
+
+// ParseCoverage returns the coverage with the given name, falling back to Center
+func ParseCoverage(name string) Coverage {
+	for _, coverage := range Coverages {
+		if string(coverage) == name {
+			return coverage
+		}
+	}
+
+	return Center
+}
+
+// DefaultSize returns the size a new template of a shape starts at in a unit, metric sizes are rounded to half meters
+func DefaultSize(shape Shape, unit measure.Unit) float32 {
+	feet := float64(30)
+	switch shape {
+	case Cone, Cube:
+		feet = 15
+	case Sphere:
+		feet = 20
+	case Cylinder:
+		feet = 10
+	}
+
+	if unit == measure.Meters {
+		return float32(math.Round(feet*measure.MetersPerFoot*2) / 2)
+	}
+
+	return float32(feet)
+}
+
+// Rotates returns true if the direction of a shape matters
+func Rotates(shape Shape) bool {
+	return shape == Cone || shape == Line || shape == Cube
+}
+
+// Footprint returns the area a template covers in map image pixels, converting its size through the scale of the grid
+func Footprint(template mapFile.Template, g grid.Grid, scale measure.Scale) geometry.Polygon {
+	pixels := float32(0)
+	if scale.Distance > 0 {
+		pixels = g.CellSize() / float32(scale.Distance)
+	}
+
+	size := template.Size * pixels
+	radians := float64(template.Angle) * math.Pi / 180
+	along := fyne.NewPos(float32(math.Cos(radians)), float32(math.Sin(radians)))
+	across := fyne.NewPos(-along.Y, along.X)
+	at := func(forward float32, sideways float32) fyne.Position {
+		return template.Origin.Add(fyne.NewPos(along.X*forward+across.X*sideways, along.Y*forward+across.Y*sideways))
+	}
+
+	switch ParseShape(template.Shape) {
+	case Cone:
+		return geometry.Polygon{template.Origin, at(size, -size/2), at(size, size/2)}
+	case Line:
+		width := g.CellSize()
+		if template.Width > 0 {
+			width = template.Width * pixels
+		}
+		return geometry.Polygon{at(0, -width/2), at(size, -width/2), at(size, width/2), at(0, width/2)}
+	case Cube:
+		return geometry.Polygon{at(-size/2, -size/2), at(size/2, -size/2), at(size/2, size/2), at(-size/2, size/2)}
+	}
+
+	return geometry.CirclePolygon(template.Origin, size, circleCorners)
+}
+
+// Handle returns where a template is grabbed to turn it: the far end of cones and lines, the middle of a cube's side
+func Handle(template mapFile.Template, g grid.Grid, scale measure.Scale) fyne.Position {
+	if !Rotates(ParseShape(template.Shape)) {
+		return template.Origin
+	}
+
+	footprint := Footprint(template, g, scale)
+
+	return midpoint(footprint[1], footprint[2])
+}
+
+// Cells returns the grid cells an area affects under a coverage rule
+func Cells(g grid.Grid, footprint geometry.Polygon, coverage Coverage) []grid.Cell {
+	if len(footprint) < 3 || g.CellSize() <= 0 {
+		return nil
+	}
+
+	// every cell the bounds touch is found by sampling them at half a cell
+	min, max := footprint.Bounds()
+	step := g.CellSize() / 2
+	seen := make(map[grid.Cell]bool)
+	var cells []grid.Cell
+	for y := min.Y; ; y += step {
+		y = fyne.Min(y, max.Y)
+		for x := min.X; ; x += step {
+			x = fyne.Min(x, max.X)
+			cell := g.CellAt(fyne.NewPos(x, y))
+			if !seen[cell] {
+				seen[cell] = true
+				if affects(g, cell, footprint, coverage) {
+					cells = append(cells, cell)
+				}
+			}
+			if x >= max.X {
+				break
+			}
+		}
+		if y >= max.Y {
+			break
+		}
+	}
+
+	return cells
+}
+
+func affects(g grid.Grid, cell grid.Cell, footprint geometry.Polygon, coverage Coverage) bool {
+	center := g.Center(cell)
+	if coverage != Overlap {
+		return footprint.Contains(center)
+	}
+
+	outline := g.Outline(cell)
+	for i, corner := range outline {
+		outline[i] = fyne.NewPos(corner.X+(center.X-corner.X)*overlapMargin, corner.Y+(center.Y-corner.Y)*overlapMargin)
+	}
+
+	return overlaps(outline, footprint)
+}
+
+// overlaps returns true if two polygons share any area
+func overlaps(a geometry.Polygon, b geometry.Polygon) bool {
+	for _, corner := range a {
+		if b.Contains(corner) {
+			return true
+		}
+	}
+	for _, corner := range b {
+		if a.Contains(corner) {
+			return true
+		}
+	}
+
+	for i, j := 0, len(a)-1; i < len(a); j, i = i, i+1 {
+		for k, l := 0, len(b)-1; k < len(b); l, k = k, k+1 {
+			if crosses(a[j], a[i], b[l], b[k]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// crosses returns true if the segments from a1 to a2 and from b1 to b2 cross each other
+func crosses(a1 fyne.Position, a2 fyne.Position, b1 fyne.Position, b2 fyne.Position) bool {
+	side := func(p fyne.Position, q fyne.Position, r fyne.Position) float32 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+
+	return side(a1, a2, b1)*side(a1, a2, b2) < 0 && side(b1, b2, a1)*side(b1, b2, a2) < 0
+}
+
+func midpoint(a fyne.Position, b fyne.Position) fyne.Position {
+	return fyne.NewPos((a.X+b.X)/2, (a.Y+b.Y)/2)
+}
@@ -0,0 +1,73 @@
+package aoe
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/measure"
+)
+
+func TestSphereCoverage(t *testing.T) {
+	square := grid.New(grid.Square, fyne.NewPos(0, 0), 50, 50, 0)
+	fireball := mapFile.Template{Shape: string(Sphere), Origin: fyne.NewPos(500, 500), Size: 20}
+	footprint := Footprint(fireball, square, measure.DefaultScale)
+
+	if cells := Cells(square, footprint, Center); len(cells) != 52 {
+		t.Errorf("expected a 20 ft sphere from a corner to cover 52 squares by their centers, got %d", len(cells))
+	}
+	if cells := Cells(square, footprint, Overlap); len(cells) != 60 {
+		t.Errorf("expected a 20 ft sphere from a corner to overlap 60 squares, got %d", len(cells))
+	}
+}
+
+func TestCubeTouchingEdgesIsNotOverlap(t *testing.T) {
+	square := grid.New(grid.Square, fyne.NewPos(0, 0), 50, 50, 0)
+	metric := measure.Scale{Distance: 1.5, Unit: measure.Meters}
+	cube := mapFile.Template{Shape: string(Cube), Origin: fyne.NewPos(100, 100), Size: 3}
+
+	for _, coverage := range Coverages {
+		if cells := Cells(square, Footprint(cube, square, metric), coverage); len(cells) != 4 {
+			t.Errorf("%s: expected a 3 m cube on a grid of 1.5 m squares to cover 4 squares, got %v", coverage, cells)
+		}
+	}
+}
+
+func TestConeFollowsItsAngle(t *testing.T) {
+	square := grid.New(grid.Square, fyne.NewPos(0, 0), 50, 50, 0)
+	cone := mapFile.Template{Shape: string(Cone), Origin: fyne.NewPos(500, 525), Size: 15}
+
+	right := Cells(square, Footprint(cone, square, measure.DefaultScale), Center)
+	if len(right) == 0 {
+		t.Fatal("expected the cone to cover some squares")
+	}
+	for _, cell := range right {
+		if cell.Col < 10 || cell.Col > 12 {
+			t.Errorf("expected a cone pointing right to stay in the 3 columns in front of it, got %v", cell)
+		}
+	}
+
+	cone.Angle = 90
+	for _, cell := range Cells(square, Footprint(cone, square, measure.DefaultScale), Center) {
+		if cell.Row < 10 || cell.Row > 13 {
+			t.Errorf("expected a cone pointing down to stay below its origin, got %v", cell)
+		}
+	}
+
+	if handle := Handle(cone, square, measure.DefaultScale); handle.X < 499 || handle.X > 501 || handle.Y < 674 || handle.Y > 676 {
+		t.Errorf("expected the handle at the end of the cone, got %v", handle)
+	}
+}
+
+func TestHexCoverage(t *testing.T) {
+	hex := grid.New(grid.HexPointyTop, fyne.NewPos(0, 0), 50, 50, 0)
+	origin := hex.Center(grid.Cell{})
+	// a radius of one hex only just reaches the middles of the six neighbours, so the cylinder is a little larger
+	burst := mapFile.Template{Shape: string(Cylinder), Origin: origin, Size: 5.5}
+
+	if cells := Cells(hex, Footprint(burst, hex, measure.DefaultScale), Center); len(cells) != 7 {
+		t.Errorf("expected a hex and its neighbours, got %v", cells)
+	}
+}
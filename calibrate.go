@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/calibration"
+	"github.com/JonCSykes/DragonTable/mapView"
+	"github.com/JonCSykes/DragonTable/touch"
+	"github.com/JonCSykes/DragonTable/widgetExt"
+)
+
+const CalibrationPanelWidth float32 = 320
+const CalibrationPanelHeight float32 = 220
+
+var CalibrationLengths = map[string]float32{"1 inch": 1, "2 inches": 2, "6 inches": 6, "12 inches": 12}
+var CalibrationLengthNames = []string{"1 inch", "2 inches", "6 inches", "12 inches"}
+
+var Calibration *calibration.Calibration
+var CalibrationOverlay *fyne.Container
+var CalibrationPanel fyne.CanvasObject
+
+// LoadCalibration reads the stored display calibrations, falling back to an empty calibration
+func LoadCalibration() {
+	path, pathError := calibration.DefaultPath()
+	if pathError != nil {
+		fmt.Println(pathError)
+	}
+
+	var loadError error
+	Calibration, loadError = calibration.Load(path)
+	if loadError != nil {
+		fmt.Println(loadError)
+	}
+}
+
+// DisplayPixelsPerInch returns the calibrated density of this display, or the density from the screen size in the settings
+func DisplayPixelsPerInch() (float32, float32) {
+	if Calibration != nil {
+		if display, found := Calibration.Display(calibration.DisplayKey(ScreenWidth, ScreenHeight)); found {
+			return display.PixelsPerInchX, display.PixelsPerInchY
+		}
+	}
+
+	if Settings.Screen.WidthInches > 0 && Settings.Screen.HeightInches > 0 {
+		return float32(ScreenWidth) / Settings.Screen.WidthInches, float32(ScreenHeight) / Settings.Screen.HeightInches
+	}
+
+	return float32(ScreenWidth / mapView.ScreenDimensionWidth), float32(ScreenHeight / mapView.ScreenDimensionHeight)
+}
+
+// CalibrationTool keeps touches from moving the map while the calibration box is on screen
+type CalibrationTool struct {
+	OnDeactivate func()
+}
+
+// HandleTouch ignores touches, the calibration box is dragged through the regular pointer events
+func (tool *CalibrationTool) HandleTouch(event touch.Event) {
+}
+
+// Deactivate closes the calibration overlay
+func (tool *CalibrationTool) Deactivate() {
+	CalibrationOverlay.Hide()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// ShowCalibration opens the calibration overlay sized from the current calibration. The box stays on the table
+// where it is measured, its panel goes with the other tools.
+func ShowCalibration(onDeactivate func()) {
+	CalibrationOverlay, CalibrationPanel = BuildCalibrationOverlay()
+	mainContent.Add(CalibrationOverlay)
+	AddToolOverlay(CalibrationPanel)
+
+	SetActiveTool(&CalibrationTool{OnDeactivate: func() {
+		mainContent.Remove(CalibrationOverlay)
+		RemoveToolOverlay(CalibrationPanel)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}})
+}
+
+func BuildCalibrationOverlay() (*fyne.Container, fyne.CanvasObject) {
+
+	inches := CalibrationLengths[CalibrationLengthNames[0]]
+	pixelsPerInchX, pixelsPerInchY := DisplayPixelsPerInch()
+
+	background := canvas.NewRectangle(color.NRGBA{R: 0, G: 0, B: 0, A: 140})
+	background.Resize(fyne.NewSize(float32(ScreenWidth), float32(ScreenHeight)))
+
+	readout := widget.NewLabel("")
+	updateReadout := func(size fyne.Size) {
+		readout.SetText(strconv.FormatFloat(float64(size.Width/inches), 'f', 1, 32) + " x " + strconv.FormatFloat(float64(size.Height/inches), 'f', 1, 32) + " pixels per inch")
+	}
+
+	box := widgetExt.NewCalibrationBox(fyne.NewSize(pixelsPerInchX*inches, pixelsPerInchY*inches), updateReadout)
+	box.Move(fyne.NewPos(float32(ScreenWidth)/3, float32(ScreenHeight)/4))
+	updateReadout(box.Size())
+
+	lengthSelect := widget.NewSelect(CalibrationLengthNames, func(name string) {
+		previous := inches
+		inches = CalibrationLengths[name]
+		box.Resize(fyne.NewSize(box.Size().Width/previous*inches, box.Size().Height/previous*inches))
+		updateReadout(box.Size())
+	})
+	lengthSelect.SetSelected(CalibrationLengthNames[0])
+
+	saveButton := widget.NewButton("Save", func() {
+		display := calibration.Display{PixelsPerInchX: box.Size().Width / inches, PixelsPerInchY: box.Size().Height / inches}
+		Calibration.SetDisplay(calibration.DisplayKey(ScreenWidth, ScreenHeight), display)
+		if saveError := Calibration.Save(); saveError != nil {
+			fmt.Println(saveError)
+		}
+
+		fmt.Println("Calibrated : ", display.PixelsPerInchX, display.PixelsPerInchY)
+		TableView.SetCellSize(display.PixelsPerInchX, display.PixelsPerInchY)
+		syncGMView()
+		SetActiveTool(nil)
+	})
+	saveButton.Importance = widget.HighImportance
+
+	cancelButton := widget.NewButton("Cancel", func() {
+		SetActiveTool(nil)
+	})
+
+	panel := container.NewVBox(
+		widget.NewLabel("Place a ruler or a mini of known size on the table\nand drag the box until it matches."),
+		lengthSelect,
+		readout,
+		container.NewGridWithColumns(2, cancelButton, saveButton),
+	)
+	panel.Resize(fyne.NewSize(CalibrationPanelWidth, CalibrationPanelHeight))
+	panel.Move(ToolPanelPosition(CalibrationPanelHeight))
+
+	return container.NewWithoutLayout(background, box), panel
+}
@@ -0,0 +1,87 @@
+package calibration
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const AppConfigDirName string = "DragonTable"
+const CalibrationFileName string = "calibration.json"
+
+// Display holds the measured pixel density of one display
+type Display struct {
+	PixelsPerInchX float32 `json:"pixelsPerInchX"`
+	PixelsPerInchY float32 `json:"pixelsPerInchY"`
+}
+
+// Calibration is the set of calibrated displays, keyed by DisplayKey
+type Calibration struct {
+	Path     string             `json:"-"`
+	Displays map[string]Display `json:"displays"`
+}
+
+// DisplayKey identifies a display by its resolution, which is all that is known about it at startup
+func DisplayKey(screenWidth int, screenHeight int) string {
+	return strconv.Itoa(screenWidth) + "x" + strconv.Itoa(screenHeight)
+}
+
+// DefaultPath returns the calibration file in the user config directory
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, AppConfigDirName, CalibrationFileName), nil
+}
+
+// Load reads the calibration file at path, a missing file gives an empty calibration
+func Load(path string) (*Calibration, error) {
+	calibration := &Calibration{Path: path, Displays: make(map[string]Display)}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return calibration, nil
+	} else if err != nil {
+		return calibration, err
+	}
+
+	if err = json.Unmarshal(data, calibration); err != nil {
+		return calibration, err
+	}
+	if calibration.Displays == nil {
+		calibration.Displays = make(map[string]Display)
+	}
+
+	return calibration, nil
+}
+
+// Save writes the calibration back to its file
+func (calibration *Calibration) Save() error {
+	data, err := json.MarshalIndent(calibration, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(calibration.Path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(calibration.Path, data, 0644)
+}
+
+// Display returns the stored calibration for the display with the given key
+func (calibration *Calibration) Display(key string) (Display, bool) {
+	display, found := calibration.Displays[key]
+
+	return display, found && display.PixelsPerInchX > 0 && display.PixelsPerInchY > 0
+}
+
+// SetDisplay stores the calibration for the display with the given key
+func (calibration *Calibration) SetDisplay(key string, display Display) {
+	calibration.Displays[key] = display
+}
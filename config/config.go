@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const AppConfigDirName string = "DragonTable"
+const SettingsFileName string = "settings.yaml"
+
+// ResourceDirName is the folder holding the icons, images and default maps
+const ResourceDirName string = "resources"
+
+// Settings are the app settings read from the settings file, anything left out of the file keeps its default
+type Settings struct {
+	Path string `yaml:"-"`
+
+	// MapDirectories are read for maps, when empty the maps folder in the resources is used
+	MapDirectories []string `yaml:"mapDirectories"`
+	// Wallpaper is shown when no map is open, when empty the wallpaper in the resources is used
+	Wallpaper string `yaml:"wallpaper"`
+	// Resources is the folder with the icons and images, when empty it is looked for next to the executable
+	Resources string `yaml:"resources"`
+	// MapMemory is the number of megabytes of decoded maps kept in memory
+	MapMemory int `yaml:"mapMemory"`
+
+	Screen ScreenSettings `yaml:"screen"`
+	Grid   GridSettings   `yaml:"grid"`
+	Zoom   ZoomSettings   `yaml:"zoom"`
+	Touch  TouchSettings  `yaml:"touch"`
+}
+
+// ScreenSettings is the physical size of the table's screen, used for the grid until the display is calibrated
+type ScreenSettings struct {
+	WidthInches  float32 `yaml:"widthInches"`
+	HeightInches float32 `yaml:"heightInches"`
+}
+
+// GridSettings is how the grid looks when the app starts
+type GridSettings struct {
+	Visible   bool    `yaml:"visible"`
+	Color     string  `yaml:"color"`
+	Opacity   float32 `yaml:"opacity"`
+	LineWidth float32 `yaml:"lineWidth"`
+	Dash      float32 `yaml:"dash"`
+	Gap       float32 `yaml:"gap"`
+}
+
+// ZoomSettings limit the zoom slider, a Min of 0 lets the map be zoomed out until it fits the screen
+type ZoomSettings struct {
+	Min float64 `yaml:"min"`
+	Max float64 `yaml:"max"`
+}
+
+// TouchSettings choose the touch backend and how panning feels.
+// With DetectMiniatures set, contacts that stay still for MiniatureSeconds are taken for minis rather than fingers.
+type TouchSettings struct {
+	Backend          string  `yaml:"backend"`
+	PanSensitivity   float64 `yaml:"panSensitivity"`
+	PanFriction      float64 `yaml:"panFriction"`
+	DetectMiniatures bool    `yaml:"detectMiniatures"`
+	MiniatureSeconds float64 `yaml:"miniatureSeconds"`
+}
+
+// Default returns the settings used when there is no settings file
+func Default() *Settings {
+	return &Settings{
+		MapMemory: 1024,
+		Screen:    ScreenSettings{WidthInches: 30, HeightInches: 16},
+		Grid:      GridSettings{Color: "#383838", Opacity: 1, LineWidth: 1},
+		Zoom:      ZoomSettings{Max: 2},
+		Touch:     TouchSettings{Backend: "auto", PanSensitivity: 0.5, PanFriction: 4, DetectMiniatures: true, MiniatureSeconds: 3},
+	}
+}
+
+// DefaultPath returns the settings file in the user config directory
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, AppConfigDirName, SettingsFileName), nil
+}
+
+// Load reads the settings file at path over the current settings, a missing file leaves them as they are.
+// Relative paths in the file are taken from the folder the file is in.
+func (settings *Settings) Load(path string) error {
+	settings.Path = path
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err = yaml.Unmarshal(data, settings); err != nil {
+		return err
+	}
+
+	folder := filepath.Dir(path)
+	for i := range settings.MapDirectories {
+		settings.MapDirectories[i] = resolve(folder, settings.MapDirectories[i])
+	}
+	settings.Wallpaper = resolve(folder, settings.Wallpaper)
+	settings.Resources = resolve(folder, settings.Resources)
+
+	return nil
+}
+
+// Save writes the settings to their file, creating its folder if needed
+func (settings *Settings) Save() error {
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(settings.Path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(settings.Path, data, 0644)
+}
+
+// ResourceDir returns the resources folder: the Resources setting, the folder next to the executable,
+// or the one in the working directory when running from the source tree
+func (settings *Settings) ResourceDir() string {
+	if settings.Resources != "" {
+		return settings.Resources
+	}
+
+	if executable, err := os.Executable(); err == nil {
+		folder := filepath.Join(filepath.Dir(executable), ResourceDirName)
+		if info, statError := os.Stat(folder); statError == nil && info.IsDir() {
+			return folder
+		}
+	}
+
+	return ResourceDirName
+}
+
+// ResourcePath returns the path of a file in the resources folder, name uses "/" between folders
+func (settings *Settings) ResourcePath(name string) string {
+	return filepath.Join(settings.ResourceDir(), filepath.FromSlash(name))
+}
+
+// MapPaths returns the folders maps are read from
+func (settings *Settings) MapPaths() []string {
+	if len(settings.MapDirectories) > 0 {
+		return settings.MapDirectories
+	}
+
+	return []string{settings.ResourcePath("maps")}
+}
+
+// WallpaperPath returns the image shown when no map is open
+func (settings *Settings) WallpaperPath() string {
+	if settings.Wallpaper != "" {
+		return settings.Wallpaper
+	}
+
+	return settings.ResourcePath("images/dragontable.jpg")
+}
+
+func resolve(folder string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(folder, path)
+}
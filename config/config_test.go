@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKeepsDefaultsForMissingSettings(t *testing.T) {
+	folder := t.TempDir()
+	path := filepath.Join(folder, SettingsFileName)
+	data := "mapDirectories:\n  - campaign\n  - /srv/maps\ngrid:\n  visible: true\nzoom:\n  max: 4\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := Default()
+	if err := settings.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := settings.MapPaths()
+	if len(paths) != 2 || paths[0] != filepath.Join(folder, "campaign") || paths[1] != "/srv/maps" {
+		t.Errorf("expected relative map folders to be taken from the settings folder, got %v", paths)
+	}
+	if !settings.Grid.Visible || settings.Zoom.Max != 4 {
+		t.Error("expected the settings in the file to be read")
+	}
+	if settings.Grid.Color != Default().Grid.Color || settings.Touch.Backend != Default().Touch.Backend {
+		t.Error("expected settings missing from the file to keep their defaults")
+	}
+}
+
+func TestSaveWritesSettingsThatLoadBack(t *testing.T) {
+	settings := Default()
+	settings.Path = filepath.Join(t.TempDir(), AppConfigDirName, SettingsFileName)
+	settings.Screen.WidthInches = 36.6
+	settings.Resources = "/opt/dragontable/resources"
+
+	if err := settings.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := Default()
+	if err := loaded.Load(settings.Path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Screen.WidthInches != 36.6 || loaded.ResourcePath("icons/bars-solid.svg") != filepath.Join("/opt/dragontable/resources", "icons", "bars-solid.svg") {
+		t.Errorf("expected the saved settings back, got %+v", loaded)
+	}
+}
@@ -0,0 +1,200 @@
+package fog
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// MaxMaskSize is the largest width or height of a mask in pixels, bigger maps share a mask pixel between several map pixels
+const MaxMaskSize int = 2048
+
+// Fogged and Revealed are the mask values for hidden and visible map pixels
+const Fogged uint8 = 255
+const Revealed uint8 = 0
+
+// Mask records which parts of a map the players can see. Positions are in map image pixels.
+type Mask struct {
+	// Scale is the number of map pixels covered by one mask pixel
+	Scale float32
+
+	alpha *image.Alpha
+	mutex sync.RWMutex
+}
+
+// NewMask returns a mask for a map of the given size with everything hidden
+func NewMask(mapWidth int, mapHeight int) *Mask {
+	scale := float32(1)
+	if largest := math.Max(float64(mapWidth), float64(mapHeight)); largest > float64(MaxMaskSize) {
+		scale = float32(largest / float64(MaxMaskSize))
+	}
+
+	width := int(math.Ceil(float64(float32(mapWidth) / scale)))
+	height := int(math.Ceil(float64(float32(mapHeight) / scale)))
+
+	mask := &Mask{Scale: scale, alpha: image.NewAlpha(image.Rect(0, 0, width, height))}
+	mask.Fill(false)
+
+	return mask
+}
+
+// Decode reads a mask saved with Encode for a map of the given size
+func Decode(data []byte, mapWidth int, mapHeight int) (*Mask, error) {
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	mask := NewMask(mapWidth, mapHeight)
+	bounds, decodedBounds := mask.alpha.Bounds(), decoded.Bounds()
+
+	// the map may have been replaced by one of a different size, stretch the saved mask over it
+	scaleX := float64(decodedBounds.Dx()) / float64(bounds.Dx())
+	scaleY := float64(decodedBounds.Dy()) / float64(bounds.Dy())
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			_, _, _, a := decoded.At(decodedBounds.Min.X+int(float64(x)*scaleX), decodedBounds.Min.Y+int(float64(y)*scaleY)).RGBA()
+			mask.alpha.Pix[y*mask.alpha.Stride+x] = uint8(a >> 8)
+		}
+	}
+
+	return mask, nil
+}
+
+// Encode saves the mask as a PNG
+func (mask *Mask) Encode() ([]byte, error) {
+	mask.mutex.RLock()
+	defer mask.mutex.RUnlock()
+
+	var buffer bytes.Buffer
+	err := png.Encode(&buffer, mask.alpha)
+
+	return buffer.Bytes(), err
+}
+
+// Size returns the mask's width and height in mask pixels
+func (mask *Mask) Size() (int, int) {
+	return mask.alpha.Bounds().Dx(), mask.alpha.Bounds().Dy()
+}
+
+// At returns the fog at a mask pixel, pixels outside the mask are fogged
+func (mask *Mask) At(x int, y int) uint8 {
+	mask.mutex.RLock()
+	defer mask.mutex.RUnlock()
+
+	if !(image.Point{X: x, Y: y}).In(mask.alpha.Rect) {
+		return Fogged
+	}
+
+	return mask.alpha.Pix[y*mask.alpha.Stride+x]
+}
+
+// Read calls read with the mask pixels while they can't change, for drawing the whole mask without locking every pixel
+func (mask *Mask) Read(read func(alpha *image.Alpha)) {
+	mask.mutex.RLock()
+	defer mask.mutex.RUnlock()
+
+	read(mask.alpha)
+}
+
+// IsFogged returns true if the map position is hidden from the players
+func (mask *Mask) IsFogged(position fyne.Position) bool {
+	return mask.At(int(position.X/mask.Scale), int(position.Y/mask.Scale)) != Revealed
+}
+
+// Fill reveals or hides the whole map
+func (mask *Mask) Fill(reveal bool) {
+	mask.mutex.Lock()
+	defer mask.mutex.Unlock()
+
+	value := valueFor(reveal)
+	for i := range mask.alpha.Pix {
+		mask.alpha.Pix[i] = value
+	}
+}
+
+// Stroke reveals or hides everything within radius of the segment from a to b, a dot when they are the same
+func (mask *Mask) Stroke(from fyne.Position, to fyne.Position, radius float32, reveal bool) {
+	min := fyne.NewPos(fyne.Min(from.X, to.X)-radius, fyne.Min(from.Y, to.Y)-radius)
+	max := fyne.NewPos(fyne.Max(from.X, to.X)+radius, fyne.Max(from.Y, to.Y)+radius)
+
+	mask.paint(min, max, reveal, func(position fyne.Position) bool {
+		return geometry.SegmentDistance(position, from, to) <= radius
+	})
+}
+
+// Rect reveals or hides the rectangle spanned by two opposite corners
+func (mask *Mask) Rect(corner1 fyne.Position, corner2 fyne.Position, reveal bool) {
+	min, max := geometry.RectPolygon(corner1, corner2).Bounds()
+
+	mask.paint(min, max, reveal, func(fyne.Position) bool {
+		return true
+	})
+}
+
+// Polygon reveals or hides the inside of a polygon
+func (mask *Mask) Polygon(polygon geometry.Polygon, reveal bool) {
+	if len(polygon) < 3 {
+		return
+	}
+
+	mask.mutex.Lock()
+	defer mask.mutex.Unlock()
+
+	// fill row by row between the edges, testing every pixel is too slow for the large polygons line of sight makes
+	value := valueFor(reveal)
+	min, max := polygon.Bounds()
+	width := mask.alpha.Rect.Max.X
+	fromY := int(math.Max(0, math.Floor(float64(min.Y/mask.Scale))))
+	toY := int(math.Min(float64(mask.alpha.Rect.Max.Y-1), math.Ceil(float64(max.Y/mask.Scale))))
+
+	for y := fromY; y <= toY; y++ {
+		crossings := polygon.Crossings((float32(y) + 0.5) * mask.Scale)
+
+		for i := 0; i+1 < len(crossings); i += 2 {
+			// the pixels whose centers fall between the two crossings
+			fromX := int(math.Max(0, math.Ceil(float64(crossings[i]/mask.Scale-0.5))))
+			toX := int(math.Min(float64(width), math.Ceil(float64(crossings[i+1]/mask.Scale-0.5))))
+			row := mask.alpha.Pix[y*mask.alpha.Stride:]
+			for x := fromX; x < toX; x++ {
+				row[x] = value
+			}
+		}
+	}
+}
+
+// paint sets the mask pixels between min and max whose centers are inside the shape
+func (mask *Mask) paint(min fyne.Position, max fyne.Position, reveal bool, inside func(fyne.Position) bool) {
+	mask.mutex.Lock()
+	defer mask.mutex.Unlock()
+
+	value := valueFor(reveal)
+	bounds := mask.alpha.Rect
+	fromX := int(math.Max(0, math.Floor(float64(min.X/mask.Scale))))
+	fromY := int(math.Max(0, math.Floor(float64(min.Y/mask.Scale))))
+	toX := int(math.Min(float64(bounds.Max.X-1), math.Ceil(float64(max.X/mask.Scale))))
+	toY := int(math.Min(float64(bounds.Max.Y-1), math.Ceil(float64(max.Y/mask.Scale))))
+
+	for y := fromY; y <= toY; y++ {
+		for x := fromX; x <= toX; x++ {
+			center := fyne.NewPos((float32(x)+0.5)*mask.Scale, (float32(y)+0.5)*mask.Scale)
+			if inside(center) {
+				mask.alpha.Pix[y*mask.alpha.Stride+x] = value
+			}
+		}
+	}
+}
+
+func valueFor(reveal bool) uint8 {
+	if reveal {
+		return Revealed
+	}
+
+	return Fogged
+}
@@ -0,0 +1,58 @@
+package fog
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+func TestRevealAndHide(t *testing.T) {
+	mask := NewMask(1000, 800)
+
+	if !mask.IsFogged(fyne.NewPos(500, 400)) {
+		t.Fatal("expected a new mask to hide the whole map")
+	}
+
+	mask.Stroke(fyne.NewPos(100, 100), fyne.NewPos(300, 100), 20, true)
+	mask.Rect(fyne.NewPos(600, 600), fyne.NewPos(500, 500), true)
+	mask.Polygon(geometry.Polygon{fyne.NewPos(800, 100), fyne.NewPos(900, 300), fyne.NewPos(700, 300)}, true)
+
+	for _, revealed := range []fyne.Position{{X: 200, Y: 110}, {X: 550, Y: 550}, {X: 800, Y: 250}} {
+		if mask.IsFogged(revealed) {
+			t.Errorf("expected %v to be revealed", revealed)
+		}
+	}
+	for _, fogged := range []fyne.Position{{X: 200, Y: 130}, {X: 450, Y: 550}, {X: 710, Y: 110}} {
+		if !mask.IsFogged(fogged) {
+			t.Errorf("expected %v to be fogged", fogged)
+		}
+	}
+
+	mask.Stroke(fyne.NewPos(550, 550), fyne.NewPos(550, 550), 10, false)
+	if !mask.IsFogged(fyne.NewPos(550, 550)) {
+		t.Error("expected the brush to hide the map again")
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	mask := NewMask(5000, 2500)
+	if mask.Scale <= 1 {
+		t.Fatalf("expected a large map to use a scaled mask, got scale %.2f", mask.Scale)
+	}
+	mask.Rect(fyne.NewPos(1000, 1000), fyne.NewPos(2000, 1500), true)
+
+	data, err := mask.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Decode(data, 5000, 2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IsFogged(fyne.NewPos(1500, 1200)) || !decoded.IsFogged(fyne.NewPos(2500, 1200)) {
+		t.Error("expected the decoded mask to match the saved one")
+	}
+}
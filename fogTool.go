@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/fog"
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const FogPanelWidth float32 = 320
+const FogPanelHeight float32 = 400
+const DefaultFogBrushRadius float32 = 60
+const MinFogBrushRadius float32 = 10
+const MaxFogBrushRadius float32 = 300
+
+const FogBrush string = "Brush"
+const FogRectangle string = "Rectangle"
+const FogPolygon string = "Polygon"
+const FogReveal string = "Reveal"
+const FogHide string = "Hide"
+
+var FogOverlay *fyne.Container
+
+var fogPreviewColor = color.NRGBA{R: 120, G: 180, B: 255, A: 90}
+var fogPreviewLineColor = color.NRGBA{R: 120, G: 180, B: 255, A: 255}
+
+// fogDrag is a finger on the table, painting, drawing a rectangle or about to tap a corner of a polygon
+type fogDrag struct {
+	start fyne.Position
+	// last is where the brush painted to so far, in map pixels
+	last  fyne.Position
+	moved bool
+}
+
+// FogTool lets the GM reveal and hide parts of the map with a brush, rectangles or polygons.
+// Shapes are drawn in screen positions and painted onto the mask in map pixels.
+type FogTool struct {
+	Shape        string
+	Reveal       bool
+	BrushRadius  float32
+	OnDeactivate func()
+
+	panel    fyne.CanvasObject
+	preview  *fyne.Container
+	drags    map[int]*fogDrag
+	vertices []fyne.Position
+}
+
+// NewFogTool starts editing the fog of the map on the table
+func NewFogTool(onDeactivate func()) *FogTool {
+	return &FogTool{
+		Shape:        FogBrush,
+		Reveal:       true,
+		BrushRadius:  DefaultFogBrushRadius,
+		OnDeactivate: onDeactivate,
+		preview:      container.NewWithoutLayout(),
+		drags:        make(map[int]*fogDrag),
+	}
+}
+
+// HandleTouch paints with every finger for the brush, or draws the rectangles or the polygon being edited.
+// Contacts that start on the panel are left to its buttons.
+func (tool *FogTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+	mask := TableView.Fog
+	if mask == nil {
+		return
+	}
+
+	drag, dragging := tool.drags[event.ID]
+	switch event.Status {
+	case touch.InitialTouch:
+		if touchesObject(tool.panel, position) {
+			return
+		}
+		drag = &fogDrag{start: position, last: ToolView().ScreenToMap(position)}
+		tool.drags[event.ID] = drag
+		if tool.Shape == FogBrush {
+			mask.Stroke(drag.last, drag.last, tool.brushRadius(), tool.Reveal)
+			RedrawFog()
+		}
+	case touch.StreamTouch:
+		if !dragging {
+			return
+		}
+		if geometry.Distance(drag.start, position) > VisionTapDistance {
+			drag.moved = true
+		}
+
+		switch tool.Shape {
+		case FogBrush:
+			mapPosition := ToolView().ScreenToMap(position)
+			mask.Stroke(drag.last, mapPosition, tool.brushRadius(), tool.Reveal)
+			drag.last = mapPosition
+			RedrawFog()
+		case FogRectangle:
+			drag.last = position
+			tool.showRectanglePreview()
+		}
+	case touch.UnTouch:
+		if !dragging {
+			return
+		}
+		delete(tool.drags, event.ID)
+
+		switch tool.Shape {
+		case FogBrush:
+			if len(tool.drags) == 0 {
+				SaveFog()
+			}
+		case FogRectangle:
+			tool.showRectanglePreview()
+			if !drag.moved {
+				return
+			}
+			mask.Rect(ToolView().ScreenToMap(drag.start), ToolView().ScreenToMap(position), tool.Reveal)
+			RedrawFog()
+			SaveFog()
+		case FogPolygon:
+			if !drag.moved {
+				tool.vertices = append(tool.vertices, position)
+				tool.showPolygonPreview()
+			}
+		}
+	}
+}
+
+// brushRadius returns the size of the brush in map pixels, so it paints the same size on screen at any zoom
+func (tool *FogTool) brushRadius() float32 {
+	return tool.BrushRadius / float32(ToolView().Zoom())
+}
+
+// showRectanglePreview outlines the rectangles being dragged out, last holds the far corner of each on screen
+func (tool *FogTool) showRectanglePreview() {
+	var objects []fyne.CanvasObject
+	for _, drag := range tool.drags {
+		if !drag.moved {
+			continue
+		}
+		min, max := geometry.RectPolygon(drag.start, drag.last).Bounds()
+		preview := canvas.NewRectangle(fogPreviewColor)
+		preview.Move(min)
+		preview.Resize(fyne.NewSize(max.X-min.X, max.Y-min.Y))
+		objects = append(objects, preview)
+	}
+
+	tool.showPreview(objects...)
+}
+
+// ClosePolygon reveals or hides the polygon tapped out so far
+func (tool *FogTool) ClosePolygon() {
+	if TableView.Fog != nil && len(tool.vertices) >= 3 {
+		var polygon geometry.Polygon
+		for _, vertex := range tool.vertices {
+			polygon = append(polygon, ToolView().ScreenToMap(vertex))
+		}
+
+		TableView.Fog.Polygon(polygon, tool.Reveal)
+		RedrawFog()
+		SaveFog()
+	}
+
+	tool.vertices = nil
+	tool.showPreview()
+}
+
+func (tool *FogTool) showPolygonPreview() {
+	var objects []fyne.CanvasObject
+	for i, vertex := range tool.vertices {
+		dot := canvas.NewCircle(fogPreviewLineColor)
+		dot.Move(vertex.Subtract(fyne.NewPos(6, 6)))
+		dot.Resize(fyne.NewSize(12, 12))
+		objects = append(objects, dot)
+
+		if i > 0 {
+			line := canvas.NewLine(fogPreviewLineColor)
+			line.StrokeWidth = 2
+			line.Position1, line.Position2 = tool.vertices[i-1], vertex
+			objects = append(objects, line)
+		}
+	}
+
+	tool.showPreview(objects...)
+}
+
+func (tool *FogTool) showPreview(objects ...fyne.CanvasObject) {
+	tool.preview.Objects = objects
+	tool.preview.Refresh()
+}
+
+// SetShape switches between the brush, rectangle and polygon, dropping anything half drawn
+func (tool *FogTool) SetShape(shape string) {
+	tool.Shape = shape
+	tool.drags = make(map[int]*fogDrag)
+	tool.vertices = nil
+	tool.showPreview()
+}
+
+// Deactivate closes the fog panel
+func (tool *FogTool) Deactivate() {
+	tool.drags = make(map[int]*fogDrag)
+	tool.showPreview()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// SetFog covers the map on the table with a fog mask, nil removes the fog
+func SetFog(mask *fog.Mask) {
+	TableView.SetFog(mask)
+	syncGMView()
+}
+
+// RedrawFog shows changes to the fog mask on the table and the GM screen
+func RedrawFog() {
+	TableView.RedrawFog()
+
+	if GMView != nil {
+		GMView.RedrawFog()
+	}
+}
+
+// SaveFog stores the fog of the map on the table next to the map
+func SaveFog() {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	file.Metadata.Fog = nil
+	file.Metadata.FogRemoved = TableView.Fog == nil
+	if TableView.Fog != nil {
+		data, encodeError := TableView.Fog.Encode()
+		if encodeError != nil {
+			fmt.Println(encodeError)
+			return
+		}
+		file.Metadata.Fog = data
+	}
+
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// CoverNewMap hides the whole of a map the first time it is shown on the table, unless the GM removed its fog
+func CoverNewMap(file *mapFile.MapFile) {
+	if TableView.CurrentMapFile != file || TableView.Fog != nil || file.Metadata.FogRemoved {
+		return
+	}
+
+	TableView.SetFog(fog.NewMask(file.Width, file.Height))
+}
+
+// ShowFogTool opens the fog panel for the map on the table
+func ShowFogTool(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to edit the fog of")
+		return false
+	}
+
+	tool := NewFogTool(nil)
+	FogOverlay = BuildFogOverlay(tool)
+	AddToolOverlay(FogOverlay)
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(FogOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildFogOverlay(tool *FogTool) *fyne.Container {
+
+	file := TableView.CurrentMapFile
+
+	shapeRadio := widget.NewRadioGroup([]string{FogBrush, FogRectangle, FogPolygon}, tool.SetShape)
+	shapeRadio.Horizontal = true
+	shapeRadio.SetSelected(tool.Shape)
+
+	modeRadio := widget.NewRadioGroup([]string{FogReveal, FogHide}, func(mode string) {
+		tool.Reveal = mode != FogHide
+	})
+	modeRadio.Horizontal = true
+	modeRadio.SetSelected(FogReveal)
+
+	brushSlider := widget.NewSlider(float64(MinFogBrushRadius), float64(MaxFogBrushRadius))
+	brushSlider.SetValue(float64(tool.BrushRadius))
+	brushSlider.OnChanged = func(value float64) {
+		tool.BrushRadius = float32(value)
+	}
+
+	fill := func(reveal bool) {
+		if TableView.Fog == nil {
+			SetFog(fog.NewMask(file.Width, file.Height))
+		}
+		TableView.Fog.Fill(reveal)
+		RedrawFog()
+		SaveFog()
+	}
+
+	removeButton := widget.NewButton("Remove Fog", func() {
+		SetFog(nil)
+		SaveFog()
+	})
+
+	doneButton := widget.NewButton("Done", func() {
+		SetActiveTool(nil)
+	})
+	doneButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabel("Paint with your fingers to reveal or hide\nthe map. Tap the corners of a polygon\nthen close it."),
+		shapeRadio,
+		modeRadio,
+		widget.NewLabel("Brush size"),
+		brushSlider,
+		widget.NewButton("Close Polygon", tool.ClosePolygon),
+		container.NewGridWithColumns(2,
+			widget.NewButton("Hide All", func() { fill(false) }),
+			widget.NewButton("Reveal All", func() { fill(true) }),
+		),
+		container.NewGridWithColumns(2, removeButton, doneButton),
+	)
+	panel.Resize(fyne.NewSize(FogPanelWidth, FogPanelHeight))
+	panel.Move(ToolPanelPosition(FogPanelHeight))
+	tool.panel = panel
+
+	if TableView.Fog == nil {
+		// the GM removed the fog from this map, so it comes back with nothing hidden until it is painted
+		mask := fog.NewMask(file.Width, file.Height)
+		mask.Fill(true)
+		SetFog(mask)
+	}
+
+	return container.NewWithoutLayout(tool.preview, panel)
+}
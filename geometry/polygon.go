@@ -0,0 +1,149 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+
+	"fyne.io/fyne/v2"
+)
+
+// Polygon is a closed shape given by its corners, the last corner joins back to the first
+type Polygon []fyne.Position
+
+// RectPolygon returns the polygon for the rectangle spanned by two opposite corners
+func RectPolygon(corner1 fyne.Position, corner2 fyne.Position) Polygon {
+	minX, maxX := float32(math.Min(float64(corner1.X), float64(corner2.X))), float32(math.Max(float64(corner1.X), float64(corner2.X)))
+	minY, maxY := float32(math.Min(float64(corner1.Y), float64(corner2.Y))), float32(math.Max(float64(corner1.Y), float64(corner2.Y)))
+
+	return Polygon{fyne.NewPos(minX, minY), fyne.NewPos(maxX, minY), fyne.NewPos(maxX, maxY), fyne.NewPos(minX, maxY)}
+}
+
+// CirclePolygon approximates a circle with the given number of corners
+func CirclePolygon(center fyne.Position, radius float32, corners int) Polygon {
+	polygon := make(Polygon, corners)
+	for i := range polygon {
+		angle := 2 * math.Pi * float64(i) / float64(corners)
+		polygon[i] = fyne.NewPos(center.X+radius*float32(math.Cos(angle)), center.Y+radius*float32(math.Sin(angle)))
+	}
+
+	return polygon
+}
+
+// Contains returns true if the point lies inside the polygon, using the even-odd rule
+func (polygon Polygon) Contains(point fyne.Position) bool {
+	inside := false
+
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > point.Y) != (b.Y > point.Y) && point.X < (b.X-a.X)*(point.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// Crossings returns where the edges of the polygon cross the horizontal line at y, sorted from left to right.
+// Following the even-odd rule the points between the first and second crossing are inside, and so on.
+func (polygon Polygon) Crossings(y float32) []float32 {
+	var crossings []float32
+
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > y) != (b.Y > y) {
+			crossings = append(crossings, (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X)
+		}
+	}
+
+	sort.Slice(crossings, func(i, j int) bool {
+		return crossings[i] < crossings[j]
+	})
+
+	return crossings
+}
+
+// Bounds returns the top left and bottom right corners of the smallest rectangle around the polygon
+func (polygon Polygon) Bounds() (fyne.Position, fyne.Position) {
+	if len(polygon) == 0 {
+		return fyne.Position{}, fyne.Position{}
+	}
+
+	min, max := polygon[0], polygon[0]
+	for _, corner := range polygon[1:] {
+		min = fyne.NewPos(float32(math.Min(float64(min.X), float64(corner.X))), float32(math.Min(float64(min.Y), float64(corner.Y))))
+		max = fyne.NewPos(float32(math.Max(float64(max.X), float64(corner.X))), float32(math.Max(float64(max.Y), float64(corner.Y))))
+	}
+
+	return min, max
+}
+
+// IsRect returns true if the polygon is an axis aligned rectangle
+func (polygon Polygon) IsRect() bool {
+	if len(polygon) != 4 {
+		return false
+	}
+
+	min, max := polygon.Bounds()
+	for _, corner := range polygon {
+		if (corner.X != min.X && corner.X != max.X) || (corner.Y != min.Y && corner.Y != max.Y) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Distance returns the straight line distance between two points
+func Distance(a fyne.Position, b fyne.Position) float32 {
+	return float32(math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y)))
+}
+
+// ClipSegment clips the segment from a to b to the rectangle between min and max,
+// returning false when no part of it lies inside
+func ClipSegment(a fyne.Position, b fyne.Position, min fyne.Position, max fyne.Position) (fyne.Position, fyne.Position, bool) {
+	t0, t1 := float32(0), float32(1)
+	dx, dy := b.X-a.X, b.Y-a.Y
+
+	edges := [4][2]float32{{-dx, a.X - min.X}, {dx, max.X - a.X}, {-dy, a.Y - min.Y}, {dy, max.Y - a.Y}}
+	for _, edge := range edges {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return a, b, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			if t > t1 {
+				return a, b, false
+			}
+			if t > t0 {
+				t0 = t
+			}
+		} else {
+			if t < t0 {
+				return a, b, false
+			}
+			if t < t1 {
+				t1 = t
+			}
+		}
+	}
+
+	return fyne.NewPos(a.X+t0*dx, a.Y+t0*dy), fyne.NewPos(a.X+t1*dx, a.Y+t1*dy), true
+}
+
+// SegmentDistance returns the distance from point to the closest point on the segment from a to b
+func SegmentDistance(point fyne.Position, a fyne.Position, b fyne.Position) float32 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return Distance(point, a)
+	}
+
+	t := ((point.X-a.X)*dx + (point.Y-a.Y)*dy) / lengthSquared
+	t = float32(math.Max(0, math.Min(1, float64(t))))
+
+	return Distance(point, fyne.NewPos(a.X+t*dx, a.Y+t*dy))
+}
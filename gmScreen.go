@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/mapView"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+// GMFogOpacity lets the GM see the map through the fog the players see as solid
+const GMFogOpacity float32 = 0.5
+const GMSidebarWidth float32 = 280
+
+// GMVisionOpacity lets the GM see the parts of the map the players' viewers can't
+const GMVisionOpacity float32 = 0.5
+
+// GMLightingOpacity lets the GM see the dark parts of the map
+const GMLightingOpacity float32 = 0.5
+
+var GMWindow fyne.Window
+var GMView *mapView.MapView
+var GMWidth int
+var GMHeight int
+
+// MirrorTable keeps the table showing what the GM screen shows, otherwise both are panned and zoomed on their own
+var MirrorTable = true
+
+var gmContent fyne.CanvasObject
+var gmMapArea *fyne.Container
+var gmNotes *widget.Entry
+var mirroring bool
+
+// gmPointer turns the GM's pointer on the map into touches for the active tool, it is only shown while a tool is
+// active so the GM can pan and zoom the map otherwise
+var gmPointer *touch.PointerSource
+
+// BuildGMScreen creates the GM's private view of the table map along with the controls and map list,
+// leaving the table as a clean player view
+func BuildGMScreen(mapLibrary fyne.CanvasObject, navButtons []*widget.Button) {
+
+	GMView = mapView.NewMapView(GMWidth, GMHeight, nil)
+	GMView.CopyMapImages = true
+	GMView.FogOpacity = GMFogOpacity
+	GMView.VisionOpacity = GMVisionOpacity
+	GMView.LightingOpacity = GMLightingOpacity
+	GMView.TokenDir = TableView.TokenDir
+	// the GM sees every token's hit points, the players only those shown to them
+	GMView.ShowAllHealth = true
+	GMView.MinZoom = TableView.MinZoom
+	GMView.MaxZoom = TableView.MaxZoom
+	GMView.SetGridStyle(TableView.GridStyle)
+	GMView.SetGridVisible(TableView.GridVisible())
+	GMView.ZoomControl.Move(fyne.NewPos(float32(GMWidth)-mapView.ZoomSliderWidth-20, float32(GMHeight)-mapView.ZoomSliderHeight-10))
+
+	TableView.OnViewChanged = func() {
+		RefreshMiniatures()
+		if MirrorTable {
+			mirrorView(TableView, GMView, gmScale())
+		}
+	}
+	GMView.OnViewChanged = func() {
+		if MirrorTable {
+			mirrorView(GMView, TableView, 1/gmScale())
+		}
+	}
+
+	// the map area keeps its size whatever the window layout does
+	viewSize := canvas.NewRectangle(color.Transparent)
+	viewSize.SetMinSize(fyne.NewSize(float32(GMWidth), float32(GMHeight)))
+	gmPointer = touch.NewPointerSource()
+	gmPointer.Surface.Resize(fyne.NewSize(float32(GMWidth), float32(GMHeight)))
+	gmPointer.Surface.Hide()
+	if pointerError := gmPointer.Start(); pointerError != nil {
+		fmt.Println(pointerError)
+	}
+	go routeGMTouchEvents(gmPointer.Events())
+
+	gmMapArea = container.NewWithoutLayout(viewSize, GMView.MapControl, GMView.LightingOverlay, GMView.GridOverlay, GMView.TemplateOverlay, GMView.VisionOverlay, GMView.FogOverlay, gmPointer.Surface, GMView.ZoomControl)
+	gmMapArea.Resize(fyne.NewSize(float32(GMWidth), float32(GMHeight)))
+
+	mirrorCheck := widget.NewCheck("Mirror table", func(checked bool) {
+		MirrorTable = checked
+		if checked {
+			mirrorView(GMView, TableView, 1/gmScale())
+		}
+	})
+	mirrorCheck.SetChecked(MirrorTable)
+
+	var toolbar []fyne.CanvasObject
+	for _, navButton := range navButtons {
+		toolbar = append(toolbar, navButton)
+	}
+	toolbar = append(toolbar, mirrorCheck)
+
+	gmNotes = widget.NewMultiLineEntry()
+	gmNotes.SetPlaceHolder("Notes for this map")
+	gmNotes.Wrapping = fyne.TextWrapWord
+	saveNotesButton := widget.NewButton("Save Notes", SaveNotes)
+
+	notes := container.NewBorder(widget.NewLabel("GM Notes"), saveNotesButton, nil, nil, gmNotes)
+	sidebar := container.NewGridWithRows(2, mapLibrary, notes)
+	sidebarSize := canvas.NewRectangle(color.Transparent)
+	sidebarSize.SetMinSize(fyne.NewSize(GMSidebarWidth, 0))
+
+	gmContent = container.NewBorder(container.NewHBox(toolbar...), nil, nil, container.NewMax(sidebarSize, sidebar), gmMapArea)
+}
+
+// routeGMTouchEvents hands the GM's pointer on the map to the active tool, in positions on the GM's map
+func routeGMTouchEvents(events <-chan touch.Event) {
+	ticker := time.NewTicker(touch.GestureTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			tool := ActiveTool()
+			if tool == nil || onTable(tool) {
+				continue
+			}
+
+			position := fyne.NewPos(event.X, event.Y).Subtract(toolOrigin())
+			event.X, event.Y = position.X, position.Y
+			tool.HandleTouch(event)
+		case now := <-ticker.C:
+			tickActiveTool(now, false)
+		}
+	}
+}
+
+// showGMPointer gives the GM's pointer on the map to the tools, or back to panning and zooming the map
+func showGMPointer(show bool) {
+	if gmPointer == nil {
+		return
+	}
+
+	if show {
+		gmPointer.Surface.Show()
+	} else {
+		gmPointer.Surface.Hide()
+	}
+}
+
+// gmScale is how much smaller the GM screen shows the map than the table does when mirroring
+func gmScale() float64 {
+	widthScale := float64(GMWidth) / float64(ScreenWidth)
+	heightScale := float64(GMHeight) / float64(ScreenHeight)
+	if heightScale < widthScale {
+		return heightScale
+	}
+
+	return widthScale
+}
+
+// mirrorView shows on target what source shows, scaled for the target's screen size
+func mirrorView(source *mapView.MapView, target *mapView.MapView, scale float64) {
+	if mirroring || target.CurrentMap == nil || target.CurrentMap.Hidden {
+		return
+	}
+
+	mirroring = true
+	defer func() { mirroring = false }()
+
+	offset := source.Offset()
+	target.SetViewport(source.Zoom()*scale, fyne.NewPos(offset.X*float32(scale), offset.Y*float32(scale)))
+}
+
+// SaveNotes stores the GM's notes with the map on the table
+func SaveNotes() {
+	file := TableView.CurrentMapFile
+	if file == nil || gmNotes == nil {
+		return
+	}
+
+	file.Metadata.Notes = gmNotes.Text
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// syncGMView updates the GM screen after the map, its grid or its fog changed on the table
+func syncGMView() {
+	if GMView == nil {
+		return
+	}
+
+	GMView.MapGrid = TableView.MapGrid
+	GMView.SetFog(TableView.Fog)
+	GMView.SetVision(TableView.Vision)
+	GMView.SetLighting(TableView.Lighting)
+	GMView.SetTokens(TableView.Tokens)
+	GMView.SetTemplates(TableView.Templates)
+	GMView.SetGridVisible(TableView.GridVisible())
+
+	// a grid fixed to the table's screen is drawn the same size over the map on the GM screen
+	scale := float32(gmScale())
+	GMView.SetCellSize(TableView.CellWidth*scale, TableView.CellHeight*scale)
+}
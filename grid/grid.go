@@ -0,0 +1,114 @@
+package grid
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// Kind names a grid shape as stored in map settings
+type Kind string
+
+const (
+	Square       Kind = "square"
+	HexFlatTop   Kind = "hex-flat"
+	HexPointyTop Kind = "hex-pointy"
+	DefaultKind  Kind = Square
+)
+
+// Kinds lists the grid shapes in the order they are offered to the GM
+var Kinds = []Kind{Square, HexFlatTop, HexPointyTop}
+
+// Cell identifies one grid cell. Square grids use column and row, hex grids use axial coordinates
+// where Col is q and Row is r.
+type Cell struct {
+	Col int
+	Row int
+}
+
+// Line is one straight piece of a grid line
+type Line struct {
+	Position1 fyne.Position
+	Position2 fyne.Position
+}
+
+// Grid converts between positions and cells of a square or hex grid.
+// All positions are in the same space the grid was created in, usually map image pixels.
+type Grid interface {
+	Kind() Kind
+	// CellAt returns the cell containing a position
+	CellAt(position fyne.Position) Cell
+	// Center returns the middle of a cell
+	Center(cell Cell) fyne.Position
+	// Outline returns the corners of a cell
+	Outline(cell Cell) geometry.Polygon
+	// Distance returns the number of cells stepped through to get from one cell to another
+	Distance(from Cell, to Cell) int
+	// CellSize returns the distance between the centers of neighbouring cells
+	CellSize() float32
+	// Lines returns the grid lines covering the rectangle between min and max, they may run past it
+	Lines(min fyne.Position, max fyne.Position) []Line
+}
+
+// New returns a grid of the given kind with a cell corner (or hex center) at origin, rotated by degrees.
+// For hex grids the size is the distance across the flat sides of a hex and height is ignored.
+func New(kind Kind, origin fyne.Position, width float32, height float32, rotation float32) Grid {
+	frame := newFrame(origin, rotation)
+
+	switch kind {
+	case HexFlatTop:
+		return &Hex{frame: frame, Size: width, FlatTop: true}
+	case HexPointyTop:
+		return &Hex{frame: frame, Size: width}
+	}
+
+	return &Rect{frame: frame, Width: width, Height: height}
+}
+
+// ParseKind returns the grid kind with the given name, falling back to DefaultKind
+func ParseKind(name string) Kind {
+	for _, kind := range Kinds {
+		if string(kind) == name {
+			return kind
+		}
+	}
+
+	return DefaultKind
+}
+
+// frame places the grid's own axes on the space it is drawn in
+type frame struct {
+	Origin   fyne.Position
+	Rotation float32
+	cos, sin float32
+}
+
+func newFrame(origin fyne.Position, rotation float32) frame {
+	radians := float64(rotation) * math.Pi / 180
+
+	return frame{Origin: origin, Rotation: rotation, cos: float32(math.Cos(radians)), sin: float32(math.Sin(radians))}
+}
+
+// toGrid converts a position to the grid's unrotated axes with the origin at 0,0
+func (frame frame) toGrid(position fyne.Position) fyne.Position {
+	dx, dy := position.X-frame.Origin.X, position.Y-frame.Origin.Y
+
+	return fyne.NewPos(dx*frame.cos+dy*frame.sin, -dx*frame.sin+dy*frame.cos)
+}
+
+// fromGrid converts a position on the grid's axes back
+func (frame frame) fromGrid(position fyne.Position) fyne.Position {
+	return fyne.NewPos(frame.Origin.X+position.X*frame.cos-position.Y*frame.sin, frame.Origin.Y+position.X*frame.sin+position.Y*frame.cos)
+}
+
+// gridBounds returns the rectangle on the grid's axes that covers the rectangle between min and max
+func (frame frame) gridBounds(min fyne.Position, max fyne.Position) (fyne.Position, fyne.Position) {
+	corners := geometry.Polygon{
+		frame.toGrid(min), frame.toGrid(fyne.NewPos(max.X, min.Y)),
+		frame.toGrid(fyne.NewPos(min.X, max.Y)), frame.toGrid(max),
+	}
+
+	return corners.Bounds()
+}
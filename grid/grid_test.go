@@ -0,0 +1,58 @@
+package grid
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+func TestCellCenterRoundTrip(t *testing.T) {
+	for _, kind := range Kinds {
+		grid := New(kind, fyne.NewPos(13, -7), 70, 70, 15)
+
+		for col := -4; col <= 4; col++ {
+			for row := -4; row <= 4; row++ {
+				cell := Cell{Col: col, Row: row}
+				if found := grid.CellAt(grid.Center(cell)); found != cell {
+					t.Errorf("%s: center of %v is in %v", kind, cell, found)
+				}
+				if !grid.Outline(cell).Contains(grid.Center(cell)) {
+					t.Errorf("%s: outline of %v does not contain its center", kind, cell)
+				}
+			}
+		}
+	}
+}
+
+func TestNeighbourSpacing(t *testing.T) {
+	for _, kind := range Kinds {
+		grid := New(kind, fyne.NewPos(0, 0), 50, 50, 0)
+		center := grid.Center(Cell{})
+
+		for _, neighbour := range []Cell{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} {
+			if grid.Distance(Cell{}, neighbour) != 1 {
+				t.Errorf("%s: expected %v to be one cell away", kind, neighbour)
+			}
+			if spacing := geometry.Distance(center, grid.Center(neighbour)); spacing < 49.9 || spacing > 50.1 {
+				t.Errorf("%s: expected centers 50 apart, got %.2f", kind, spacing)
+			}
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	square := New(Square, fyne.NewPos(0, 0), 50, 50, 0)
+	if distance := square.Distance(Cell{0, 0}, Cell{3, -5}); distance != 5 {
+		t.Errorf("square: expected 5, got %d", distance)
+	}
+
+	hex := New(HexPointyTop, fyne.NewPos(0, 0), 50, 50, 0)
+	if distance := hex.Distance(Cell{0, 0}, Cell{3, -5}); distance != 5 {
+		t.Errorf("hex: expected 5, got %d", distance)
+	}
+	if distance := hex.Distance(Cell{0, 0}, Cell{3, 2}); distance != 5 {
+		t.Errorf("hex: expected 5, got %d", distance)
+	}
+}
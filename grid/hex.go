@@ -0,0 +1,156 @@
+package grid
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+var sqrt3 = float32(math.Sqrt(3))
+
+// Hex is a grid of hexagons in axial coordinates with the center of hex 0,0 at the origin.
+// Size is the distance across the flat sides, which is also the distance between neighbouring centers.
+type Hex struct {
+	frame
+	Size    float32
+	FlatTop bool
+}
+
+func (grid *Hex) Kind() Kind {
+	if grid.FlatTop {
+		return HexFlatTop
+	}
+
+	return HexPointyTop
+}
+
+// radius is the distance from the center to a corner
+func (grid *Hex) radius() float32 {
+	return grid.Size / sqrt3
+}
+
+func (grid *Hex) CellAt(position fyne.Position) Cell {
+	local := grid.toGrid(position)
+	radius := grid.radius()
+
+	var q, r float32
+	if grid.FlatTop {
+		q = local.X * 2 / 3 / radius
+		r = (-local.X/3 + sqrt3/3*local.Y) / radius
+	} else {
+		q = (sqrt3/3*local.X - local.Y/3) / radius
+		r = local.Y * 2 / 3 / radius
+	}
+
+	return roundAxial(q, r)
+}
+
+// roundAxial finds the hex containing fractional axial coordinates by rounding in cube coordinates
+func roundAxial(q float32, r float32) Cell {
+	s := -q - r
+	roundQ, roundR, roundS := math.Round(float64(q)), math.Round(float64(r)), math.Round(float64(s))
+	diffQ, diffR, diffS := math.Abs(roundQ-float64(q)), math.Abs(roundR-float64(r)), math.Abs(roundS-float64(s))
+
+	if diffQ > diffR && diffQ > diffS {
+		roundQ = -roundR - roundS
+	} else if diffR > diffS {
+		roundR = -roundQ - roundS
+	}
+
+	return Cell{Col: int(roundQ), Row: int(roundR)}
+}
+
+func (grid *Hex) localCenter(cell Cell) fyne.Position {
+	q, r := float32(cell.Col), float32(cell.Row)
+
+	if grid.FlatTop {
+		return fyne.NewPos(grid.radius()*1.5*q, grid.Size*(r+q/2))
+	}
+
+	return fyne.NewPos(grid.Size*(q+r/2), grid.radius()*1.5*r)
+}
+
+func (grid *Hex) Center(cell Cell) fyne.Position {
+	return grid.fromGrid(grid.localCenter(cell))
+}
+
+// localCorners returns the corners clockwise, starting on the right for flat top hexes and top right for pointy top
+func (grid *Hex) localCorners(cell Cell) [6]fyne.Position {
+	var corners [6]fyne.Position
+
+	center := grid.localCenter(cell)
+	radius := float64(grid.radius())
+	start := -math.Pi / 6
+	if grid.FlatTop {
+		start = 0
+	}
+
+	for i := range corners {
+		angle := start + math.Pi/3*float64(i)
+		corners[i] = fyne.NewPos(center.X+float32(radius*math.Cos(angle)), center.Y+float32(radius*math.Sin(angle)))
+	}
+
+	return corners
+}
+
+func (grid *Hex) Outline(cell Cell) geometry.Polygon {
+	var outline geometry.Polygon
+	for _, corner := range grid.localCorners(cell) {
+		outline = append(outline, grid.fromGrid(corner))
+	}
+
+	return outline
+}
+
+func (grid *Hex) Distance(from Cell, to Cell) int {
+	q, r := to.Col-from.Col, to.Row-from.Row
+
+	return (abs(q) + abs(r) + abs(q+r)) / 2
+}
+
+func (grid *Hex) CellSize() float32 {
+	return grid.Size
+}
+
+// Lines draws three sides of every hex, the other three belong to the neighbours
+func (grid *Hex) Lines(min fyne.Position, max fyne.Position) []Line {
+	var lines []Line
+	if grid.Size <= 0 {
+		return lines
+	}
+
+	localMin, localMax := grid.gridBounds(min, max)
+	rowStep, colStep := grid.Size, grid.radius()*1.5
+	if !grid.FlatTop {
+		rowStep, colStep = grid.radius()*1.5, grid.Size
+	}
+
+	addCell := func(cell Cell) {
+		corners := grid.localCorners(cell)
+		for i := 0; i < 3; i++ {
+			lines = append(lines, Line{grid.fromGrid(corners[i]), grid.fromGrid(corners[i+1])})
+		}
+	}
+
+	if grid.FlatTop {
+		for q := int(math.Floor(float64(localMin.X/colStep))) - 1; q <= int(math.Ceil(float64(localMax.X/colStep)))+1; q++ {
+			shift := float32(q) / 2
+			for r := int(math.Floor(float64(localMin.Y/rowStep-shift))) - 1; r <= int(math.Ceil(float64(localMax.Y/rowStep-shift)))+1; r++ {
+				addCell(Cell{Col: q, Row: r})
+			}
+		}
+
+		return lines
+	}
+
+	for r := int(math.Floor(float64(localMin.Y/rowStep))) - 1; r <= int(math.Ceil(float64(localMax.Y/rowStep)))+1; r++ {
+		shift := float32(r) / 2
+		for q := int(math.Floor(float64(localMin.X/colStep-shift))) - 1; q <= int(math.Ceil(float64(localMax.X/colStep-shift)))+1; q++ {
+			addCell(Cell{Col: q, Row: r})
+		}
+	}
+
+	return lines
+}
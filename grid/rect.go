@@ -0,0 +1,82 @@
+package grid
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// Rect is a grid of square (or, on uncalibrated displays, rectangular) cells
+type Rect struct {
+	frame
+	Width  float32
+	Height float32
+}
+
+func (grid *Rect) Kind() Kind {
+	return Square
+}
+
+func (grid *Rect) CellAt(position fyne.Position) Cell {
+	local := grid.toGrid(position)
+
+	return Cell{Col: int(math.Floor(float64(local.X / grid.Width))), Row: int(math.Floor(float64(local.Y / grid.Height)))}
+}
+
+func (grid *Rect) Center(cell Cell) fyne.Position {
+	return grid.fromGrid(fyne.NewPos((float32(cell.Col)+0.5)*grid.Width, (float32(cell.Row)+0.5)*grid.Height))
+}
+
+func (grid *Rect) Outline(cell Cell) geometry.Polygon {
+	left, top := float32(cell.Col)*grid.Width, float32(cell.Row)*grid.Height
+
+	return geometry.Polygon{
+		grid.fromGrid(fyne.NewPos(left, top)),
+		grid.fromGrid(fyne.NewPos(left+grid.Width, top)),
+		grid.fromGrid(fyne.NewPos(left+grid.Width, top+grid.Height)),
+		grid.fromGrid(fyne.NewPos(left, top+grid.Height)),
+	}
+}
+
+// Distance counts diagonal steps as one cell
+func (grid *Rect) Distance(from Cell, to Cell) int {
+	cols, rows := abs(to.Col-from.Col), abs(to.Row-from.Row)
+	if cols > rows {
+		return cols
+	}
+
+	return rows
+}
+
+func (grid *Rect) CellSize() float32 {
+	return grid.Width
+}
+
+func (grid *Rect) Lines(min fyne.Position, max fyne.Position) []Line {
+	var lines []Line
+	if grid.Width <= 0 || grid.Height <= 0 {
+		return lines
+	}
+
+	localMin, localMax := grid.gridBounds(min, max)
+
+	for x := float32(math.Ceil(float64(localMin.X/grid.Width))) * grid.Width; x <= localMax.X; x += grid.Width {
+		lines = append(lines, Line{grid.fromGrid(fyne.NewPos(x, localMin.Y)), grid.fromGrid(fyne.NewPos(x, localMax.Y))})
+	}
+
+	for y := float32(math.Ceil(float64(localMin.Y/grid.Height))) * grid.Height; y <= localMax.Y; y += grid.Height {
+		lines = append(lines, Line{grid.fromGrid(fyne.NewPos(localMin.X, y)), grid.fromGrid(fyne.NewPos(localMax.X, y))})
+	}
+
+	return lines
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,281 @@
+package lighting
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+// MaxLightMapSize is the largest width or height of the light map, light fades smoothly so it needs far fewer pixels than the map
+const MaxLightMapSize int = 512
+
+// FlickerFrame is how often flickering lights change their brightness
+const FlickerFrame = 100 * time.Millisecond
+
+// DefaultAmbient is how light a map is outside its lights when lighting is first turned on
+const DefaultAmbient float32 = 0.1
+
+// DimLevel is how lit the ring between a light's bright and dim radius is
+const DimLevel float32 = 0.5
+
+// fadeWidth is the part of the bright and dim radius over which the light fades into the next ring, so the edges aren't hard
+const fadeWidth float32 = 0.15
+
+// circleCorners is how many corners the circle of a light has when there are no walls to block it
+const circleCorners int = 64
+
+var white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+// Layer is how lit every part of a map is by its lights, blocked by the map's walls.
+// The light map's alpha is how lit a pixel is and its color is the tint of the lights reaching it.
+type Layer struct {
+	// Ambient is how light the map is outside its lights, from 0 for pitch dark to 1, changed with SetAmbient
+	Ambient float32
+	// Scale is the number of map pixels covered by one light map pixel
+	Scale float32
+
+	walls    *vision.Walls
+	lights   []mapFile.LightSource
+	polygons []geometry.Polygon
+	light    *image.NRGBA
+	frame    int64
+	dirty    bool
+	mutex    sync.Mutex
+}
+
+// New returns a dark layer without any lights for a map of the given size
+func New(walls *vision.Walls, mapWidth int, mapHeight int) *Layer {
+	scale := float32(1)
+	if largest := math.Max(float64(mapWidth), float64(mapHeight)); largest > float64(MaxLightMapSize) {
+		scale = float32(largest / float64(MaxLightMapSize))
+	}
+
+	width := int(math.Ceil(float64(float32(mapWidth) / scale)))
+	height := int(math.Ceil(float64(float32(mapHeight) / scale)))
+
+	return &Layer{Ambient: DefaultAmbient, Scale: scale, walls: walls, light: image.NewNRGBA(image.Rect(0, 0, width, height)), dirty: true}
+}
+
+// SceneLights returns the lights that came with an imported map, lit and bright for half their range
+func SceneLights(scene *mapFile.Scene) []mapFile.LightSource {
+	if scene == nil {
+		return nil
+	}
+
+	var lights []mapFile.LightSource
+	for _, light := range scene.Lights {
+		tint := light.Color
+		tint.A = 255
+		lights = append(lights, mapFile.LightSource{Position: light.Position, Bright: light.Range / 2, Dim: light.Range, Color: tint, On: true})
+	}
+
+	return lights
+}
+
+// Lights returns the lights on the map
+func (layer *Layer) Lights() []mapFile.LightSource {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	return append([]mapFile.LightSource(nil), layer.lights...)
+}
+
+// SetLights places, moves or switches the lights and works out where their light reaches
+func (layer *Layer) SetLights(lights []mapFile.LightSource) {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	layer.lights = append([]mapFile.LightSource(nil), lights...)
+	layer.updatePolygons()
+}
+
+// SetWalls changes the walls, when a door is opened or closed, and works out where the light reaches
+func (layer *Layer) SetWalls(walls *vision.Walls) {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	layer.walls = walls
+	layer.updatePolygons()
+}
+
+// SetAmbient changes how light the map is outside its lights
+func (layer *Layer) SetAmbient(ambient float32) {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	layer.Ambient = ambient
+	layer.dirty = true
+}
+
+// Flickering returns true if a light that is on flickers, so the light map changes every FlickerFrame
+func (layer *Layer) Flickering() bool {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	return layer.flickering()
+}
+
+// Read calls read with the light map at the given time while it can't change
+func (layer *Layer) Read(now time.Time, read func(light *image.NRGBA)) {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	frame := now.UnixNano() / int64(FlickerFrame)
+	if layer.dirty || (frame != layer.frame && layer.flickering()) {
+		layer.render(frame)
+	}
+
+	read(layer.light)
+}
+
+// At returns how lit a map position is at the given time, from 0 to 1
+func (layer *Layer) At(position fyne.Position, now time.Time) float32 {
+	var level float32
+	layer.Read(now, func(light *image.NRGBA) {
+		x, y := int(position.X/layer.Scale), int(position.Y/layer.Scale)
+		if (image.Point{X: x, Y: y}).In(light.Rect) {
+			level = float32(light.Pix[light.PixOffset(x, y)+3]) / 255
+		}
+	})
+
+	return level
+}
+
+func (layer *Layer) flickering() bool {
+	for _, light := range layer.lights {
+		if light.On && light.Flicker > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updatePolygons works out the area each light reaches, the walls cast its shadows
+func (layer *Layer) updatePolygons() {
+	layer.polygons = make([]geometry.Polygon, len(layer.lights))
+	for i, light := range layer.lights {
+		if !light.On || light.Dim <= 0 {
+			continue
+		}
+
+		if layer.walls != nil {
+			layer.polygons[i] = layer.walls.Visible(light.Position, light.Dim)
+		} else {
+			layer.polygons[i] = geometry.CirclePolygon(light.Position, light.Dim, circleCorners)
+		}
+	}
+
+	layer.dirty = true
+}
+
+// render fills the light map for a flicker frame, adding up every light that reaches each pixel
+func (layer *Layer) render(frame int64) {
+	bounds := layer.light.Rect
+	width, height := bounds.Dx(), bounds.Dy()
+	levels := make([]float32, width*height)
+	tints := make([][3]float32, width*height)
+
+	for i, light := range layer.lights {
+		polygon := layer.polygons[i]
+		if !light.On || len(polygon) < 3 {
+			continue
+		}
+
+		strength := flicker(light.Flicker, i, frame)
+		tint := light.Color
+		if tint == (color.NRGBA{}) {
+			tint = white
+		}
+
+		min, max := polygon.Bounds()
+		fromY := int(math.Max(0, math.Floor(float64(min.Y/layer.Scale))))
+		toY := int(math.Min(float64(height-1), math.Ceil(float64(max.Y/layer.Scale))))
+		for y := fromY; y <= toY; y++ {
+			mapY := (float32(y) + 0.5) * layer.Scale
+			crossings := polygon.Crossings(mapY)
+
+			for c := 0; c+1 < len(crossings); c += 2 {
+				fromX := int(math.Max(0, math.Ceil(float64(crossings[c]/layer.Scale-0.5))))
+				toX := int(math.Min(float64(width), math.Ceil(float64(crossings[c+1]/layer.Scale-0.5))))
+				for x := fromX; x < toX; x++ {
+					distance := geometry.Distance(light.Position, fyne.NewPos((float32(x)+0.5)*layer.Scale, mapY))
+					level := Falloff(distance, light.Bright, light.Dim) * strength
+					if level <= 0 {
+						continue
+					}
+
+					pixel := y*width + x
+					levels[pixel] += level
+					tints[pixel][0] += float32(tint.R) * level
+					tints[pixel][1] += float32(tint.G) * level
+					tints[pixel][2] += float32(tint.B) * level
+				}
+			}
+		}
+	}
+
+	ambient := fyne.Min(fyne.Max(layer.Ambient, 0), 1)
+	for pixel, level := range levels {
+		offset := pixel * 4
+		layer.light.Pix[offset], layer.light.Pix[offset+1], layer.light.Pix[offset+2] = white.R, white.G, white.B
+		if level > 0 {
+			layer.light.Pix[offset] = uint8(tints[pixel][0] / level)
+			layer.light.Pix[offset+1] = uint8(tints[pixel][1] / level)
+			layer.light.Pix[offset+2] = uint8(tints[pixel][2] / level)
+		}
+		layer.light.Pix[offset+3] = uint8(fyne.Min(ambient+level, 1) * 255)
+	}
+
+	layer.frame = frame
+	layer.dirty = false
+}
+
+// Falloff returns how lit a point is at a distance from a light, full within bright, DimLevel out to dim,
+// fading between the rings rather than stopping at a hard edge
+func Falloff(distance float32, bright float32, dim float32) float32 {
+	if dim < bright {
+		dim = bright
+	}
+
+	brightFade := bright * fadeWidth
+	dimFade := (dim - bright) * fadeWidth
+
+	switch {
+	case distance >= dim:
+		return 0
+	case distance <= bright-brightFade:
+		return 1
+	case distance <= bright:
+		if dim == bright {
+			return (bright - distance) / brightFade
+		}
+		return DimLevel + (1-DimLevel)*(bright-distance)/brightFade
+	case distance <= dim-dimFade:
+		return DimLevel
+	default:
+		return DimLevel * (dim - distance) / dimFade
+	}
+}
+
+// flicker returns how strong a flickering light is during a frame, waving irregularly between 1-amount and 1.
+// Each light gets its own phase so a room of torches doesn't pulse together.
+func flicker(amount float32, light int, frame int64) float32 {
+	if amount <= 0 {
+		return 1
+	}
+
+	t := float64(frame)
+	phase := float64(light) * 1.7
+	wave := 0.5 + 0.3*math.Sin(t*0.9+phase) + 0.2*math.Sin(t*2.3+phase*0.6)
+
+	return 1 - fyne.Min(amount, 1)*float32(wave)
+}
@@ -0,0 +1,109 @@
+package lighting
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+func TestFalloffRings(t *testing.T) {
+	if level := Falloff(10, 100, 200); level != 1 {
+		t.Errorf("expected full light well within the bright radius, got %.2f", level)
+	}
+	if level := Falloff(150, 100, 200); level != DimLevel {
+		t.Errorf("expected dim light between the radii, got %.2f", level)
+	}
+	if level := Falloff(95, 100, 200); level <= DimLevel || level >= 1 {
+		t.Errorf("expected the bright edge to fade into the dim ring, got %.2f", level)
+	}
+	if level := Falloff(195, 100, 200); level <= 0 || level >= DimLevel {
+		t.Errorf("expected the dim edge to fade out, got %.2f", level)
+	}
+	if level := Falloff(200, 100, 200); level != 0 {
+		t.Errorf("expected no light past the dim radius, got %.2f", level)
+	}
+}
+
+func TestLightsAreBlockedByWalls(t *testing.T) {
+	walls := vision.NewWalls([]vision.Segment{{A: fyne.NewPos(500, 0), B: fyne.NewPos(500, 1000)}}, 1000, 1000)
+	layer := New(walls, 1000, 1000)
+	layer.SetAmbient(0)
+	layer.SetLights([]mapFile.LightSource{{Position: fyne.NewPos(300, 500), Bright: 200, Dim: 400, On: true}})
+
+	now := time.Now()
+	if level := layer.At(fyne.NewPos(300, 520), now); level < 0.99 {
+		t.Errorf("expected the light's own spot to be fully lit, got %.2f", level)
+	}
+	if level := layer.At(fyne.NewPos(300, 820), now); level < 0.45 || level > 0.55 {
+		t.Errorf("expected the dim ring to be half lit, got %.2f", level)
+	}
+	if level := layer.At(fyne.NewPos(600, 500), now); level != 0 {
+		t.Errorf("expected the other side of the wall to be dark, got %.2f", level)
+	}
+
+	layer.SetAmbient(0.25)
+	if level := layer.At(fyne.NewPos(600, 500), now); level < 0.24 || level > 0.26 {
+		t.Errorf("expected the ambient light behind the wall, got %.2f", level)
+	}
+
+	lights := layer.Lights()
+	lights[0].On = false
+	layer.SetLights(lights)
+	if level := layer.At(fyne.NewPos(300, 520), now); level > 0.26 {
+		t.Errorf("expected a light that is off to give no light, got %.2f", level)
+	}
+}
+
+func TestLightsAreTinted(t *testing.T) {
+	layer := New(nil, 1000, 1000)
+	layer.SetLights([]mapFile.LightSource{{Position: fyne.NewPos(500, 500), Bright: 100, Dim: 200, Color: color.NRGBA{R: 255, G: 120, B: 0, A: 255}, On: true}})
+
+	layer.Read(time.Now(), func(light *image.NRGBA) {
+		x, y := int(500/layer.Scale), int(500/layer.Scale)
+		pixel := light.NRGBAAt(x, y)
+		if pixel.R != 255 || pixel.G != 120 || pixel.B != 0 {
+			t.Errorf("expected the light's color, got %v", pixel)
+		}
+		if corner := light.NRGBAAt(0, 0); corner.R != 255 || corner.G != 255 || corner.B != 255 {
+			t.Errorf("expected no tint where no light reaches, got %v", corner)
+		}
+	})
+}
+
+func TestFlickerChangesWithTime(t *testing.T) {
+	layer := New(nil, 1000, 1000)
+	layer.SetAmbient(0)
+	layer.SetLights([]mapFile.LightSource{{Position: fyne.NewPos(500, 500), Bright: 100, Dim: 200, Flicker: 0.5, On: true}})
+
+	if !layer.Flickering() {
+		t.Fatal("expected the layer to flicker")
+	}
+
+	start := time.Unix(0, 0)
+	levels := make(map[float32]bool)
+	for frame := 0; frame < 10; frame++ {
+		level := layer.At(fyne.NewPos(500, 500), start.Add(time.Duration(frame)*FlickerFrame))
+		if level < 0.49 || level > 1 {
+			t.Errorf("expected the flicker to stay within its amount, got %.2f", level)
+		}
+		levels[level] = true
+	}
+	if len(levels) < 3 {
+		t.Errorf("expected the light to change brightness over time, got %v", levels)
+	}
+}
+
+func TestSceneLights(t *testing.T) {
+	scene := &mapFile.Scene{Lights: []mapFile.Light{{Position: fyne.NewPos(10, 20), Range: 300, Intensity: 1, Color: color.NRGBA{R: 255, G: 200, B: 100, A: 128}}}}
+
+	lights := SceneLights(scene)
+	if len(lights) != 1 || lights[0].Dim != 300 || lights[0].Bright != 150 || !lights[0].On || lights[0].Color.A != 255 {
+		t.Errorf("unexpected lights from the scene %+v", lights)
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/lighting"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/mapView"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const LightingPanelWidth float32 = 320
+const LightingPanelHeight float32 = 380
+
+// LightMarkerRadius is the size of the markers drawn for lights while the lighting tool is open
+const LightMarkerRadius float32 = 16
+
+var LightingOverlay *fyne.Container
+
+// LightPreset is a kind of light the GM can place, with its radii in grid squares
+type LightPreset struct {
+	Name    string
+	Bright  float32
+	Dim     float32
+	Color   color.NRGBA
+	Flicker float32
+}
+
+// LightPresets are the lights offered by the lighting tool, sized for 5 foot squares
+var LightPresets = []LightPreset{
+	{Name: "Torch", Bright: 4, Dim: 8, Color: color.NRGBA{R: 255, G: 160, B: 70, A: 255}, Flicker: 0.25},
+	{Name: "Lantern", Bright: 6, Dim: 12, Color: color.NRGBA{R: 255, G: 210, B: 140, A: 255}, Flicker: 0.08},
+	{Name: "Candle", Bright: 1, Dim: 2, Color: color.NRGBA{R: 255, G: 190, B: 110, A: 255}, Flicker: 0.35},
+	{Name: "Light", Bright: 4, Dim: 8, Color: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	{Name: "Magic", Bright: 2, Dim: 4, Color: color.NRGBA{R: 120, G: 150, B: 255, A: 255}, Flicker: 0.05},
+}
+
+// lightDrag is a finger on the table, either moving a light or about to tap
+type lightDrag struct {
+	light int
+	start fyne.Position
+	moved bool
+}
+
+// LightingTool lets the GM place lights on the map. A tap adds a light, tapping a light switches it on or off,
+// or removes it while Remove is checked, and dragging a light moves it.
+type LightingTool struct {
+	Preset       LightPreset
+	Remove       bool
+	OnDeactivate func()
+
+	panel   fyne.CanvasObject
+	preview *fyne.Container
+	drags   map[int]*lightDrag
+}
+
+// NewLightingTool starts placing torches on the map on the table
+func NewLightingTool(onDeactivate func()) *LightingTool {
+	return &LightingTool{
+		Preset:       LightPresets[0],
+		OnDeactivate: onDeactivate,
+		preview:      container.NewWithoutLayout(),
+		drags:        make(map[int]*lightDrag),
+	}
+}
+
+// HandleTouch adds, moves, switches and removes lights
+func (tool *LightingTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+	layer := TableView.Lighting
+
+	if event.Status == touch.InitialTouch && touchesObject(tool.panel, position) {
+		return
+	}
+	if layer == nil {
+		return
+	}
+
+	drag, dragging := tool.drags[event.ID]
+	switch event.Status {
+	case touch.InitialTouch:
+		tool.drags[event.ID] = &lightDrag{light: tool.lightAt(position), start: position}
+	case touch.StreamTouch:
+		if !dragging {
+			return
+		}
+		if geometry.Distance(drag.start, position) > VisionTapDistance {
+			drag.moved = true
+		}
+		if drag.moved && drag.light >= 0 {
+			lights := layer.Lights()
+			if drag.light < len(lights) {
+				lights[drag.light].Position = clampToMap(ToolView().ScreenToMap(position))
+				layer.SetLights(lights)
+				RedrawLighting()
+				tool.showMarkers()
+			}
+		}
+	case touch.UnTouch:
+		if !dragging {
+			return
+		}
+		delete(tool.drags, event.ID)
+
+		if !drag.moved {
+			tool.tap(drag, position)
+		}
+		SaveLighting()
+	}
+}
+
+func (tool *LightingTool) tap(drag *lightDrag, position fyne.Position) {
+	layer := TableView.Lighting
+	lights := layer.Lights()
+
+	if drag.light >= 0 && drag.light < len(lights) {
+		if tool.Remove {
+			lights = append(lights[:drag.light], lights[drag.light+1:]...)
+		} else {
+			lights[drag.light].On = !lights[drag.light].On
+		}
+	} else if !tool.Remove {
+		cellSize := TableView.Grid().CellSize()
+		lights = append(lights, mapFile.LightSource{
+			Position: clampToMap(ToolView().ScreenToMap(position)),
+			Bright:   tool.Preset.Bright * cellSize,
+			Dim:      tool.Preset.Dim * cellSize,
+			Color:    tool.Preset.Color,
+			Flicker:  tool.Preset.Flicker,
+			On:       true,
+		})
+	}
+
+	layer.SetLights(lights)
+	RedrawLighting()
+	tool.showMarkers()
+}
+
+// lightAt returns the index of the light under a screen position, -1 when there is none
+func (tool *LightingTool) lightAt(position fyne.Position) int {
+	for i, light := range TableView.Lighting.Lights() {
+		if geometry.Distance(ToolView().MapToScreen(light.Position), position) <= LightMarkerRadius*1.5 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// showMarkers draws every light over the table, filled while it is on
+func (tool *LightingTool) showMarkers() {
+	var objects []fyne.CanvasObject
+
+	if TableView.Lighting != nil {
+		for _, light := range TableView.Lighting.Lights() {
+			fill := color.Color(color.Transparent)
+			if light.On {
+				fill = light.Color
+			}
+
+			marker := canvas.NewCircle(fill)
+			marker.StrokeColor = light.Color
+			marker.StrokeWidth = 4
+			marker.Move(ToolView().MapToScreen(light.Position).Subtract(fyne.NewPos(LightMarkerRadius, LightMarkerRadius)))
+			marker.Resize(fyne.NewSize(LightMarkerRadius*2, LightMarkerRadius*2))
+			objects = append(objects, marker)
+		}
+	}
+
+	tool.preview.Objects = objects
+	tool.preview.Refresh()
+}
+
+// Deactivate closes the lighting panel
+func (tool *LightingTool) Deactivate() {
+	tool.drags = make(map[int]*lightDrag)
+	tool.preview.Objects = nil
+	tool.preview.Refresh()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// SetLighting darkens the map on the table outside its lights, nil turns lighting off
+func SetLighting(layer *lighting.Layer) {
+	TableView.SetLighting(layer)
+	syncGMView()
+}
+
+// RedrawLighting shows changes to the lights on the table and the GM screen
+func RedrawLighting() {
+	TableView.RedrawLighting()
+
+	if GMView != nil {
+		GMView.RedrawLighting()
+	}
+}
+
+// AnimateLighting keeps redrawing the map while it has flickering lights
+func AnimateLighting() {
+	ticker := time.NewTicker(lighting.FlickerFrame)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if layer := TableView.Lighting; layer != nil && layer.Flickering() {
+			RedrawLighting()
+		}
+	}
+}
+
+// SaveLighting stores the lights next to the map on the table
+func SaveLighting() {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	file.Metadata.Lighting = nil
+	if layer := TableView.Lighting; layer != nil {
+		file.Metadata.Lighting = &mapFile.LightingSettings{Ambient: layer.Ambient, Lights: layer.Lights()}
+	}
+
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// ShowLightingTool opens the lighting panel for the map on the table, turning lighting on for it
+func ShowLightingTool(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to light")
+		return false
+	}
+
+	tool := NewLightingTool(nil)
+	LightingOverlay = BuildLightingOverlay(tool)
+	AddToolOverlay(LightingOverlay)
+	tool.showMarkers()
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(LightingOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildLightingOverlay(tool *LightingTool) *fyne.Container {
+
+	file := TableView.CurrentMapFile
+
+	if TableView.Lighting == nil {
+		// a map starts dark, lit only by the lights that came with it, the first time the GM turns lighting on
+		file.Metadata.Lighting = &mapFile.LightingSettings{Ambient: lighting.DefaultAmbient, Lights: lighting.SceneLights(file.Scene)}
+		SetLighting(mapView.LoadLighting(file))
+		SaveLighting()
+	}
+
+	var presetNames []string
+	for _, preset := range LightPresets {
+		presetNames = append(presetNames, preset.Name)
+	}
+	presetSelect := widget.NewSelect(presetNames, func(name string) {
+		for _, preset := range LightPresets {
+			if preset.Name == name {
+				tool.Preset = preset
+			}
+		}
+	})
+	presetSelect.SetSelected(tool.Preset.Name)
+
+	removeCheck := widget.NewCheck("Remove lights", func(checked bool) {
+		tool.Remove = checked
+	})
+
+	ambientSlider := widget.NewSlider(0, 1)
+	ambientSlider.Step = 0.05
+	ambientSlider.SetValue(float64(TableView.Lighting.Ambient))
+	ambientSlider.OnChanged = func(value float64) {
+		if TableView.Lighting == nil {
+			return
+		}
+		TableView.Lighting.SetAmbient(float32(value))
+		RedrawLighting()
+		SaveLighting()
+	}
+
+	offButton := widget.NewButton("Turn Off", func() {
+		SetLighting(nil)
+		SaveLighting()
+		SetActiveTool(nil)
+	})
+
+	doneButton := widget.NewButton("Done", func() {
+		SetActiveTool(nil)
+	})
+	doneButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabel("Tap to place a light, tap a light to switch\nit on or off and drag it to move it."),
+		presetSelect,
+		removeCheck,
+		widget.NewLabel("Ambient light"),
+		ambientSlider,
+		container.NewGridWithColumns(2, offButton, doneButton),
+	)
+	panel.Resize(fyne.NewSize(LightingPanelWidth, LightingPanelHeight))
+	panel.Move(ToolPanelPosition(LightingPanelHeight))
+	tool.panel = panel
+
+	return container.NewWithoutLayout(tool.preview, panel)
+}
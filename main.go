@@ -1,90 +1,138 @@
 package main
 
-/*
-#cgo CFLAGS: -I../lib
-#cgo LDFLAGS: -L. -lEloMtApi
-#include <EloInterface.h>
-*/
-import "C"
 import (
+	"flag"
 	"fmt"
-	"image/color"
-	"math"
+	"os"
 	"strconv"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/JonCSykes/DragonTable/config"
 	"github.com/JonCSykes/DragonTable/mapFile"
-	"github.com/JonCSykes/DragonTable/widgetExt"
-	"github.com/lxn/win"
+	"github.com/JonCSykes/DragonTable/mapView"
+	"github.com/JonCSykes/DragonTable/touch"
 )
 
-const ScreenDimensionWidth int = 30
-const ScreenDimensionHeight int = 16
-const ZoomSliderWidth float32 = 150
-const ZoomSliderHeight float32 = 50
-const ZoomSliderXOffset int = 200
-const ZoomSliderYOffset int = 150
-
-const DragonTableWallpaperPath string = "./resources/images/dragontable.jpg"
-
 var ScreenHeight int
 var ScreenWidth int
 var TouchEnabled bool
+var Settings = config.Default()
+var GridStyle = mapView.DefaultGridStyle
 
 var MainWindow fyne.Window
 var mapFiles []*mapFile.MapFile
 var mainContent *fyne.Container
-var CurrentMap *canvas.Image
-var CurrentMapSize fyne.Size
-var MapContent *fyne.Container
-var MapControl *container.Scroll
-var ZoomControl *fyne.Container
-var ZoomSlider *widget.Slider
-
-type DeltaXY struct {
-	TX int64
-	TY int64
-	DX int64
-	DY int64
-}
+var MapList *widget.List
+var TableView *mapView.MapView
+var TouchSource touch.TouchSource
+var Gestures *touch.GestureRecognizer
 
 func main() {
 
-	deltaChan := make(chan DeltaXY)
-	TouchEnabled = true
+	settingsPath := flag.String("config", "", "settings file, DragonTable/"+config.SettingsFileName+" in the user config directory by default")
+	flag.Var(pathListFlag{&Settings.MapDirectories}, "maps", "folders to read maps from, separated by "+string(os.PathListSeparator))
+	flag.StringVar(&Settings.Wallpaper, "wallpaper", Settings.Wallpaper, "image shown when no map is open")
+	flag.StringVar(&Settings.Resources, "resources", Settings.Resources, "folder with the icons and images, next to the executable by default")
+	flag.Var(floatFlag{&Settings.Screen.WidthInches}, "screen-width", "physical width of the screen in inches, used until the display is calibrated")
+	flag.Var(floatFlag{&Settings.Screen.HeightInches}, "screen-height", "physical height of the screen in inches, used until the display is calibrated")
+	flag.Float64Var(&Settings.Zoom.Min, "min-zoom", Settings.Zoom.Min, "smallest zoom, 0 to zoom out until the map fits the screen")
+	flag.Float64Var(&Settings.Zoom.Max, "max-zoom", Settings.Zoom.Max, "largest zoom")
+	flag.StringVar(&Settings.Touch.Backend, "touch", Settings.Touch.Backend, "touch input backend: auto, elo, evdev or pointer")
+	touchRecordPath := flag.String("record", "", "record touch input to this file")
+	touchReplayPath := flag.String("replay", "", "replay touch input from a recording instead of the touch backend")
+	flag.Float64Var(&Settings.Touch.PanSensitivity, "pan-sensitivity", Settings.Touch.PanSensitivity, "map scroll distance per pixel of finger movement")
+	flag.Float64Var(&Settings.Touch.PanFriction, "pan-friction", Settings.Touch.PanFriction, "how quickly the map stops gliding after a flick, 0 disables momentum")
+	flag.BoolVar(&Settings.Grid.Visible, "grid", Settings.Grid.Visible, "show the grid when the app starts")
+	flag.StringVar(&Settings.Grid.Color, "grid-color", Settings.Grid.Color, "grid line color as #rrggbb")
+	flag.Var(floatFlag{&Settings.Grid.Opacity}, "grid-opacity", "grid line opacity from 0 to 1")
+	flag.Var(floatFlag{&Settings.Grid.LineWidth}, "grid-width", "grid line width in pixels")
+	flag.Var(floatFlag{&Settings.Grid.Dash}, "grid-dash", "length of the dashes in grid lines, 0 for solid lines")
+	flag.Var(floatFlag{&Settings.Grid.Gap}, "grid-gap", "length of the gaps between dashes in grid lines")
+	gmScreen := flag.Bool("gm-window", false, "open a second window with the GM's view and controls, leaving the table as a player view")
+	flag.IntVar(&GMWidth, "gm-width", 1280, "width of the map on the GM window")
+	flag.IntVar(&GMHeight, "gm-height", 720, "height of the map on the GM window")
+	flag.IntVar(&Settings.MapMemory, "map-memory", Settings.MapMemory, "megabytes of decoded maps to keep in memory before unloading the least recently shown")
+	flag.Parse()
+
+	LoadSettings(*settingsPath)
+	ApplySettings()
 
-	go streamTouchInput(deltaChan)
-	go triggerScrolledEvent(deltaChan)
+	TouchEnabled = true
 
 	GetScreenResolution()
+	LoadCalibration()
+
+	var touchError error
+	if *touchReplayPath != "" {
+		recording, recordingError := touch.LoadRecording(*touchReplayPath)
+		if recordingError != nil {
+			fmt.Println(recordingError)
+		}
+		TouchSource = touch.NewReplaySource(recording, true)
+	} else {
+		TouchSource, touchError = touch.NewSource(Settings.Touch.Backend, ScreenWidth, ScreenHeight)
+		if touchError != nil {
+			fmt.Println(touchError)
+			TouchSource = touch.NewPointerSource()
+		}
+	}
+	fmt.Printf("Touch backend : %T\n", TouchSource)
+
+	if *touchRecordPath != "" {
+		recorder, recorderError := touch.CreateRecorder(TouchSource, *touchRecordPath)
+		if recorderError != nil {
+			fmt.Println(recorderError)
+		} else {
+			TouchSource = recorder
+		}
+	}
+
+	if touchError = TouchSource.Start(); touchError != nil {
+		fmt.Println(touchError)
+	}
+	defer TouchSource.Stop()
+
+	Rejector = touch.NewRejector()
+	Rejector.LearnStationary = Settings.Touch.DetectMiniatures
+	if Settings.Touch.MiniatureSeconds > 0 {
+		Rejector.StationaryDuration = time.Duration(Settings.Touch.MiniatureSeconds * float64(time.Second))
+	}
+	Rejector.OnZonesChanged = func() {
+		RefreshZoneOverlay()
+		RefreshMiniatures()
+		applyClipRectangles()
+	}
+
+	Gestures = touch.NewGestureRecognizer()
+
+	go Gestures.Run(routeTouchEvents(Rejector.Run(TouchSource.Events())))
+	go triggerGestureEvents(Gestures.Gestures())
 
 	myApp := app.New()
 	MainWindow = myApp.NewWindow("Dragon Table - v0.1")
+	if *gmScreen {
+		GMWindow = myApp.NewWindow("Dragon Table - GM")
+		GMWindow.SetOnClosed(myApp.Quit)
+	}
 
 	BuildUI()
-
-	MainWindow.SetContent(mainContent)
+	ApplyContent()
+	go AnimateLighting()
 
 	MainWindow.SetPadded(true)
 	MainWindow.SetFullScreen(true)
+	if GMWindow != nil {
+		GMWindow.Show()
+	}
 	MainWindow.ShowAndRun()
 }
 
-func GetScreenResolution() {
-	hDC := win.GetDC(0)
-	defer win.ReleaseDC(0, hDC)
-	ScreenWidth = int(win.GetDeviceCaps(hDC, win.HORZRES))
-	ScreenHeight = int(win.GetDeviceCaps(hDC, win.VERTRES))
-
-	fmt.Println(strconv.Itoa(ScreenWidth) + " x " + strconv.Itoa(ScreenHeight))
-}
-
 func BuildUI() {
 
 	content := container.NewWithoutLayout()
@@ -92,39 +140,110 @@ func BuildUI() {
 	wallpaper := BuildWallpaper()
 	mapList := BuildNavList()
 	navButtons := BuildNavButtons()
-	InitCurrentMap()
-
-	BuildZoomControls()
-	lines := DrawGrid()
-	MapContent = container.NewWithoutLayout()
-	MapContent.Add(CurrentMap)
-	for _, line := range lines {
-		MapContent.Add(line)
-	}
 
-	MapControl = container.NewScroll(MapContent)
-	MapControl.Resize(fyne.NewSize(float32(ScreenWidth), float32(ScreenHeight)))
-	MapControl.Move(fyne.Position{X: -2, Y: -2})
+	TableView = mapView.NewMapView(ScreenWidth, ScreenHeight, nil)
+	TableView.Panning.Sensitivity = float32(Settings.Touch.PanSensitivity)
+	TableView.Panning.Friction = Settings.Touch.PanFriction
+	TableView.Panning.Momentum = Settings.Touch.PanFriction > 0
+	TableView.MinZoom = Settings.Zoom.Min
+	TableView.MaxZoom = Settings.Zoom.Max
+	TableView.SetCellSize(DisplayPixelsPerInch())
+	TableView.SetGridStyle(GridStyle)
+	TableView.SetGridVisible(Settings.Grid.Visible)
+	TableView.TokenDir = Settings.ResourcePath(TokenFolder)
+	// the minis stay where they are while the map moves under them
+	TableView.OnViewChanged = RefreshMiniatures
 
 	content.Add(wallpaper)
-	content.Add(MapControl)
-
-	for _, navButton := range navButtons {
-		content.Add(navButton)
+	content.Add(TableView.MapControl)
+	content.Add(TableView.LightingOverlay)
+	content.Add(TableView.GridOverlay)
+	content.Add(TableView.TemplateOverlay)
+	content.Add(TableView.VisionOverlay)
+	content.Add(TableView.FogOverlay)
+
+	if pointerSource, ok := BaseTouchSource().(*touch.PointerSource); ok {
+		pointerSource.Surface.Resize(fyne.NewSize(float32(ScreenWidth), float32(ScreenHeight)))
+		pointerSource.Surface.Move(fyne.Position{X: 0, Y: 0})
+		content.Add(pointerSource.Surface)
 	}
 
+	MiniatureOverlay = BuildMiniatureOverlay()
+	content.Add(MiniatureOverlay)
+
+	ZoneOverlay = BuildZoneOverlay()
+	content.Add(ZoneOverlay)
+
 	mapList.Refresh()
-	content.Add(mapList)
+	MapList = mapList
+	MapLibrary = BuildMapLibrary(mapList)
+
+	if GMWindow != nil {
+		BuildGMScreen(MapLibrary, navButtons)
+	} else {
+		MapLibrary.Resize(fyne.NewSize(MapLibraryWidth, 1000))
+		MapLibrary.Move(fyne.Position{X: float32(ScreenWidth) - MapLibraryWidth - 10, Y: 80})
 
-	if ZoomControl != nil {
-		content.Add(ZoomControl)
+		for _, navButton := range navButtons {
+			content.Add(navButton)
+		}
+		content.Add(MapLibrary)
+		content.Add(TableView.ZoomControl)
 	}
 
 	mainContent = content
 }
 
+// ApplyContent puts the built UI into the table window and the GM window when there is one
+func ApplyContent() {
+	MainWindow.SetContent(mainContent)
+
+	if GMWindow != nil {
+		GMWindow.SetContent(gmContent)
+	}
+}
+
+// ShowMap puts a map from the library on the table and the GM screen
+func ShowMap(file *mapFile.MapFile) {
+	TableView.ShowMapFile(file)
+	CoverNewMap(file)
+
+	if GMView != nil {
+		// the GM screen picks up the table's zoom rather than its own
+		mirroring = true
+		GMView.ShowMapFile(file)
+		mirroring = false
+
+		syncGMView()
+		mirrorView(TableView, GMView, gmScale())
+		gmNotes.SetText(file.Metadata.Notes)
+	}
+
+	RefreshMiniatures()
+}
+
+// HideMap takes the map off the table and the GM screen
+func HideMap() {
+	TableView.HideCurrentMap()
+
+	if GMView != nil {
+		GMView.HideCurrentMap()
+	}
+
+	RefreshMiniatures()
+}
+
+// BaseTouchSource returns the touch backend itself when it is wrapped by a recorder
+func BaseTouchSource() touch.TouchSource {
+	if recorder, ok := TouchSource.(*touch.Recorder); ok {
+		return recorder.Source()
+	}
+
+	return TouchSource
+}
+
 func BuildWallpaper() *canvas.Image {
-	dragonTableWallpaperResource, imageError := fyne.LoadResourceFromPath(DragonTableWallpaperPath)
+	dragonTableWallpaperResource, imageError := fyne.LoadResourceFromPath(Settings.WallpaperPath())
 	if imageError != nil {
 		fmt.Println(imageError)
 	}
@@ -137,138 +256,130 @@ func BuildWallpaper() *canvas.Image {
 	return dragonTableImage
 }
 
-func InitCurrentMap() {
-	if len(mapFiles) == 0 {
-		mapFiles = mapFile.GetMaps()
-	}
-
-	CurrentMap = mapFiles[0].Image
-	CurrentMap.FillMode = canvas.ImageFillStretch
-	CurrentMapSize = CurrentMap.Size()
-
-	CurrentMap.Hide()
-}
-
 func BuildNavList() *widget.List {
 
-	if len(mapFiles) == 0 {
+	libraryMutex.Lock()
+	scanned := len(mapFiles) == 0
+	if scanned {
 		mapFiles = mapFile.GetMaps()
 	}
+	libraryRows = mapFile.LibraryRows(mapFiles, librarySearchText(), collapsedCategories)
+	libraryMutex.Unlock()
+
+	if scanned {
+		WatchMaps()
+	}
 
 	mapList := widget.NewList(
 		func() int {
-			return len(mapFiles)
+			libraryMutex.Lock()
+			defer libraryMutex.Unlock()
+
+			return len(libraryRows)
 		},
 		func() fyne.CanvasObject {
-			return widgetExt.NewImageButton("", nil, nil)
+			return NewLibraryItem()
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
-
-			o.(*widgetExt.ImageButton).SetText(mapFiles[i].FileName)
-			o.(*widgetExt.ImageButton).OnTapped = func() {
-				fmt.Println("Clicked : " + mapFiles[i].FileName)
-				fmt.Println("Width : " + strconv.Itoa(mapFiles[i].Width))
-				fmt.Println("Height : " + strconv.Itoa(mapFiles[i].Height))
-
-				if !CurrentMap.Hidden && CurrentMap.Resource.Name() == mapFiles[i].FileName+"."+mapFiles[i].Extension {
-					HideCurrentMap()
-				} else {
-					SetCurrentMap(mapFiles[i].Image)
-					ShowCurrentMap()
-				}
+			libraryMutex.Lock()
+			// the watchers can shorten the list between it being measured and drawn
+			if i >= len(libraryRows) {
+				libraryMutex.Unlock()
+				return
 			}
-			o.(*widgetExt.ImageButton).Resize(fyne.Size{Width: 250, Height: 50})
-			o.(*widgetExt.ImageButton).SetImage(mapFiles[i].ThumbResource)
-		})
+			row := libraryRows[i]
+			libraryMutex.Unlock()
 
-	mapList.Resize(fyne.NewSize(250, 1000))
-	mapList.Move(fyne.Position{X: float32(ScreenWidth) - 260, Y: 80})
+			UpdateLibraryItem(o.(*fyne.Container), row)
+		})
 
 	return mapList
 }
 
-func HideCurrentMap() {
-	if CurrentMap != nil {
-		CurrentMap.Hide()
-		ZoomControl.Hide()
-	}
-}
-
-func ShowCurrentMap() {
-	if CurrentMap != nil {
-		CurrentMap.Show()
-		ZoomControl.Show()
-		SetZoomSliderRange()
-	}
-}
-
-func SetCurrentMap(image *canvas.Image) {
-	CurrentMap = image
-	CurrentMap.FillMode = canvas.ImageFillStretch
-	CurrentMap.Move(fyne.Position{X: 0, Y: 0})
-	CurrentMapSize = CurrentMap.Size()
-
-	fmt.Println(image.Size().Width, image.Size().Height)
-	fmt.Println(CurrentMap.Size().Width, CurrentMap.Size().Height)
-	fmt.Println(CurrentMapSize.Width, CurrentMapSize.Height)
-
-	lines := DrawGrid()
-	MapContent = container.NewWithoutLayout()
-	MapContent.Add(CurrentMap)
-	for _, line := range lines {
-		MapContent.Add(line)
-	}
-
-	MapControl.Content = MapContent
-	MapControl.Refresh()
-
-}
-
 func BuildNavButtons() []*widget.Button {
 
 	var navButtons []*widget.Button
 
-	var touchControlButton, hamburgerButton, gridButton *widget.Button
+	var touchControlButton, hamburgerButton, gridButton, zoneButton, calibrateButton, alignGridButton, fogButton, visionButton, lightingButton, tokenButton, measureButton, templateButton *widget.Button
 
-	hamburger, hamburgerError := fyne.LoadResourceFromPath("./resources/icons/bars-solid.svg")
+	hamburger, hamburgerError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/bars-solid.svg"))
 	if hamburgerError != nil {
 		fmt.Println(hamburgerError)
 	}
 
-	disabledTouchIcon, enableTouchError := fyne.LoadResourceFromPath("./resources/icons/hand-point-up-regular.svg")
+	disabledTouchIcon, enableTouchError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/hand-point-up-regular.svg"))
 	if enableTouchError != nil {
 		fmt.Println(enableTouchError)
 	}
 
-	enabledTouchIcon, enableTouchError := fyne.LoadResourceFromPath("./resources/icons/hand-point-up-solid.svg")
+	enabledTouchIcon, enableTouchError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/hand-point-up-solid.svg"))
 	if enableTouchError != nil {
 		fmt.Println(enableTouchError)
 	}
 
-	gridIcon, gridError := fyne.LoadResourceFromPath("./resources/icons/border-all-solid.svg")
+	gridIcon, gridError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/border-all-solid.svg"))
 	if gridError != nil {
 		fmt.Println(gridError)
 	}
 
-	syncIcon, syncError := fyne.LoadResourceFromPath("./resources/icons/sync-alt-solid.svg")
+	syncIcon, syncError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/sync-alt-solid.svg"))
 	if syncError != nil {
 		fmt.Println(syncError)
 	}
 
+	zoneIcon, zoneError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/ban-solid.svg"))
+	if zoneError != nil {
+		fmt.Println(zoneError)
+	}
+
+	calibrateIcon, calibrateError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/ruler-combined-solid.svg"))
+	if calibrateError != nil {
+		fmt.Println(calibrateError)
+	}
+
+	alignGridIcon, alignGridError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/crosshairs-solid.svg"))
+	if alignGridError != nil {
+		fmt.Println(alignGridError)
+	}
+
+	fogIcon, fogError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/cloud-solid.svg"))
+	if fogError != nil {
+		fmt.Println(fogError)
+	}
+
+	visionIcon, visionError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/eye-solid.svg"))
+	if visionError != nil {
+		fmt.Println(visionError)
+	}
+
+	lightingIcon, lightingError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/lightbulb-solid.svg"))
+	if lightingError != nil {
+		fmt.Println(lightingError)
+	}
+
+	tokenIcon, tokenError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/chess-pawn-solid.svg"))
+	if tokenError != nil {
+		fmt.Println(tokenError)
+	}
+
+	measureIcon, measureError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/ruler-solid.svg"))
+	if measureError != nil {
+		fmt.Println(measureError)
+	}
+
+	templateIcon, templateError := fyne.LoadResourceFromPath(Settings.ResourcePath("icons/shapes-solid.svg"))
+	if templateError != nil {
+		fmt.Println(templateError)
+	}
+
 	hamburgerButton = widget.NewButtonWithIcon("", hamburger, func() {
 
-		for _, child := range mainContent.Objects {
-			switch x := child.(type) {
-			case *widget.List:
-
-				if x.Hidden {
-					x.Show()
-					hamburgerButton.Importance = widget.HighImportance
-				} else {
-					x.Hide()
-					hamburgerButton.Importance = widget.MediumImportance
-				}
-			}
+		if MapLibrary.Hidden {
+			MapLibrary.Show()
+			hamburgerButton.Importance = widget.HighImportance
+		} else {
+			MapLibrary.Hide()
+			hamburgerButton.Importance = widget.MediumImportance
 		}
 	})
 
@@ -277,10 +388,8 @@ func BuildNavButtons() []*widget.Button {
 	hamburgerButton.Move(fyne.Position{X: float32(ScreenWidth) - 70, Y: 10})
 
 	syncButton := widget.NewButtonWithIcon("", syncIcon, func() {
-		fmt.Println("Refreshing Main Content")
-		mapFiles = nil
-		BuildUI()
-		MainWindow.SetContent(mainContent)
+		// the watchers keep the maps themselves up to date
+		RefreshLibrary()
 	})
 
 	syncButton.Importance = widget.HighImportance
@@ -305,136 +414,230 @@ func BuildNavButtons() []*widget.Button {
 	touchControlButton.Move(fyne.Position{X: float32(ScreenWidth) - 190, Y: 10})
 
 	gridButton = widget.NewButtonWithIcon("", gridIcon, func() {
-		for _, child := range MapContent.Objects {
-			switch x := child.(type) {
-			case *canvas.Line:
-				if x.Hidden {
-					x.Show()
-					gridButton.Importance = widget.HighImportance
-				} else {
-					x.Hide()
-					gridButton.Importance = widget.MediumImportance
-				}
-			}
+		visible := TableView.ToggleGrid()
+		syncGMView()
+		if visible {
+			gridButton.Importance = widget.HighImportance
+		} else {
+			gridButton.Importance = widget.MediumImportance
 		}
+		gridButton.Refresh()
 	})
 
 	gridButton.Importance = widget.MediumImportance
+	if Settings.Grid.Visible {
+		gridButton.Importance = widget.HighImportance
+	}
 	gridButton.Resize(fyne.NewSize(50, 50))
 	gridButton.Move(fyne.Position{X: float32(ScreenWidth) - 240, Y: 10})
 
-	navButtons = append(navButtons, touchControlButton, hamburgerButton, gridButton, syncButton)
+	zoneButton = widget.NewButtonWithIcon("", zoneIcon, func() {
+		if _, active := ActiveTool().(*ZoneTool); active {
+			SetActiveTool(nil)
+			return
+		}
 
-	return navButtons
-}
+		zoneButton.Importance = widget.HighImportance
+		zoneButton.Refresh()
+		SetActiveTool(NewZoneTool(func() {
+			zoneButton.Importance = widget.MediumImportance
+			zoneButton.Refresh()
+		}))
+	})
 
-func DrawGrid() []*canvas.Line {
+	zoneButton.Importance = widget.MediumImportance
+	zoneButton.Resize(fyne.NewSize(50, 50))
+	zoneButton.Move(fyne.Position{X: float32(ScreenWidth) - 300, Y: 10})
 
-	var lines []*canvas.Line
-	screenGridOffset := 5
+	calibrateButton = widget.NewButtonWithIcon("", calibrateIcon, func() {
+		if _, active := ActiveTool().(*CalibrationTool); active {
+			SetActiveTool(nil)
+			return
+		}
 
-	vLineSpace := ScreenWidth / ScreenDimensionWidth
-	hLineSpace := ScreenHeight / ScreenDimensionHeight
+		calibrateButton.Importance = widget.HighImportance
+		calibrateButton.Refresh()
+		ShowCalibration(func() {
+			calibrateButton.Importance = widget.MediumImportance
+			calibrateButton.Refresh()
+		})
+	})
 
-	fmt.Println("Number of lines across: ", int(CurrentMapSize.Width/float32(vLineSpace)))
-	fmt.Println("Number of lines down: ", int(CurrentMapSize.Height/float32(hLineSpace)))
+	calibrateButton.Importance = widget.MediumImportance
+	calibrateButton.Resize(fyne.NewSize(50, 50))
+	calibrateButton.Move(fyne.Position{X: float32(ScreenWidth) - 360, Y: 10})
 
-	for i := 0; i < int(CurrentMapSize.Width*float32(ZoomSlider.Max)/float32(vLineSpace)); i++ {
-		line := canvas.NewLine(color.RGBA{R: 56, G: 56, B: 56, A: 255})
-		line.StrokeWidth = 1
-		line.Position1 = fyne.NewPos(float32(vLineSpace*i), float32(0-screenGridOffset))
-		line.Position2 = fyne.NewPos(float32(vLineSpace*i), CurrentMapSize.Height*float32(ZoomSlider.Max)+float32(screenGridOffset))
-		line.Hide()
+	alignGridButton = widget.NewButtonWithIcon("", alignGridIcon, func() {
+		if _, active := ActiveTool().(*AlignGridTool); active {
+			SetActiveTool(nil)
+			return
+		}
 
-		lines = append(lines, line)
-	}
+		if ShowAlignGrid(func() {
+			alignGridButton.Importance = widget.MediumImportance
+			alignGridButton.Refresh()
+		}) {
+			alignGridButton.Importance = widget.HighImportance
+			alignGridButton.Refresh()
+		}
+	})
 
-	for i := 0; i < int(CurrentMapSize.Height*float32(ZoomSlider.Max)/float32(hLineSpace)); i++ {
-		line := canvas.NewLine(color.RGBA{R: 56, G: 56, B: 56, A: 255})
-		line.StrokeWidth = 1
-		line.Position1 = fyne.NewPos(float32(0-screenGridOffset), float32(hLineSpace*i))
-		line.Position2 = fyne.NewPos(CurrentMapSize.Width*float32(ZoomSlider.Max)+float32(screenGridOffset), float32(hLineSpace*i))
-		line.Hide()
+	alignGridButton.Importance = widget.MediumImportance
+	alignGridButton.Resize(fyne.NewSize(50, 50))
+	alignGridButton.Move(fyne.Position{X: float32(ScreenWidth) - 420, Y: 10})
 
-		lines = append(lines, line)
-	}
+	fogButton = widget.NewButtonWithIcon("", fogIcon, func() {
+		if _, active := ActiveTool().(*FogTool); active {
+			SetActiveTool(nil)
+			return
+		}
 
-	return lines
-}
+		if ShowFogTool(func() {
+			fogButton.Importance = widget.MediumImportance
+			fogButton.Refresh()
+		}) {
+			fogButton.Importance = widget.HighImportance
+			fogButton.Refresh()
+		}
+	})
 
-func BuildZoomControls() {
-
-	f := 1.0
-	data := binding.BindFloat(&f)
-	ZoomSlider = widget.NewSliderWithData(0.1, 2, data)
-	ZoomSlider.Step = 0.1
-	ZoomSlider.Resize(fyne.NewSize(ZoomSliderWidth, ZoomSliderHeight))
-	ZoomSlider.OnChanged = func(value float64) {
-		fmt.Println("Zoom Changed " + fmt.Sprintf("%f", value))
-		if CurrentMap != nil {
-			newWidth := float32(math.Abs(float64(CurrentMapSize.Width) * value))
-			newHeight := float32(math.Abs(float64(CurrentMapSize.Height) * value))
-
-			CurrentMap.Resize(fyne.NewSize(newWidth, newHeight))
-			CurrentMap.SetMinSize(fyne.NewSize(newWidth, newHeight))
-			fmt.Println(CurrentMapSize.Width, CurrentMapSize.Height)
-			fmt.Println(newWidth, newHeight)
-			MapControl.Refresh()
+	fogButton.Importance = widget.MediumImportance
+	fogButton.Resize(fyne.NewSize(50, 50))
+	fogButton.Move(fyne.Position{X: float32(ScreenWidth) - 480, Y: 10})
+
+	visionButton = widget.NewButtonWithIcon("", visionIcon, func() {
+		if _, active := ActiveTool().(*VisionTool); active {
+			SetActiveTool(nil)
+			return
 		}
-	}
-	ZoomControl = container.NewWithoutLayout(ZoomSlider)
-	ZoomControl.Resize(fyne.NewSize(ZoomSliderWidth, ZoomSliderHeight))
-	ZoomControl.Move(fyne.NewPos(float32(ScreenWidth-ZoomSliderXOffset), float32(ScreenHeight-ZoomSliderYOffset)))
-	ZoomControl.Hide()
 
-}
+		if ShowVisionTool(func() {
+			visionButton.Importance = widget.MediumImportance
+			visionButton.Refresh()
+		}) {
+			visionButton.Importance = widget.HighImportance
+			visionButton.Refresh()
+		}
+	})
 
-func SetZoomSliderRange() {
+	visionButton.Importance = widget.MediumImportance
+	visionButton.Resize(fyne.NewSize(50, 50))
+	visionButton.Move(fyne.Position{X: float32(ScreenWidth) - 540, Y: 10})
 
-	heightRatio := float32(ScreenHeight) / CurrentMapSize.Height
-	widthRatio := float32(ScreenWidth) / CurrentMapSize.Width
+	lightingButton = widget.NewButtonWithIcon("", lightingIcon, func() {
+		if _, active := ActiveTool().(*LightingTool); active {
+			SetActiveTool(nil)
+			return
+		}
 
-	if heightRatio > widthRatio {
-		ZoomSlider.Min = float64(math.Round(float64(heightRatio)*100) / 100)
-		ZoomSlider.Max = 2
-		ZoomSlider.Value = 1
-		fmt.Println(ZoomSlider.Min)
-	} else {
-		ZoomSlider.Min = float64(math.Round(float64(widthRatio)*100) / 100)
-		ZoomSlider.Max = 2
-		ZoomSlider.Value = 1
-		fmt.Println(ZoomSlider.Min)
-	}
-	ZoomControl.Refresh()
-}
+		if ShowLightingTool(func() {
+			lightingButton.Importance = widget.MediumImportance
+			lightingButton.Refresh()
+		}) {
+			lightingButton.Importance = widget.HighImportance
+			lightingButton.Refresh()
+		}
+	})
 
-func triggerScrolledEvent(deltaChan chan DeltaXY) {
+	lightingButton.Importance = widget.MediumImportance
+	lightingButton.Resize(fyne.NewSize(50, 50))
+	lightingButton.Move(fyne.Position{X: float32(ScreenWidth) - 600, Y: 10})
 
-	for {
-		delta := <-deltaChan
-		if MapControl != nil && TouchEnabled {
-			//fmt.Println(delta.DX*10, delta.DY*10)
-			MapControl.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{AbsolutePosition: fyne.NewPos(0, 0), Position: fyne.NewPos(0, 0)}, Scrolled: fyne.NewDelta(float32(delta.DX/2), float32(delta.DY/2))})
+	tokenButton = widget.NewButtonWithIcon("", tokenIcon, func() {
+		if _, active := ActiveTool().(*TokenTool); active {
+			SetActiveTool(nil)
+			return
 		}
-	}
-}
 
-func streamTouchInput(deltaChan chan DeltaXY) {
+		if ShowTokenTool(func() {
+			tokenButton.Importance = widget.MediumImportance
+			tokenButton.Refresh()
+		}) {
+			tokenButton.Importance = widget.HighImportance
+			tokenButton.Refresh()
+		}
+	})
+
+	tokenButton.Importance = widget.MediumImportance
+	tokenButton.Resize(fyne.NewSize(50, 50))
+	tokenButton.Move(fyne.Position{X: float32(ScreenWidth) - 660, Y: 10})
+
+	measureButton = widget.NewButtonWithIcon("", measureIcon, func() {
+		if _, active := ActiveTool().(*MeasureTool); active {
+			SetActiveTool(nil)
+			return
+		}
+
+		if ShowMeasureTool(func() {
+			measureButton.Importance = widget.MediumImportance
+			measureButton.Refresh()
+		}) {
+			measureButton.Importance = widget.HighImportance
+			measureButton.Refresh()
+		}
+	})
 
-	var x, px, y, py, z C.int
-	var status C.TOUCH_STATUS
-	var is C.bool
-	is = false
+	measureButton.Importance = widget.MediumImportance
+	measureButton.Resize(fyne.NewSize(50, 50))
+	measureButton.Move(fyne.Position{X: float32(ScreenWidth) - 720, Y: 10})
 
-	for {
-		C.EloGetTouchPacket(C.int(0), &x, &y, &z, &status, is)
+	templateButton = widget.NewButtonWithIcon("", templateIcon, func() {
+		if _, active := ActiveTool().(*TemplateTool); active {
+			SetActiveTool(nil)
+			return
+		}
 
-		if status == 2 && px > 0 && py > 0 {
-			deltaChan <- DeltaXY{TX: int64(x), TY: int64(y), DX: int64(x - px), DY: int64(y - py)}
+		if ShowTemplateTool(func() {
+			templateButton.Importance = widget.MediumImportance
+			templateButton.Refresh()
+		}) {
+			templateButton.Importance = widget.HighImportance
+			templateButton.Refresh()
 		}
+	})
+
+	templateButton.Importance = widget.MediumImportance
+	templateButton.Resize(fyne.NewSize(50, 50))
+	templateButton.Move(fyne.Position{X: float32(ScreenWidth) - 780, Y: 10})
+
+	navButtons = append(navButtons, touchControlButton, hamburgerButton, gridButton, syncButton, zoneButton, calibrateButton, alignGridButton, fogButton, visionButton, lightingButton, tokenButton, measureButton, templateButton)
+
+	return navButtons
+}
+
+// floatFlag lets float32 settings be set from the command line
+type floatFlag struct {
+	value *float32
+}
 
-		px = x
-		py = y
+func (flag floatFlag) String() string {
+	if flag.value == nil {
+		return ""
 	}
 
+	return strconv.FormatFloat(float64(*flag.value), 'f', -1, 32)
+}
+
+func (flag floatFlag) Set(text string) error {
+	parsed, parseError := strconv.ParseFloat(text, 32)
+	if parseError != nil {
+		return parseError
+	}
+	*flag.value = float32(parsed)
+
+	return nil
+}
+
+func triggerGestureEvents(gestures <-chan touch.Gesture) {
+
+	for gesture := range gestures {
+		if TableView != nil && TouchEnabled {
+			// a long press on a token opens its menu instead of going to the map, with a GM screen the menu is
+			// opened from the token tool there instead
+			if GMWindow == nil && gesture.Type == touch.GestureLongPress && ShowTokenMenu(gesture.Position) {
+				continue
+			}
+			TableView.HandleGesture(gesture)
+		}
+	}
 }
@@ -0,0 +1,168 @@
+package mapFile
+
+import (
+	"sort"
+	"strings"
+)
+
+// FavoritesCategory is the category of the favorites row at the top of the library, it can't clash with a folder
+// because folder categories never start with a colon
+const FavoritesCategory string = ":favorites"
+
+// LibraryRow is one line of the map library, either a category header or a map.
+// Category is the folder path relative to the map path with "/" between folders, "" for maps at the top level.
+type LibraryRow struct {
+	Category  string
+	Name      string
+	Depth     int
+	Collapsed bool
+	File      *MapFile
+}
+
+// IsCategory returns true for header rows
+func (row LibraryRow) IsCategory() bool {
+	return row.File == nil
+}
+
+// Matches returns true if every word of the query is part of the map's name or one of its tags, ignoring case
+func (mapFile *MapFile) Matches(query string) bool {
+	name := strings.ToLower(mapFile.FileName)
+
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		found := strings.Contains(name, word)
+		for _, tag := range mapFile.Metadata.Tags {
+			found = found || strings.Contains(strings.ToLower(tag), word)
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetFavorite marks the map as a favorite and saves its metadata
+func (mapFile *MapFile) SetFavorite(favorite bool) error {
+	mapFile.Metadata.Favorite = favorite
+	return mapFile.SaveMetadata()
+}
+
+// SetTags replaces the map's tags and saves its metadata
+func (mapFile *MapFile) SetTags(tags []string) error {
+	mapFile.Metadata.Tags = tags
+	return mapFile.SaveMetadata()
+}
+
+// ParseTags splits comma separated tags, dropping blanks and repeats
+func ParseTags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// LibraryRows lays out the maps matching a query as a tree of categories, with favorites first.
+// Maps under a collapsed category are left out unless there is a query, searching always shows every match.
+func LibraryRows(files []*MapFile, query string, collapsed map[string]bool) []LibraryRow {
+	var rows []LibraryRow
+	var matches []*MapFile
+	var favorites []*MapFile
+
+	searching := strings.TrimSpace(query) != ""
+	isCollapsed := func(category string) bool {
+		return !searching && collapsed[category]
+	}
+
+	for _, file := range files {
+		if file.Matches(query) {
+			matches = append(matches, file)
+		}
+	}
+
+	// maps added while the library is open come last in files
+	sort.SliceStable(matches, func(i, j int) bool {
+		return compareMaps(matches[i], matches[j]) < 0
+	})
+
+	for _, file := range matches {
+		if file.Metadata.Favorite {
+			favorites = append(favorites, file)
+		}
+	}
+
+	if len(favorites) > 0 {
+		rows = append(rows, LibraryRow{Category: FavoritesCategory, Name: "Favorites", Collapsed: isCollapsed(FavoritesCategory)})
+		if !isCollapsed(FavoritesCategory) {
+			for _, file := range favorites {
+				rows = append(rows, LibraryRow{Category: FavoritesCategory, Name: file.FileName, Depth: 1, File: file})
+			}
+		}
+	}
+
+	shown := make(map[string]bool)
+	for _, file := range matches {
+		folders := splitCategory(file.Category)
+		hidden := false
+
+		for depth := range folders {
+			category := strings.Join(folders[:depth+1], "/")
+			if !shown[category] {
+				shown[category] = true
+				rows = append(rows, LibraryRow{Category: category, Name: folders[depth], Depth: depth, Collapsed: isCollapsed(category)})
+			}
+
+			if isCollapsed(category) {
+				hidden = true
+				break
+			}
+		}
+
+		if !hidden {
+			rows = append(rows, LibraryRow{Category: file.Category, Name: file.FileName, Depth: len(folders), File: file})
+		}
+	}
+
+	return rows
+}
+
+func splitCategory(category string) []string {
+	if category == "" {
+		return nil
+	}
+
+	return strings.Split(category, "/")
+}
+
+// compareMaps orders maps by category and then by name, ignoring case
+func compareMaps(a *MapFile, b *MapFile) int {
+	if comparison := compareCategories(a.Category, b.Category); comparison != 0 {
+		return comparison
+	}
+
+	return strings.Compare(strings.ToLower(a.FileName), strings.ToLower(b.FileName))
+}
+
+// compareCategories orders categories folder by folder so every folder is followed by its own subfolders
+func compareCategories(a string, b string) int {
+	foldersA := splitCategory(a)
+	foldersB := splitCategory(b)
+
+	for i := 0; i < len(foldersA) && i < len(foldersB); i++ {
+		if comparison := strings.Compare(strings.ToLower(foldersA[i]), strings.ToLower(foldersB[i])); comparison != 0 {
+			return comparison
+		}
+	}
+
+	return len(foldersA) - len(foldersB)
+}
@@ -0,0 +1,87 @@
+package mapFile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func libraryFile(name string, category string, favorite bool, tags ...string) *MapFile {
+	return &MapFile{FileName: name, Category: category, Metadata: Metadata{Favorite: favorite, Tags: tags}}
+}
+
+func rowNames(rows []LibraryRow) []string {
+	var names []string
+	for _, row := range rows {
+		if row.IsCategory() {
+			names = append(names, "["+row.Name+"]")
+		} else {
+			names = append(names, row.Name)
+		}
+	}
+
+	return names
+}
+
+func TestLibraryRowsNestCategories(t *testing.T) {
+	files := []*MapFile{
+		libraryFile("Tavern", "", false),
+		libraryFile("Crypt", "Curse of Strahd/Castle", false),
+		libraryFile("Village", "Curse of Strahd", true),
+		libraryFile("Forest", "Wilderness", false, "outdoor"),
+	}
+
+	rows := LibraryRows(files, "", nil)
+	expected := []string{"[Favorites]", "Village", "Tavern", "[Curse of Strahd]", "Village", "[Castle]", "Crypt", "[Wilderness]", "Forest"}
+	if !reflect.DeepEqual(rowNames(rows), expected) {
+		t.Errorf("expected %v, got %v", expected, rowNames(rows))
+	}
+	if rows[5].Depth != 1 || rows[6].Depth != 2 {
+		t.Errorf("expected subfolders to be indented, got depths %d and %d", rows[5].Depth, rows[6].Depth)
+	}
+
+	collapsed := map[string]bool{"Curse of Strahd": true, FavoritesCategory: true}
+	expected = []string{"[Favorites]", "Tavern", "[Curse of Strahd]", "[Wilderness]", "Forest"}
+	if names := rowNames(LibraryRows(files, "", collapsed)); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected collapsed categories to hide their maps, got %v", names)
+	}
+}
+
+func TestLibraryRowsSortMapsByName(t *testing.T) {
+	files := []*MapFile{
+		libraryFile("tower", "Wilderness", false),
+		libraryFile("Bridge", "Wilderness", true),
+		libraryFile("Tavern", "", false),
+		libraryFile("Camp", "Wilderness", false),
+		libraryFile("alley", "", true),
+	}
+
+	expected := []string{"[Favorites]", "alley", "Bridge", "alley", "Tavern", "[Wilderness]", "Bridge", "Camp", "tower"}
+	if names := rowNames(LibraryRows(files, "", nil)); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestLibraryRowsSearchByNameOrTag(t *testing.T) {
+	files := []*MapFile{
+		libraryFile("Tavern", "", false, "town"),
+		libraryFile("Crypt", "Dungeons", false, "undead"),
+		libraryFile("Forest Road", "Wilderness", false, "outdoor"),
+	}
+
+	expected := []string{"[Dungeons]", "Crypt"}
+	if names := rowNames(LibraryRows(files, "UNDEAD", map[string]bool{"Dungeons": true})); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected tag search to find the crypt in its collapsed folder, got %v", names)
+	}
+
+	expected = []string{"[Wilderness]", "Forest Road"}
+	if names := rowNames(LibraryRows(files, "road outdoor", nil)); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected every word to match, got %v", names)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	expected := []string{"Town", "night"}
+	if tags := ParseTags(" Town, ,night,town "); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected %v, got %v", expected, tags)
+	}
+}
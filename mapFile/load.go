@@ -0,0 +1,192 @@
+package mapFile
+
+import (
+	"container/list"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/JonCSykes/DragonTable/tiles"
+)
+
+// TileCacheDir is where large maps are cut into tiles, the user cache directory when empty
+var TileCacheDir string
+
+// Loaded returns true if the map is ready to be shown, for large maps once their tiles have been opened
+func (mapFile *MapFile) Loaded() bool {
+	mapFile.loadMutex.Lock()
+	defer mapFile.loadMutex.Unlock()
+
+	return mapFile.loaded()
+}
+
+func (mapFile *MapFile) loaded() bool {
+	return mapFile.Image != nil || mapFile.Pyramid != nil
+}
+
+// Tiled returns true if the map is too large to decode whole and is shown from tiles instead
+func (mapFile *MapFile) Tiled() bool {
+	return tiles.NeedsTiles(mapFile.Width, mapFile.Height)
+}
+
+// MemorySize returns roughly how many bytes the decoded map takes up
+func (mapFile *MapFile) MemorySize() int64 {
+	if mapFile.Tiled() {
+		return 0
+	}
+
+	return int64(mapFile.Width) * int64(mapFile.Height) * 4
+}
+
+// Load decodes the full map, calling progress with the fraction of the file read so far.
+// Large maps are cut into tiles instead, which only takes a while the first time.
+func (mapFile *MapFile) Load(progress func(float32)) error {
+	mapFile.loadMutex.Lock()
+	defer mapFile.loadMutex.Unlock()
+
+	if mapFile.loaded() {
+		return nil
+	}
+
+	if mapFile.Tiled() {
+		cacheDir := TileCacheDir
+		if cacheDir == "" {
+			var err error
+			if cacheDir, err = tiles.DefaultCacheDir(); err != nil {
+				return err
+			}
+		}
+		pyramid, err := tiles.Open(mapFile.ImagePath(), cacheDir)
+		if err != nil {
+			return err
+		}
+		mapFile.Pyramid = pyramid
+		return nil
+	}
+
+	file, err := os.Open(mapFile.ImagePath())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if info, statError := file.Stat(); statError == nil && progress != nil {
+		reader = &progressReader{reader: file, size: info.Size(), progress: progress}
+	}
+
+	decoded, _, err := image.Decode(reader)
+	if err != nil {
+		return fmt.Errorf("%s: %w", mapFile.FullPath, err)
+	}
+
+	mapImage := canvas.NewImageFromImage(decoded)
+	mapImage.Resize(fyne.NewSize(float32(mapFile.Width), float32(mapFile.Height)))
+	mapImage.SetMinSize(fyne.NewSize(float32(mapFile.Width), float32(mapFile.Height)))
+	mapFile.Image = mapImage
+
+	return nil
+}
+
+// Unload frees the decoded map, it is decoded again by the next Load
+func (mapFile *MapFile) Unload() {
+	mapFile.loadMutex.Lock()
+	defer mapFile.loadMutex.Unlock()
+
+	mapFile.Image = nil
+	mapFile.Pyramid = nil
+}
+
+// progressStep is the smallest change in progress worth reporting
+const progressStep float32 = 0.01
+
+// progressReader reports how much of a file has been read
+type progressReader struct {
+	reader   io.Reader
+	size     int64
+	read     int64
+	reported float32
+	progress func(float32)
+}
+
+func (reader *progressReader) Read(buffer []byte) (int, error) {
+	count, err := reader.reader.Read(buffer)
+	reader.read += int64(count)
+
+	if reader.size > 0 {
+		if fraction := float32(reader.read) / float32(reader.size); fraction-reader.reported >= progressStep || fraction >= 1 {
+			reader.reported = fraction
+			reader.progress(fraction)
+		}
+	}
+
+	return count, err
+}
+
+// Cache keeps recently shown maps decoded and unloads the least recently used ones once they take up more than Budget bytes
+type Cache struct {
+	Budget int64
+	// InUse keeps maps that are on screen from being unloaded
+	InUse func(mapFile *MapFile) bool
+
+	order *list.List
+	files map[*MapFile]*list.Element
+	mutex sync.Mutex
+}
+
+// NewCache returns a cache with a memory budget in bytes
+func NewCache(budget int64) *Cache {
+	return &Cache{Budget: budget, order: list.New(), files: make(map[*MapFile]*list.Element)}
+}
+
+// Use marks a map as just shown and unloads others until the cache is within its budget
+func (cache *Cache) Use(mapFile *MapFile) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.files[mapFile]; found {
+		cache.order.MoveToFront(element)
+	} else {
+		cache.files[mapFile] = cache.order.PushFront(mapFile)
+	}
+
+	total := int64(0)
+	for element := cache.order.Front(); element != nil; element = element.Next() {
+		total += element.Value.(*MapFile).MemorySize()
+	}
+
+	for element := cache.order.Back(); element != nil && total > cache.Budget; {
+		previous := element.Prev()
+		file := element.Value.(*MapFile)
+		if file != mapFile && (cache.InUse == nil || !cache.InUse(file)) {
+			fmt.Println("Unloading " + file.FileName)
+			total -= file.MemorySize()
+			file.Unload()
+			cache.order.Remove(element)
+			delete(cache.files, file)
+		}
+		element = previous
+	}
+}
+
+// Remove forgets a map that has left the library, unloading it unless it is still on screen
+func (cache *Cache) Remove(mapFile *MapFile) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// a map on screen stays in the cache and is unloaded like any other once it is put away
+	if cache.InUse != nil && cache.InUse(mapFile) {
+		return
+	}
+
+	if element, found := cache.files[mapFile]; found {
+		cache.order.Remove(element)
+		delete(cache.files, mapFile)
+	}
+	mapFile.Unload()
+}
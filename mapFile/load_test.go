@@ -0,0 +1,122 @@
+package mapFile
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JonCSykes/DragonTable/tiles"
+)
+
+func writeTestMap(t *testing.T, name string, width int, height int) *MapFile {
+	path := filepath.Join(t.TempDir(), name+".png")
+	writeTestImage(t, path, image.NewRGBA(image.Rect(0, 0, width, height)))
+
+	return &MapFile{FileName: name, Extension: "png", FullPath: path, Width: width, Height: height}
+}
+
+// writeTestImage saves a picture as png, whatever the extension of path
+func writeTestImage(t *testing.T, path string, picture image.Image) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = png.Encode(file, picture)
+	if closeError := file.Close(); err == nil {
+		err = closeError
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadReportsProgress(t *testing.T) {
+	mapFile := writeTestMap(t, "map", 300, 200)
+
+	var last float32
+	if err := mapFile.Load(func(progress float32) { last = progress }); err != nil {
+		t.Fatal(err)
+	}
+
+	if !mapFile.Loaded() || mapFile.Image.Image.Bounds().Dx() != 300 {
+		t.Fatal("expected the map to be decoded")
+	}
+	if last != 1 {
+		t.Errorf("expected the progress to finish at 1, got %.2f", last)
+	}
+}
+
+func TestTiledMapLoadsOnceItsTilesAreOpen(t *testing.T) {
+	tiledMapSize, cacheDir := tiles.TiledMapSize, TileCacheDir
+	t.Cleanup(func() { tiles.TiledMapSize, TileCacheDir = tiledMapSize, cacheDir })
+	tiles.TiledMapSize, TileCacheDir = 256, t.TempDir()
+
+	mapFile := writeTestMap(t, "large", 600, 400)
+	if mapFile.Loaded() {
+		t.Fatal("expected a large map not to be loaded before its tiles are cut")
+	}
+
+	if err := mapFile.Load(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !mapFile.Loaded() || mapFile.Pyramid == nil || mapFile.Image != nil {
+		t.Fatal("expected the large map to be loaded from tiles")
+	}
+
+	mapFile.Unload()
+	if mapFile.Loaded() {
+		t.Error("expected an unloaded large map to need loading again")
+	}
+}
+
+func TestCacheUnloadsLeastRecentlyUsed(t *testing.T) {
+	first, second, third := writeTestMap(t, "first", 100, 100), writeTestMap(t, "second", 100, 100), writeTestMap(t, "third", 100, 100)
+
+	// room for two 100x100 maps
+	cache := NewCache(2 * 100 * 100 * 4)
+	cache.InUse = func(mapFile *MapFile) bool { return mapFile == first }
+
+	for _, mapFile := range []*MapFile{first, second, third} {
+		if err := mapFile.Load(nil); err != nil {
+			t.Fatal(err)
+		}
+		cache.Use(mapFile)
+	}
+
+	if !first.Loaded() {
+		t.Error("expected the map in use to stay loaded")
+	}
+	if second.Loaded() {
+		t.Error("expected the least recently used map to be unloaded")
+	}
+	if !third.Loaded() {
+		t.Error("expected the latest map to stay loaded")
+	}
+}
+
+func TestCacheRemoveKeepsMapsOnScreen(t *testing.T) {
+	shown, removed := writeTestMap(t, "shown", 50, 50), writeTestMap(t, "removed", 50, 50)
+
+	cache := NewCache(1024 * 1024)
+	cache.InUse = func(mapFile *MapFile) bool { return mapFile == shown }
+
+	for _, mapFile := range []*MapFile{shown, removed} {
+		if err := mapFile.Load(nil); err != nil {
+			t.Fatal(err)
+		}
+		cache.Use(mapFile)
+	}
+
+	cache.Remove(shown)
+	cache.Remove(removed)
+
+	if !shown.Loaded() {
+		t.Error("expected a removed map that is on screen to stay loaded")
+	}
+	if removed.Loaded() {
+		t.Error("expected a removed map to be unloaded")
+	}
+}
@@ -3,16 +3,17 @@ package mapFile
 import (
 	"fmt"
 	"image"
-	"image/jpeg"
 	"io/ioutil"
-	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
-	"github.com/gxcbuf/graphics-go/graphics"
+
+	"github.com/JonCSykes/DragonTable/tiles"
 )
 
 // MapFile :
@@ -22,14 +23,29 @@ type MapFile struct {
 	Extension         string
 	FullPath          string
 	FullThumbnailPath string
-	Height            int
-	Width             int
-	Image             *canvas.Image
-	ImageResource     fyne.Resource
-	ThumbResource     fyne.Resource
+	// ImageFile is the image extracted from a Universal VTT map, empty for plain images
+	ImageFile string
+	// Scene holds the walls, doors and lights of a Universal VTT map, nil for plain images
+	Scene *Scene
+	// Category is the folder the map is in relative to its map path, with "/" between folders
+	Category string
+	Height   int
+	Width    int
+	Image    *canvas.Image
+	// Pyramid holds the tiles of a large map once Load has opened them, nil for maps decoded whole
+	Pyramid       *tiles.Pyramid
+	ThumbResource fyne.Resource
+	Metadata      Metadata
+
+	// loadMutex stops a map being decoded twice when it is tapped again while loading
+	loadMutex sync.Mutex
 }
 
-const MapPath string = "./resources/maps"
+// MapPaths are the folders maps are read from
+var MapPaths = []string{"./resources/maps"}
+
+// MapExtensions are the file types read from MapPaths, images and the VTTExtensions
+var MapExtensions = append([]string{"jpg", "jpeg", "png", "webp"}, VTTExtensions...)
 
 func InitMapFile(fullPath string) *MapFile {
 
@@ -39,80 +55,146 @@ func InitMapFile(fullPath string) *MapFile {
 	extension := fullFileName[strings.LastIndex(fullFileName, ".")+1:]
 	fileName := fullFileName[:strings.LastIndex(fullFileName, ".")]
 
-	imageResource, imageError := fyne.LoadResourceFromPath(fullPath)
-	if imageError != nil {
-		fmt.Println(imageError)
+	newMapFile := &MapFile{FileName: fileName, Path: path, Extension: extension, FullPath: fullPath}
+
+	if IsVTTFile(fullFileName) {
+		if importError := newMapFile.importVTT(); importError != nil {
+			fmt.Println(importError)
+			return newMapFile
+		}
 	}
 
-	image := canvas.NewImageFromResource(imageResource)
+	// only the size is read here, the map itself is decoded by Load when it is shown
+	if configError := newMapFile.readConfig(); configError != nil {
+		fmt.Println(configError)
+	}
 
-	newMapFile := MapFile{FileName: fileName, Path: path, Extension: extension, FullPath: fullPath, ImageResource: imageResource, Image: image}
+	if thumbnails, thumbnailError := Thumbnails(); thumbnailError != nil {
+		fmt.Println(thumbnailError)
+	} else if thumbnailError = newMapFile.GenerateThumb(thumbnails); thumbnailError != nil {
+		fmt.Println(fileName, thumbnailError)
+	}
 
-	newMapFile.GenerateThumb()
+	if metadataError := newMapFile.LoadMetadata(); metadataError != nil {
+		fmt.Println(fileName, metadataError)
+	}
+
+	// the grid of an imported map is known until the GM aligns it differently
+	if newMapFile.Metadata.Grid == nil && newMapFile.Scene != nil {
+		newMapFile.Metadata.Grid = &GridSettings{PixelsPerSquare: newMapFile.Scene.PixelsPerGrid}
+	}
 
-	return &newMapFile
+	return newMapFile
 }
 
-func (mapFile *MapFile) GenerateThumb() {
+func (mapFile *MapFile) readConfig() error {
+	fileReader, err := os.Open(mapFile.ImagePath())
+	if err != nil {
+		return err
+	}
+	defer fileReader.Close()
+
+	imageConfig, _, err := image.DecodeConfig(fileReader)
+	if err != nil {
+		return fmt.Errorf("%s: %w", mapFile.FullPath, err)
+	}
 
-	imagePath, _ := os.Open(mapFile.FullPath)
-	defer imagePath.Close()
-	srcImage, _, _ := image.Decode(imagePath)
+	mapFile.Width = imageConfig.Width
+	mapFile.Height = imageConfig.Height
 
-	dstImage := image.NewRGBA(image.Rect(0, 0, 250, 50))
+	return nil
+}
 
-	graphics.Thumbnail(dstImage, srcImage)
+// GetMaps reads every map under MapPaths, subfolders become the maps' categories
+func GetMaps() []*MapFile {
 
-	fullThumbnailPath := mapFile.Path + mapFile.FileName + "_thumb." + mapFile.Extension
+	var mapFiles []*MapFile
+	for _, mapPath := range MapPaths {
+		files, err := ioutil.ReadDir(mapPath)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
 
-	newImage, _ := os.Create(fullThumbnailPath)
-	defer newImage.Close()
-	jpeg.Encode(newImage, dstImage, &jpeg.Options{Quality: jpeg.DefaultQuality})
+		mapFiles = append(mapFiles, getMapsIn(filepath.ToSlash(mapPath), "", files)...)
+	}
 
-	mapThumb, thumbError := fyne.LoadResourceFromPath(fullThumbnailPath)
-	if thumbError != nil {
-		fmt.Println(thumbError)
+	if thumbnails, thumbnailError := Thumbnails(); thumbnailError == nil {
+		if saveError := thumbnails.Save(); saveError != nil {
+			fmt.Println(saveError)
+		}
 	}
 
-	mapFile.FullThumbnailPath = fullThumbnailPath
-	mapFile.ThumbResource = mapThumb
+	return mapFiles
 }
 
-func GetMaps() []*MapFile {
+func getMapsIn(directory string, category string, files []os.FileInfo) []*MapFile {
 
 	var mapFiles []*MapFile
-	files, err := ioutil.ReadDir(MapPath)
-	if err != nil {
-		log.Fatal(err)
-	}
 
 	for _, file := range files {
-		if !strings.Contains(file.Name(), "_thumb") {
-			mapFile := InitMapFile(MapPath + "/" + file.Name())
-
-			fileReader, err := os.Open(MapPath + "/" + file.Name())
-			if err != nil {
-				fmt.Println("Impossible to open the file:", err)
-			} else {
-				defer fileReader.Close()
-				imageConfig, _, err := image.DecodeConfig(fileReader)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "%s: %v\n", file.Name(), err)
-					continue
-				}
-
-				mapFile.Width = imageConfig.Width
-				mapFile.Height = imageConfig.Height
-
-				mapFile.Image.Resize(fyne.NewSize(float32(imageConfig.Width), float32(imageConfig.Height)))
-				mapFile.Image.SetMinSize(fyne.NewSize(float32(imageConfig.Width), float32(imageConfig.Height)))
-
-				fmt.Println("Created " + mapFile.FileName + " : " + strconv.Itoa(mapFile.Width) + "x" + strconv.Itoa(mapFile.Height))
+		if file.IsDir() {
+			// hidden folders are left alone so caches and version control don't show up as categories
+			if strings.HasPrefix(file.Name(), ".") {
+				continue
+			}
+
+			subfolder := directory + "/" + file.Name()
+			subfolderFiles, readError := ioutil.ReadDir(subfolder)
+			if readError != nil {
+				fmt.Println(readError)
+				continue
+			}
+
+			subcategory := file.Name()
+			if category != "" {
+				subcategory = category + "/" + file.Name()
 			}
 
+			mapFiles = append(mapFiles, getMapsIn(subfolder, subcategory, subfolderFiles)...)
+		} else if IsMapFile(file.Name()) && !isLegacyThumbnail(file.Name(), files) {
+			mapFile := InitMapFile(directory + "/" + file.Name())
+			if mapFile.Width == 0 || mapFile.Height == 0 {
+				continue
+			}
+			mapFile.Category = category
+
+			fmt.Println("Created " + mapFile.FileName + " : " + strconv.Itoa(mapFile.Width) + "x" + strconv.Itoa(mapFile.Height))
+
 			mapFiles = append(mapFiles, mapFile)
 		}
 	}
 
 	return mapFiles
 }
+
+// isLegacyThumbnail returns true for the <name>_thumb.<ext> files older versions wrote next to each map,
+// which are only skipped when the map they belong to is there too
+func isLegacyThumbnail(fileName string, files []os.FileInfo) bool {
+	extension := fileName[strings.LastIndex(fileName, ".")+1:]
+	name := fileName[:len(fileName)-len(extension)-1]
+	if !strings.HasSuffix(name, "_thumb") {
+		return false
+	}
+
+	mapName := strings.TrimSuffix(name, "_thumb") + "." + extension
+	for _, file := range files {
+		if file.Name() == mapName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsMapFile returns true if the file name has one of the MapExtensions
+func IsMapFile(fileName string) bool {
+	extension := strings.ToLower(fileName[strings.LastIndex(fileName, ".")+1:])
+	for _, mapExtension := range MapExtensions {
+		if extension == mapExtension {
+			return true
+		}
+	}
+
+	return false
+}
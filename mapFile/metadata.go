@@ -0,0 +1,142 @@
+package mapFile
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io/ioutil"
+	"os"
+
+	"fyne.io/fyne/v2"
+)
+
+// MetadataSuffix is appended to a map's file name to get the sidecar file its settings are stored in
+const MetadataSuffix string = ".json"
+
+// GridSettings describes the grid printed on a map in map image pixels, Rotation is in degrees.
+// Type is one of the grid package kinds, for hex grids PixelsPerSquare is the distance across a hex's flat sides.
+type GridSettings struct {
+	Type            string  `json:"type,omitempty"`
+	PixelsPerSquare float32 `json:"pixelsPerSquare"`
+	OffsetX         float32 `json:"offsetX"`
+	OffsetY         float32 `json:"offsetY"`
+	Rotation        float32 `json:"rotation"`
+}
+
+// VisionSettings are where line of sight is worked out from, in map image pixels. Range is how far the viewers
+// can see, 0 for as far as the walls allow.
+type VisionSettings struct {
+	Viewers []fyne.Position `json:"viewers"`
+	Range   float32         `json:"range"`
+}
+
+// LightSource is a torch, lantern or spell the GM placed on a map. Position, Bright and Dim are in map image pixels:
+// the light is full within Bright and half as strong out to Dim. Flicker from 0 to 1 is how much it wavers.
+type LightSource struct {
+	Position fyne.Position `json:"position"`
+	Bright   float32       `json:"bright"`
+	Dim      float32       `json:"dim"`
+	Color    color.NRGBA   `json:"color"`
+	Flicker  float32       `json:"flicker,omitempty"`
+	On       bool          `json:"on"`
+}
+
+// LightingSettings are the lights of a map and how light the map is without them, from 0 for pitch dark to 1
+type LightingSettings struct {
+	Ambient float32       `json:"ambient"`
+	Lights  []LightSource `json:"lights"`
+}
+
+// ScaleSettings are how far one grid cell of a map is in the world, in a measure package unit, how the ruler
+// counts diagonals on the map and which cells area of effect templates cover, one of the aoe package coverages
+type ScaleSettings struct {
+	Distance float32 `json:"distance"`
+	Unit     string  `json:"unit"`
+	Rule     string  `json:"rule,omitempty"`
+	Coverage string  `json:"coverage,omitempty"`
+}
+
+// Template is a spell's area of effect placed on a map. Shape is one of the aoe package shapes, Origin is in map
+// image pixels and Size, and Width for lines, are in the unit of the map's scale. Angle is the direction cones, lines
+// and cubes point in, in degrees clockwise from the right.
+type Template struct {
+	Shape  string        `json:"shape"`
+	Origin fyne.Position `json:"origin"`
+	Size   float32       `json:"size"`
+	Width  float32       `json:"width,omitempty"`
+	Angle  float32       `json:"angle,omitempty"`
+}
+
+// Token is a creature placed on a map. Image is the token's picture relative to the token folder, Position is
+// its center in map image pixels and Size is one of the token package sizes. Sees makes line of sight follow it.
+// Team, HP and Conditions track the creature during play: Team and Conditions are token package names, and the
+// HP bar is only shown to the players when ShowHP is set.
+type Token struct {
+	Name       string        `json:"name"`
+	Image      string        `json:"image"`
+	Position   fyne.Position `json:"position"`
+	Size       string        `json:"size,omitempty"`
+	Sees       bool          `json:"sees,omitempty"`
+	Team       string        `json:"team,omitempty"`
+	HP         int           `json:"hp,omitempty"`
+	MaxHP      int           `json:"maxHP,omitempty"`
+	ShowHP     bool          `json:"showHP,omitempty"`
+	Conditions []string      `json:"conditions,omitempty"`
+}
+
+// Metadata is everything stored alongside a map in its sidecar file
+type Metadata struct {
+	Grid *GridSettings `json:"grid,omitempty"`
+	// Fog is the fog of war mask as a PNG, empty when the map has no fog
+	Fog []byte `json:"fog,omitempty"`
+	// FogRemoved is set once the GM takes the fog off the map, so it isn't hidden again the next time it is shown
+	FogRemoved bool `json:"fogRemoved,omitempty"`
+	// Notes are the GM's private notes for the map
+	Notes string `json:"notes,omitempty"`
+	// Tags and Favorite organise the map library
+	Tags     []string `json:"tags,omitempty"`
+	Favorite bool     `json:"favorite,omitempty"`
+	// Vision turns on line of sight for the map, nil while it is off
+	Vision *VisionSettings `json:"vision,omitempty"`
+	// Explored is the mask of everything the viewers have seen as a PNG
+	Explored []byte `json:"explored,omitempty"`
+	// Doors holds whether each of the scene's doors is closed, empty until one is opened or closed
+	Doors []bool `json:"doors,omitempty"`
+	// Lighting darkens the map outside its lights, nil while it is off
+	Lighting *LightingSettings `json:"lighting,omitempty"`
+	// Tokens are the creatures on the map, in the order they are drawn
+	Tokens []Token `json:"tokens,omitempty"`
+	// Scale is how far the ruler and templates count a grid cell, nil for 5 feet
+	Scale *ScaleSettings `json:"scale,omitempty"`
+	// Templates are the areas of effect on the map until the GM dismisses them
+	Templates []Template `json:"templates,omitempty"`
+}
+
+// MetadataPath returns the path of the sidecar file for this map
+func (mapFile *MapFile) MetadataPath() string {
+	return mapFile.FullPath + MetadataSuffix
+}
+
+// LoadMetadata reads the sidecar file, a missing file leaves the map with empty metadata
+func (mapFile *MapFile) LoadMetadata() error {
+	mapFile.Metadata = Metadata{}
+
+	data, err := ioutil.ReadFile(mapFile.MetadataPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &mapFile.Metadata)
+}
+
+// SaveMetadata writes the sidecar file next to the map
+func (mapFile *MapFile) SaveMetadata() error {
+	data, err := json.MarshalIndent(mapFile.Metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(mapFile.MetadataPath(), data, 0644)
+}
@@ -0,0 +1,244 @@
+package mapFile
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"github.com/gxcbuf/graphics-go/graphics"
+)
+
+const ThumbnailWidth int = 250
+const ThumbnailHeight int = 50
+
+const thumbnailIndexName string = "index.json"
+
+// ThumbnailCache keeps map thumbnails out of the maps folder, named by the hash of the map's content.
+// The index remembers each map's size and modification time so unchanged maps aren't hashed again.
+type ThumbnailCache struct {
+	Dir string
+
+	index map[string]thumbnailEntry
+	mutex sync.Mutex
+}
+
+type thumbnailEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"`
+	Hash    string `json:"hash"`
+	// Format is the thumbnail's file extension, picked from the map's picture when the thumbnail was written
+	Format string `json:"format,omitempty"`
+}
+
+var defaultThumbnails *ThumbnailCache
+var defaultThumbnailsOnce sync.Once
+
+// DefaultThumbnailDir returns the thumbnail directory under the user cache directory
+func DefaultThumbnailDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "DragonTable", "thumbnails"), nil
+}
+
+// Thumbnails returns the thumbnail cache in the default directory
+func Thumbnails() (*ThumbnailCache, error) {
+	var err error
+
+	defaultThumbnailsOnce.Do(func() {
+		var dir string
+		if dir, err = DefaultThumbnailDir(); err != nil {
+			return
+		}
+		defaultThumbnails, err = OpenThumbnailCache(dir)
+	})
+
+	if defaultThumbnails == nil && err == nil {
+		err = errors.New("thumbnail cache is not available")
+	}
+
+	return defaultThumbnails, err
+}
+
+// OpenThumbnailCache reads the index of a thumbnail directory, creating the directory if needed
+func OpenThumbnailCache(dir string) (*ThumbnailCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	cache := &ThumbnailCache{Dir: dir, index: make(map[string]thumbnailEntry)}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, thumbnailIndexName))
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &cache.index); err != nil {
+		// a broken index only costs hashing the maps again
+		fmt.Println(thumbnailIndexName, err)
+		cache.index = make(map[string]thumbnailEntry)
+	}
+
+	return cache, nil
+}
+
+// Save writes the index so the next start can skip hashing unchanged maps
+func (cache *ThumbnailCache) Save() error {
+	cache.mutex.Lock()
+	data, err := json.MarshalIndent(cache.index, "", "  ")
+	cache.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(cache.Dir, thumbnailIndexName), data, 0644)
+}
+
+// Thumbnail returns the path of a map's thumbnail, generating it only when the map is new or has changed
+func (cache *ThumbnailCache) Thumbnail(mapFile *MapFile) (string, error) {
+	info, err := os.Stat(mapFile.FullPath)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := filepath.Abs(mapFile.FullPath)
+	if err != nil {
+		key = mapFile.FullPath
+	}
+
+	cache.mutex.Lock()
+	entry, found := cache.index[key]
+	cache.mutex.Unlock()
+
+	if !found || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		hash, hashError := hashFile(mapFile.FullPath)
+		if hashError != nil {
+			return "", hashError
+		}
+		entry = thumbnailEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hash}
+	}
+
+	path := ""
+	if entry.Format != "" {
+		path = filepath.Join(cache.Dir, entry.Hash+"."+entry.Format)
+		if _, statError := os.Stat(path); errors.Is(statError, os.ErrNotExist) {
+			path = ""
+		} else if statError != nil {
+			return "", statError
+		}
+	}
+
+	if path == "" {
+		if entry.Format, err = writeThumbnail(mapFile.ImagePath(), cache.Dir, entry.Hash); err != nil {
+			return "", err
+		}
+		path = filepath.Join(cache.Dir, entry.Hash+"."+entry.Format)
+	}
+
+	cache.mutex.Lock()
+	cache.index[key] = entry
+	cache.mutex.Unlock()
+
+	return path, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha1.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// thumbnailFormat keeps maps with transparency as png thumbnails so it survives, opaque maps become jpeg
+func thumbnailFormat(decoded image.Image) string {
+	if opaque, ok := decoded.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return "jpg"
+	}
+
+	return "png"
+}
+
+// writeThumbnail writes the thumbnail of a map into dir named by its hash, returning the format it was written in
+func writeThumbnail(mapPath string, dir string, hash string) (string, error) {
+	source, err := os.Open(mapPath)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	sourceImage, _, err := image.Decode(source)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", mapPath, err)
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, ThumbnailWidth, ThumbnailHeight))
+	if err = graphics.Thumbnail(thumbnail, sourceImage); err != nil {
+		return "", err
+	}
+
+	format := thumbnailFormat(sourceImage)
+	thumbnailPath := filepath.Join(dir, hash+"."+format)
+
+	// write to a temporary file first so an interrupted start never leaves half a thumbnail behind
+	temporaryPath := thumbnailPath + ".tmp"
+	file, err := os.Create(temporaryPath)
+	if err != nil {
+		return "", err
+	}
+
+	if format == "png" {
+		err = png.Encode(file, thumbnail)
+	} else {
+		err = jpeg.Encode(file, thumbnail, &jpeg.Options{Quality: jpeg.DefaultQuality})
+	}
+	if closeError := file.Close(); err == nil {
+		err = closeError
+	}
+	if err != nil {
+		os.Remove(temporaryPath)
+		return "", err
+	}
+
+	return format, os.Rename(temporaryPath, thumbnailPath)
+}
+
+// GenerateThumb loads the map's thumbnail from the cache, creating it if the map is new or has changed
+func (mapFile *MapFile) GenerateThumb(cache *ThumbnailCache) error {
+	thumbnailPath, err := cache.Thumbnail(mapFile)
+	if err != nil {
+		return err
+	}
+
+	mapThumb, err := fyne.LoadResourceFromPath(thumbnailPath)
+	if err != nil {
+		return err
+	}
+
+	mapFile.FullThumbnailPath = thumbnailPath
+	mapFile.ThumbResource = mapThumb
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package mapFile
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestThumbnailCacheRegeneratesOnlyWhenTheMapChanges(t *testing.T) {
+	cache, err := OpenThumbnailCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapFile := writeTestMap(t, "cave_thumb", 600, 300)
+
+	first, err := cache.Thumbnail(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(first) != ".png" {
+		t.Errorf("expected a png map to keep a png thumbnail, got %s", first)
+	}
+	if filepath.Dir(first) != cache.Dir {
+		t.Errorf("expected the thumbnail inside the cache, got %s", first)
+	}
+
+	written := time.Now().Add(-time.Hour)
+	if err = os.Chtimes(first, written, written); err != nil {
+		t.Fatal(err)
+	}
+	if err = cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenThumbnailCache(cache.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := reopened.Thumbnail(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(second); second != first || !info.ModTime().Equal(written) {
+		t.Error("expected the unchanged map to reuse its thumbnail")
+	}
+
+	writeTestImage(t, mapFile.FullPath, image.NewRGBA(image.Rect(0, 0, 400, 400)))
+	changed := time.Now().Add(time.Minute)
+	if err = os.Chtimes(mapFile.FullPath, changed, changed); err != nil {
+		t.Fatal(err)
+	}
+
+	third, err := reopened.Thumbnail(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Error("expected a changed map to get a new thumbnail")
+	}
+}
+
+func TestThumbnailFormatFollowsThePicture(t *testing.T) {
+	cache, err := OpenThumbnailCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opaque := image.NewGray(image.Rect(0, 0, 300, 200))
+	tests := []struct {
+		name    string
+		picture image.Image
+		format  string
+	}{
+		{name: "transparent.Png", picture: image.NewNRGBA(image.Rect(0, 0, 300, 200)), format: ".png"},
+		{name: "opaque.PNG", picture: opaque, format: ".jpg"},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), test.name)
+		writeTestImage(t, path, test.picture)
+
+		thumbnail, err := cache.Thumbnail(&MapFile{FileName: test.name, FullPath: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(thumbnail) != test.format {
+			t.Errorf("%s: expected a %s thumbnail, got %s", test.name, test.format, thumbnail)
+		}
+	}
+}
+
+func TestThumbnailCacheReportsUnreadableMaps(t *testing.T) {
+	cache, err := OpenThumbnailCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "broken.jpg")
+	if err = os.WriteFile(path, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = cache.Thumbnail(&MapFile{FileName: "broken", Extension: "jpg", FullPath: path}); err == nil {
+		t.Error("expected an error for a map that can't be decoded")
+	}
+}
@@ -0,0 +1,284 @@
+package mapFile
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	// Dungeondraft can export its images as webp
+	_ "golang.org/x/image/webp"
+)
+
+// VTTExtensions are Universal VTT files, which hold a map image along with its grid, walls, doors and lights
+var VTTExtensions = []string{"dd2vtt", "uvtt"}
+
+// Scene is the geometry that came with a Universal VTT map, in map image pixels
+type Scene struct {
+	PixelsPerGrid float32 `json:"pixelsPerGrid"`
+	// Walls block line of sight, each is a chain of points
+	Walls  [][]fyne.Position `json:"walls"`
+	Doors  []Door            `json:"doors"`
+	Lights []Light           `json:"lights"`
+}
+
+// Door is an opening in the walls that blocks sight while it is closed
+type Door struct {
+	Bounds [2]fyne.Position `json:"bounds"`
+	Closed bool             `json:"closed"`
+}
+
+// Light is a light source placed on the map, Range is its radius in pixels
+type Light struct {
+	Position  fyne.Position `json:"position"`
+	Range     float32       `json:"range"`
+	Intensity float32       `json:"intensity"`
+	Color     color.NRGBA   `json:"color"`
+	Shadows   bool          `json:"shadows"`
+}
+
+// vttImport is what is kept in the cache for an imported file, the image sits next to it
+type vttImport struct {
+	Image string `json:"image"`
+	Scene Scene  `json:"scene"`
+}
+
+type uvttPoint struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+// uvttFile is the Universal VTT format, positions and ranges are in grid squares
+type uvttFile struct {
+	Resolution struct {
+		MapOrigin     uvttPoint `json:"map_origin"`
+		PixelsPerGrid float32   `json:"pixels_per_grid"`
+	} `json:"resolution"`
+	LineOfSight        [][]uvttPoint `json:"line_of_sight"`
+	ObjectsLineOfSight [][]uvttPoint `json:"objects_line_of_sight"`
+	Portals            []struct {
+		Bounds []uvttPoint `json:"bounds"`
+		Closed bool        `json:"closed"`
+	} `json:"portals"`
+	Lights []struct {
+		Position  uvttPoint `json:"position"`
+		Range     float32   `json:"range"`
+		Intensity float32   `json:"intensity"`
+		Color     string    `json:"color"`
+		Shadows   bool      `json:"shadows"`
+	} `json:"lights"`
+	Image string `json:"image"`
+}
+
+// IsVTTFile returns true if the file name has one of the VTTExtensions
+func IsVTTFile(fileName string) bool {
+	extension := strings.ToLower(fileName[strings.LastIndex(fileName, ".")+1:])
+	for _, vttExtension := range VTTExtensions {
+		if extension == vttExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultVTTDir returns the folder imported images are kept in under the user cache directory
+func DefaultVTTDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "DragonTable", "vtt"), nil
+}
+
+// ImagePath returns the file the map image is read from, which for Universal VTT maps is the extracted image
+func (mapFile *MapFile) ImagePath() string {
+	if mapFile.ImageFile != "" {
+		return mapFile.ImageFile
+	}
+
+	return mapFile.FullPath
+}
+
+// importVTT extracts the image and scene of a Universal VTT map
+func (mapFile *MapFile) importVTT() error {
+	cacheDir, err := DefaultVTTDir()
+	if err != nil {
+		return err
+	}
+
+	imagePath, scene, err := ImportVTT(mapFile.FullPath, cacheDir)
+	if err != nil {
+		return err
+	}
+
+	mapFile.ImageFile = imagePath
+	mapFile.Scene = scene
+
+	return nil
+}
+
+// ImportVTT extracts the image of a Universal VTT file into cacheDir and returns its path along with the file's walls,
+// doors and lights. The file is only read again once it changes.
+func ImportVTT(path string, cacheDir string) (string, *Scene, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		absolutePath = path
+	}
+	hash := sha1.Sum([]byte(absolutePath + "|" + strconv.FormatInt(info.Size(), 10) + "|" + strconv.FormatInt(info.ModTime().UnixNano(), 10)))
+	key := hex.EncodeToString(hash[:])
+	importPath := filepath.Join(cacheDir, key+".json")
+
+	if imported, readError := readVTTImport(importPath); readError == nil {
+		imagePath := filepath.Join(cacheDir, imported.Image)
+		if _, statError := os.Stat(imagePath); statError == nil {
+			return imagePath, &imported.Scene, nil
+		}
+	} else if !errors.Is(readError, os.ErrNotExist) {
+		fmt.Println(importPath, readError)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	scene, imageData, err := ParseVTT(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if format == "jpeg" {
+		format = "jpg"
+	}
+
+	if err = os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", nil, err
+	}
+
+	imported := vttImport{Image: key + "." + format, Scene: *scene}
+	if err = ioutil.WriteFile(filepath.Join(cacheDir, imported.Image), imageData, 0644); err != nil {
+		return "", nil, err
+	}
+
+	importData, err := json.Marshal(imported)
+	if err != nil {
+		return "", nil, err
+	}
+	if err = ioutil.WriteFile(importPath, importData, 0644); err != nil {
+		return "", nil, err
+	}
+
+	return filepath.Join(cacheDir, imported.Image), scene, nil
+}
+
+func readVTTImport(path string) (*vttImport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := &vttImport{}
+	if err = json.Unmarshal(data, imported); err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+// ParseVTT reads a Universal VTT file, returning its scene in image pixels and the image's encoded bytes
+func ParseVTT(data []byte) (*Scene, []byte, error) {
+	var file uvttFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, err
+	}
+
+	if file.Image == "" {
+		return nil, nil, errors.New("no image in the file")
+	}
+	imageData, err := base64.StdEncoding.DecodeString(file.Image)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pixelsPerGrid := file.Resolution.PixelsPerGrid
+	if pixelsPerGrid <= 0 {
+		return nil, nil, errors.New("pixels_per_grid is missing")
+	}
+
+	origin := file.Resolution.MapOrigin
+	toPixels := func(point uvttPoint) fyne.Position {
+		return fyne.NewPos((point.X-origin.X)*pixelsPerGrid, (point.Y-origin.Y)*pixelsPerGrid)
+	}
+
+	scene := &Scene{PixelsPerGrid: pixelsPerGrid}
+
+	for _, line := range append(file.LineOfSight, file.ObjectsLineOfSight...) {
+		if len(line) < 2 {
+			continue
+		}
+
+		wall := make([]fyne.Position, len(line))
+		for i, point := range line {
+			wall[i] = toPixels(point)
+		}
+		scene.Walls = append(scene.Walls, wall)
+	}
+
+	for _, portal := range file.Portals {
+		if len(portal.Bounds) < 2 {
+			continue
+		}
+
+		scene.Doors = append(scene.Doors, Door{Bounds: [2]fyne.Position{toPixels(portal.Bounds[0]), toPixels(portal.Bounds[1])}, Closed: portal.Closed})
+	}
+
+	for _, light := range file.Lights {
+		scene.Lights = append(scene.Lights, Light{
+			Position:  toPixels(light.Position),
+			Range:     light.Range * pixelsPerGrid,
+			Intensity: light.Intensity,
+			Color:     parseVTTColor(light.Color),
+			Shadows:   light.Shadows,
+		})
+	}
+
+	return scene, imageData, nil
+}
+
+// parseVTTColor reads the aarrggbb or rrggbb hex colors Universal VTT files use, anything else is white
+func parseVTTColor(text string) color.NRGBA {
+	text = strings.TrimPrefix(text, "#")
+
+	value, err := strconv.ParseUint(text, 16, 32)
+	if err != nil || (len(text) != 6 && len(text) != 8) {
+		return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+
+	if len(text) == 6 {
+		value |= 0xff000000
+	}
+
+	return color.NRGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: uint8(value >> 24)}
+}
@@ -0,0 +1,83 @@
+package mapFile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+func writeTestVTT(t *testing.T) string {
+	var imageData bytes.Buffer
+	if err := png.Encode(&imageData, image.NewRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	data := fmt.Sprintf(`{
+		"format": 0.3,
+		"resolution": {"map_origin": {"x": 1, "y": 0}, "map_size": {"x": 4, "y": 2}, "pixels_per_grid": 50},
+		"line_of_sight": [[{"x": 1, "y": 0}, {"x": 5, "y": 0}, {"x": 5, "y": 2}]],
+		"objects_line_of_sight": [[{"x": 2, "y": 1}, {"x": 3, "y": 1}]],
+		"portals": [{"position": {"x": 3, "y": 2}, "bounds": [{"x": 2.5, "y": 2}, {"x": 3.5, "y": 2}], "rotation": 0, "closed": true, "freestanding": false}],
+		"lights": [{"position": {"x": 2, "y": 1}, "range": 4, "intensity": 1, "color": "ffff8000", "shadows": true}],
+		"image": "%s"
+	}`, base64.StdEncoding.EncodeToString(imageData.Bytes()))
+
+	path := filepath.Join(t.TempDir(), "cave.dd2vtt")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestImportVTTExtractsImageAndGeometry(t *testing.T) {
+	cacheDir := t.TempDir()
+	path := writeTestVTT(t)
+
+	imagePath, scene, err := ImportVTT(path, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Ext(imagePath) != ".png" {
+		t.Errorf("expected the image to keep its format, got %s", imagePath)
+	}
+	mapFile := &MapFile{FullPath: path, ImageFile: imagePath}
+	if err = mapFile.readConfig(); err != nil || mapFile.Width != 200 || mapFile.Height != 100 {
+		t.Errorf("expected a 200x100 image, got %dx%d (%v)", mapFile.Width, mapFile.Height, err)
+	}
+
+	if scene.PixelsPerGrid != 50 || len(scene.Walls) != 2 || len(scene.Doors) != 1 || len(scene.Lights) != 1 {
+		t.Fatalf("expected 2 walls, a door and a light at 50 pixels per square, got %+v", scene)
+	}
+	if last := scene.Walls[0][2]; last != (fyne.Position{X: 200, Y: 100}) {
+		t.Errorf("expected wall points in pixels from the map origin, got %v", last)
+	}
+	if door := scene.Doors[0]; !door.Closed || door.Bounds[0].X != 75 || door.Bounds[1].X != 125 {
+		t.Errorf("expected a closed door from 75 to 125, got %+v", door)
+	}
+	light := scene.Lights[0]
+	if light.Range != 200 || light.Color != (color.NRGBA{R: 255, G: 128, B: 0, A: 255}) || light.Position.X != 50 {
+		t.Errorf("expected an orange light with a 200 pixel range, got %+v", light)
+	}
+
+	extracted := time.Now().Add(-time.Hour)
+	os.Chtimes(imagePath, extracted, extracted)
+
+	again, _, err := ImportVTT(path, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(again); again != imagePath || !info.ModTime().Equal(extracted) {
+		t.Error("expected an unchanged file to reuse the extracted image")
+	}
+}
@@ -0,0 +1,266 @@
+package mapFile
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDelay is how long a file has to be left alone before it is read, so maps still being copied aren't read half written
+const DefaultWatchDelay = 500 * time.Millisecond
+
+// Watcher follows the maps folder and its subfolders, reporting maps as they are added, changed and removed.
+// The callbacks are called from the watcher's goroutine.
+type Watcher struct {
+	Root      string
+	Delay     time.Duration
+	OnAdded   func(file *MapFile)
+	OnChanged func(old *MapFile, file *MapFile)
+	OnRemoved func(file *MapFile)
+
+	watcher *fsnotify.Watcher
+	files   map[string]*MapFile
+	pending map[string]*time.Timer
+	mutex   sync.Mutex
+}
+
+// NewWatcher creates a watcher for root that already knows about the maps read by GetMaps
+func NewWatcher(root string, files []*MapFile) *Watcher {
+	watcher := &Watcher{Root: root, Delay: DefaultWatchDelay, files: make(map[string]*MapFile), pending: make(map[string]*time.Timer)}
+
+	for _, file := range files {
+		watcher.files[filepath.Clean(file.FullPath)] = file
+	}
+
+	return watcher
+}
+
+// Start watches root and every folder under it
+func (watcher *Watcher) Start() error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	watcher.watcher = fsWatcher
+
+	if err = watcher.watchFolder(filepath.Clean(watcher.Root)); err != nil {
+		fsWatcher.Close()
+		return err
+	}
+
+	go watcher.run(fsWatcher)
+
+	return nil
+}
+
+// Stop ends watching, changes that are waiting out the delay are dropped
+func (watcher *Watcher) Stop() {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	for path, timer := range watcher.pending {
+		timer.Stop()
+		delete(watcher.pending, path)
+	}
+
+	if watcher.watcher != nil {
+		watcher.watcher.Close()
+		watcher.watcher = nil
+	}
+}
+
+func (watcher *Watcher) run(fsWatcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return
+			}
+			// permission and timestamp changes don't change the picture
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			watcher.schedule(filepath.Clean(event.Name))
+		case watchError, ok := <-fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Println(watchError)
+		}
+	}
+}
+
+// schedule reads a path once it has had no events for Delay
+func (watcher *Watcher) schedule(path string) {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	if watcher.watcher == nil {
+		return
+	}
+
+	if timer, found := watcher.pending[path]; found {
+		timer.Reset(watcher.Delay)
+		return
+	}
+
+	watcher.pending[path] = time.AfterFunc(watcher.Delay, func() {
+		watcher.mutex.Lock()
+		delete(watcher.pending, path)
+		stopped := watcher.watcher == nil
+		watcher.mutex.Unlock()
+
+		if !stopped {
+			watcher.update(path)
+		}
+	})
+}
+
+// update brings the library in line with whatever is at path now
+func (watcher *Watcher) update(path string) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		watcher.removeUnder(path)
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if info.IsDir() {
+		if watchError := watcher.watchFolder(path); watchError != nil {
+			fmt.Println(watchError)
+		}
+		return
+	}
+
+	watcher.updateFile(path)
+}
+
+// watchFolder adds a watch on a folder and its subfolders, picking up maps that were there before the watch
+func (watcher *Watcher) watchFolder(folder string) error {
+	if strings.HasPrefix(filepath.Base(folder), ".") && folder != filepath.Clean(watcher.Root) {
+		return nil
+	}
+
+	watcher.mutex.Lock()
+	fsWatcher := watcher.watcher
+	watcher.mutex.Unlock()
+	if fsWatcher == nil {
+		return nil
+	}
+
+	if err := fsWatcher.Add(folder); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		path := filepath.Join(folder, file.Name())
+		if file.IsDir() {
+			if watchError := watcher.watchFolder(path); watchError != nil {
+				fmt.Println(watchError)
+			}
+		} else if watcher.file(path) == nil {
+			watcher.updateFile(path)
+		}
+	}
+
+	return nil
+}
+
+// updateFile reads a map that is new or has changed
+func (watcher *Watcher) updateFile(path string) {
+	name := filepath.Base(path)
+	if !IsMapFile(name) {
+		return
+	}
+
+	siblings, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if isLegacyThumbnail(name, siblings) {
+		return
+	}
+
+	file := InitMapFile(filepath.ToSlash(path))
+	if file.Width == 0 || file.Height == 0 {
+		// most likely still being written, the next write brings it back here
+		return
+	}
+	file.Category = watcher.category(path)
+
+	if thumbnails, thumbnailError := Thumbnails(); thumbnailError == nil {
+		if saveError := thumbnails.Save(); saveError != nil {
+			fmt.Println(saveError)
+		}
+	}
+
+	watcher.mutex.Lock()
+	old := watcher.files[path]
+	watcher.files[path] = file
+	watcher.mutex.Unlock()
+
+	if old == nil {
+		fmt.Println("Added " + file.FileName)
+		if watcher.OnAdded != nil {
+			watcher.OnAdded(file)
+		}
+	} else {
+		fmt.Println("Changed " + file.FileName)
+		if watcher.OnChanged != nil {
+			watcher.OnChanged(old, file)
+		}
+	}
+}
+
+// removeUnder forgets the map at path, or every map in it when it was a folder
+func (watcher *Watcher) removeUnder(path string) {
+	var removed []*MapFile
+
+	watcher.mutex.Lock()
+	for filePath, file := range watcher.files {
+		if filePath == path || strings.HasPrefix(filePath, path+string(filepath.Separator)) {
+			removed = append(removed, file)
+			delete(watcher.files, filePath)
+		}
+	}
+	watcher.mutex.Unlock()
+
+	for _, file := range removed {
+		fmt.Println("Removed " + file.FileName)
+		if watcher.OnRemoved != nil {
+			watcher.OnRemoved(file)
+		}
+	}
+}
+
+func (watcher *Watcher) file(path string) *MapFile {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	return watcher.files[path]
+}
+
+// category is the folder of path relative to Root with "/" between folders, like GetMaps gives
+func (watcher *Watcher) category(path string) string {
+	folder, err := filepath.Rel(filepath.Clean(watcher.Root), filepath.Dir(path))
+	if err != nil || folder == "." {
+		return ""
+	}
+
+	return filepath.ToSlash(folder)
+}
@@ -0,0 +1,67 @@
+package mapFile
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func useTestThumbnails(t *testing.T) {
+	defaultThumbnailsOnce.Do(func() {})
+
+	thumbnails, err := OpenThumbnailCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultThumbnails = thumbnails
+}
+
+func waitFor(t *testing.T, events chan string, expected string) {
+	select {
+	case event := <-events:
+		if event != expected {
+			t.Fatalf("expected %q, got %q", expected, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %q", expected)
+	}
+}
+
+func TestWatcherFollowsTheMapsFolder(t *testing.T) {
+	useTestThumbnails(t)
+	root := t.TempDir()
+	events := make(chan string, 10)
+
+	watcher := NewWatcher(root, nil)
+	watcher.Delay = 20 * time.Millisecond
+	watcher.OnAdded = func(file *MapFile) { events <- "added " + file.Category + ":" + file.FileName }
+	watcher.OnChanged = func(old *MapFile, file *MapFile) { events <- "changed " + file.FileName }
+	watcher.OnRemoved = func(file *MapFile) { events <- "removed " + file.FileName }
+	if err := watcher.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	picture := image.NewRGBA(image.Rect(0, 0, 64, 64))
+
+	writeTestImage(t, filepath.Join(root, "tavern.png"), picture)
+	waitFor(t, events, "added :tavern")
+
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("not a map"), 0644)
+	writeTestImage(t, filepath.Join(root, "tavern.png"), picture)
+	waitFor(t, events, "changed tavern")
+
+	folder := filepath.Join(root, "Dungeons", "Level 1")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestImage(t, filepath.Join(folder, "crypt.png"), picture)
+	waitFor(t, events, "added Dungeons/Level 1:crypt")
+
+	if err := os.RemoveAll(filepath.Join(root, "Dungeons")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, events, "removed crypt")
+}
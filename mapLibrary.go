@@ -0,0 +1,389 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/widgetExt"
+)
+
+const LoadingIndicatorWidth float32 = 400
+const LoadingIndicatorHeight float32 = 80
+const MapLibraryWidth float32 = 300
+const MapDetailsWidth float32 = 400
+const MapDetailsHeight float32 = 200
+const TouchKeyboardWidth float32 = 760
+const TouchKeyboardHeight float32 = 300
+
+// libraryIndent is put in front of a category's name for each folder it is nested in
+const libraryIndent string = "    "
+
+var MapCache *mapFile.Cache
+var MapLibrary *fyne.Container
+var MapWatchers []*mapFile.Watcher
+
+// loadingMap is the map the GM asked for last while it loads, a map that finishes loading after another was chosen isn't shown
+var loadingMap *mapFile.MapFile
+var loadingMutex sync.Mutex
+var libraryRows []mapFile.LibraryRow
+var collapsedCategories = make(map[string]bool)
+
+// libraryMutex guards mapFiles, libraryRows and collapsedCategories, the folder watchers change them from their own goroutines
+var libraryMutex sync.Mutex
+var librarySearch *widgetExt.TouchEntry
+var touchKeyboard *widgetExt.TouchKeyboard
+
+// OpenMap shows a map from the library, decoding it in the background first with a progress bar when it isn't loaded
+func OpenMap(file *mapFile.MapFile) {
+	loadingMutex.Lock()
+	if file.Loaded() {
+		loadingMap = nil
+		loadingMutex.Unlock()
+
+		MapCache.Use(file)
+		ShowMap(file)
+		return
+	}
+
+	if loadingMap == file {
+		loadingMutex.Unlock()
+		return
+	}
+	loadingMap = file
+	loadingMutex.Unlock()
+
+	tableIndicator, tableProgress := BuildLoadingIndicator(file, float32(ScreenWidth), float32(ScreenHeight))
+	mainContent.Add(tableIndicator)
+
+	var gmIndicator *fyne.Container
+	var gmProgress func(float32)
+	if gmMapArea != nil {
+		gmIndicator, gmProgress = BuildLoadingIndicator(file, float32(GMWidth), float32(GMHeight))
+		gmMapArea.Add(gmIndicator)
+	}
+
+	go func() {
+		loadError := file.Load(func(progress float32) {
+			tableProgress(progress)
+			if gmProgress != nil {
+				gmProgress(progress)
+			}
+		})
+
+		mainContent.Remove(tableIndicator)
+		if gmIndicator != nil {
+			gmMapArea.Remove(gmIndicator)
+		}
+
+		loadingMutex.Lock()
+		latest := loadingMap == file
+		if latest {
+			loadingMap = nil
+		}
+		loadingMutex.Unlock()
+
+		if loadError != nil {
+			fmt.Println(loadError)
+			return
+		}
+		// a map that was passed over stays loaded, so the cache has to count it
+		MapCache.Use(file)
+		if latest {
+			ShowMap(file)
+		}
+	}()
+}
+
+// WatchMaps follows the maps folder so maps that are copied in, edited or deleted show up in the library straight away
+func WatchMaps() {
+	for _, watcher := range MapWatchers {
+		watcher.Stop()
+	}
+	MapWatchers = nil
+
+	for _, mapPath := range mapFile.MapPaths {
+		MapWatchers = append(MapWatchers, watchMapPath(mapPath))
+	}
+}
+
+func watchMapPath(mapPath string) *mapFile.Watcher {
+	libraryMutex.Lock()
+	known := append([]*mapFile.MapFile(nil), mapFiles...)
+	libraryMutex.Unlock()
+
+	watcher := mapFile.NewWatcher(mapPath, known)
+	watcher.OnAdded = func(file *mapFile.MapFile) {
+		libraryMutex.Lock()
+		mapFiles = append(mapFiles, file)
+		libraryMutex.Unlock()
+
+		RefreshLibrary()
+	}
+	watcher.OnChanged = func(old *mapFile.MapFile, file *mapFile.MapFile) {
+		// a changed map that is on screen stays as it was until it is opened again
+		libraryMutex.Lock()
+		for i := range mapFiles {
+			if mapFiles[i] == old {
+				mapFiles[i] = file
+			}
+		}
+		libraryMutex.Unlock()
+
+		MapCache.Remove(old)
+		RefreshLibrary()
+	}
+	watcher.OnRemoved = func(file *mapFile.MapFile) {
+		libraryMutex.Lock()
+		var remaining []*mapFile.MapFile
+		for _, existing := range mapFiles {
+			if existing != file {
+				remaining = append(remaining, existing)
+			}
+		}
+		mapFiles = remaining
+		libraryMutex.Unlock()
+
+		MapCache.Remove(file)
+		RefreshLibrary()
+	}
+
+	if watchError := watcher.Start(); watchError != nil {
+		fmt.Println(watchError)
+	}
+
+	return watcher
+}
+
+// MapInUse returns true if a map is on the table or the GM screen and must stay loaded
+func MapInUse(file *mapFile.MapFile) bool {
+	return (TableView != nil && TableView.CurrentMapFile == file) || (GMView != nil && GMView.CurrentMapFile == file)
+}
+
+// BuildLoadingIndicator creates a progress bar centered on a screen of the given size, along with a function that moves it
+func BuildLoadingIndicator(file *mapFile.MapFile, width float32, height float32) (*fyne.Container, func(float32)) {
+
+	var progressBar fyne.CanvasObject
+	setProgress := func(float32) {}
+
+	// tiles are cut without knowing how far along they are
+	if file.Tiled() {
+		progressBar = widget.NewProgressBarInfinite()
+	} else {
+		bar := widget.NewProgressBar()
+		progressBar = bar
+		setProgress = func(progress float32) {
+			bar.SetValue(float64(progress))
+		}
+	}
+
+	indicator := container.NewVBox(widget.NewLabel("Loading "+file.FileName), progressBar)
+	indicator.Resize(fyne.NewSize(LoadingIndicatorWidth, LoadingIndicatorHeight))
+	indicator.Move(fyne.NewPos((width-LoadingIndicatorWidth)/2, (height-LoadingIndicatorHeight)/2))
+
+	return container.NewWithoutLayout(indicator), setProgress
+}
+
+// BuildMapLibrary puts a search box above the map list
+func BuildMapLibrary(mapList *widget.List) *fyne.Container {
+
+	searchText := librarySearchText()
+	librarySearch = widgetExt.NewTouchEntry()
+	librarySearch.SetPlaceHolder("Search by name or tag")
+	librarySearch.SetText(searchText)
+	librarySearch.OnChanged = func(string) {
+		RefreshLibrary()
+	}
+	librarySearch.OnFocused = func() {
+		// the GM screen has a real keyboard
+		if GMWindow == nil {
+			ShowTouchKeyboard(librarySearch, nil)
+		}
+	}
+
+	clearButton := widget.NewButtonWithIcon("", theme.ContentClearIcon(), func() {
+		librarySearch.SetText("")
+	})
+
+	searchRow := container.NewBorder(nil, nil, nil, clearButton, librarySearch)
+
+	return container.NewBorder(searchRow, nil, nil, nil, mapList)
+}
+
+// RefreshLibrary lays the map list out again after a search, a category being opened or closed, or a map changing
+func RefreshLibrary() {
+	searchText := librarySearchText()
+
+	libraryMutex.Lock()
+	libraryRows = mapFile.LibraryRows(mapFiles, searchText, collapsedCategories)
+	libraryMutex.Unlock()
+
+	if MapList != nil {
+		MapList.Refresh()
+	}
+}
+
+func librarySearchText() string {
+	if librarySearch == nil {
+		return ""
+	}
+
+	return librarySearch.Text
+}
+
+// NewLibraryItem creates a list item that can show either a category or a map
+func NewLibraryItem() *fyne.Container {
+	categoryButton := widget.NewButton("", nil)
+	categoryButton.Alignment = widget.ButtonAlignLeading
+
+	mapButton := widgetExt.NewImageButton("", nil, nil)
+	detailsButton := widget.NewButtonWithIcon("", theme.MoreVerticalIcon(), nil)
+
+	return container.NewBorder(nil, nil, nil, detailsButton, container.NewMax(categoryButton, mapButton))
+}
+
+// UpdateLibraryItem fills an item made by NewLibraryItem with a row of the library
+func UpdateLibraryItem(item *fyne.Container, row mapFile.LibraryRow) {
+	// a border container keeps its center objects first and the right object last
+	buttons := item.Objects[0].(*fyne.Container)
+	categoryButton := buttons.Objects[0].(*widget.Button)
+	mapButton := buttons.Objects[1].(*widgetExt.ImageButton)
+	detailsButton := item.Objects[1].(*widget.Button)
+
+	if row.IsCategory() {
+		mapButton.Hide()
+		detailsButton.Hide()
+		categoryButton.Show()
+
+		icon := theme.FolderOpenIcon()
+		if row.Collapsed {
+			icon = theme.FolderIcon()
+		}
+		categoryButton.SetIcon(icon)
+		categoryButton.SetText(strings.Repeat(libraryIndent, row.Depth) + row.Name)
+		categoryButton.OnTapped = func() {
+			libraryMutex.Lock()
+			collapsedCategories[row.Category] = !collapsedCategories[row.Category]
+			libraryMutex.Unlock()
+
+			RefreshLibrary()
+		}
+		return
+	}
+
+	file := row.File
+	categoryButton.Hide()
+	mapButton.Show()
+	detailsButton.Show()
+
+	name := file.FileName
+	if file.Metadata.Favorite {
+		name = "★ " + name
+	}
+	mapButton.SetText(name)
+	mapButton.SetImage(file.ThumbResource)
+	mapButton.OnTapped = func() {
+		fmt.Println("Clicked : " + file.FileName)
+		fmt.Println("Width : " + strconv.Itoa(file.Width))
+		fmt.Println("Height : " + strconv.Itoa(file.Height))
+
+		if TableView.IsShowingFile(file) {
+			HideMap()
+		} else {
+			OpenMap(file)
+		}
+	}
+	detailsButton.OnTapped = func() {
+		ShowMapDetails(file)
+	}
+}
+
+// ShowMapDetails opens a panel to mark a map as a favorite and edit its tags
+func ShowMapDetails(file *mapFile.MapFile) {
+
+	area, width, height := mainContent, float32(ScreenWidth), float32(ScreenHeight)
+	if GMWindow != nil {
+		area, width, height = gmMapArea, float32(GMWidth), float32(GMHeight)
+	}
+
+	favoriteCheck := widget.NewCheck("Favorite", nil)
+	favoriteCheck.SetChecked(file.Metadata.Favorite)
+
+	tagsEntry := widgetExt.NewTouchEntry()
+	tagsEntry.SetPlaceHolder("Tags, separated by commas")
+	tagsEntry.SetText(strings.Join(file.Metadata.Tags, ", "))
+
+	var details *fyne.Container
+	closeDetails := func() {
+		area.Remove(details)
+		HideTouchKeyboard()
+	}
+
+	saveButton := widget.NewButton("Save", func() {
+		file.Metadata.Favorite = favoriteCheck.Checked
+		if saveError := file.SetTags(mapFile.ParseTags(tagsEntry.Text)); saveError != nil {
+			fmt.Println(saveError)
+		}
+
+		closeDetails()
+		RefreshLibrary()
+	})
+	saveButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabelWithStyle(file.FileName, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		favoriteCheck,
+		tagsEntry,
+		container.NewGridWithColumns(2, widget.NewButton("Cancel", closeDetails), saveButton),
+	)
+	panel.Resize(fyne.NewSize(MapDetailsWidth, MapDetailsHeight))
+	panel.Move(fyne.NewPos((width-MapDetailsWidth)/2, height/4))
+
+	details = container.NewWithoutLayout(panel)
+	area.Add(details)
+
+	if GMWindow == nil {
+		tagsEntry.OnFocused = func() {
+			ShowTouchKeyboard(tagsEntry, nil)
+		}
+		ShowTouchKeyboard(tagsEntry, nil)
+	}
+}
+
+// ShowTouchKeyboard opens the on-screen keyboard at the bottom of the table, typing into target
+func ShowTouchKeyboard(target fyne.Focusable, onDone func()) {
+	if touchKeyboard == nil {
+		touchKeyboard = widgetExt.NewTouchKeyboard(nil)
+		touchKeyboard.Content.Resize(fyne.NewSize(TouchKeyboardWidth, TouchKeyboardHeight))
+	}
+
+	touchKeyboard.Target = target
+	touchKeyboard.OnDone = func() {
+		HideTouchKeyboard()
+		if onDone != nil {
+			onDone()
+		}
+	}
+
+	touchKeyboard.Content.Move(fyne.NewPos((float32(ScreenWidth)-TouchKeyboardWidth)/2, float32(ScreenHeight)-TouchKeyboardHeight-20))
+	mainContent.Remove(touchKeyboard.Content)
+	mainContent.Add(touchKeyboard.Content)
+}
+
+// HideTouchKeyboard closes the on-screen keyboard if it is open
+func HideTouchKeyboard() {
+	if touchKeyboard == nil {
+		return
+	}
+
+	mainContent.Remove(touchKeyboard.Content)
+	touchKeyboard.Target = nil
+	MainWindow.Canvas().Unfocus()
+}
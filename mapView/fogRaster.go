@@ -0,0 +1,61 @@
+package mapView
+
+import (
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/fog"
+)
+
+var DefaultFogColor = color.NRGBA{R: 10, G: 10, B: 14, A: 255}
+
+// SetFog covers the current map with a fog mask, nil removes the fog
+func (view *MapView) SetFog(mask *fog.Mask) {
+	view.Fog = mask
+
+	view.RedrawFog()
+}
+
+// RedrawFog draws the fog again after the mask changed
+func (view *MapView) RedrawFog() {
+	if view.FogOverlay != nil {
+		view.FogOverlay.Refresh()
+	}
+}
+
+// drawFog renders the fog over the part of the map that is on screen, it is the generator of FogOverlay
+func (view *MapView) drawFog(width int, height int) image.Image {
+
+	img := view.fogRaster.clear(width, height)
+	mask := view.Fog
+	if mask == nil || view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return img
+	}
+
+	view.fogRaster.locate(view, view.FogOverlay, mask.Scale)
+	columns, rows := view.fogRaster.columns, view.fogRaster.rows
+
+	opacity := fyne.Min(fyne.Max(view.FogOpacity, 0), 1)
+	mask.Read(func(alpha *image.Alpha) {
+		for y, row := range rows {
+			if row < 0 || row >= alpha.Rect.Max.Y {
+				continue
+			}
+
+			for x, column := range columns {
+				if column < 0 || column >= alpha.Rect.Max.X {
+					continue
+				}
+				if value := alpha.Pix[row*alpha.Stride+column]; value != fog.Revealed {
+					pixel := view.FogColor
+					pixel.A = uint8(float32(value) * float32(pixel.A) / 255 * opacity)
+					img.SetNRGBA(x, y, pixel)
+				}
+			}
+		}
+	})
+
+	return img
+}
@@ -0,0 +1,117 @@
+package mapView
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// GridStyle describes how grid lines are drawn. Dash and Gap are lengths along the line, a Dash of 0 draws solid lines.
+type GridStyle struct {
+	Color     color.NRGBA
+	Opacity   float32
+	LineWidth float32
+	Dash      float32
+	Gap       float32
+}
+
+var DefaultGridStyle = GridStyle{Color: color.NRGBA{R: 56, G: 56, B: 56, A: 255}, Opacity: 1, LineWidth: 1}
+
+// drawGrid renders the part of the grid that is on screen, it is the generator of GridOverlay
+func (view *MapView) drawGrid(width int, height int) image.Image {
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if !view.gridVisible || view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return img
+	}
+
+	// the raster may be drawn at a different pixel density than the canvas
+	scale := float32(1)
+	if size := view.GridOverlay.Size(); size.Width > 0 {
+		scale = float32(width) / size.Width
+	}
+
+	zoom := float32(view.Zoom())
+	offset := view.MapControl.Offset
+	min := fyne.NewPos(fyne.Max(offset.X, 0), fyne.Max(offset.Y, 0))
+	max := fyne.NewPos(fyne.Min(offset.X+float32(width)/scale, view.CurrentMapSize.Width*zoom), fyne.Min(offset.Y+float32(height)/scale, view.CurrentMapSize.Height*zoom))
+	if min.X >= max.X || min.Y >= max.Y {
+		return img
+	}
+
+	style := view.GridStyle
+	lineColor := style.Color
+	lineColor.A = uint8(float32(lineColor.A) * fyne.Min(fyne.Max(style.Opacity, 0), 1))
+
+	for _, line := range view.scaledGrid(zoom).Lines(min, max) {
+		position1, position2, visible := geometry.ClipSegment(line.Position1, line.Position2, min, max)
+		if visible {
+			drawGridLine(img, position1, position2, offset, scale, lineColor, style)
+		}
+	}
+
+	return img
+}
+
+// drawGridLine stamps a line of map content positions into the image. Dashes are measured from the content origin
+// so they stay in place while the map scrolls.
+func drawGridLine(img *image.NRGBA, position1 fyne.Position, position2 fyne.Position, offset fyne.Position, scale float32, lineColor color.NRGBA, style GridStyle) {
+
+	length := geometry.Distance(position1, position2)
+	if length == 0 {
+		return
+	}
+
+	directionX, directionY := (position2.X-position1.X)/length, (position2.Y-position1.Y)/length
+	penWidth := int(math.Max(1, math.Round(float64(style.LineWidth*scale))))
+	penStart := float32(penWidth-1) / 2
+	period := style.Dash + style.Gap
+
+	// two steps per pixel so diagonal lines have no holes
+	steps := int(length*scale*2) + 1
+	for step := 0; step <= steps; step++ {
+		along := length * float32(step) / float32(steps)
+		x, y := position1.X+directionX*along, position1.Y+directionY*along
+
+		if style.Dash > 0 && period > 0 {
+			phase := math.Mod(float64(x*directionX+y*directionY), float64(period))
+			if phase < 0 {
+				phase += float64(period)
+			}
+			if phase >= float64(style.Dash) {
+				continue
+			}
+		}
+
+		pixelX := int(math.Floor(float64((x-offset.X)*scale - penStart)))
+		pixelY := int(math.Floor(float64((y-offset.Y)*scale - penStart)))
+		for dy := 0; dy < penWidth; dy++ {
+			for dx := 0; dx < penWidth; dx++ {
+				if (image.Point{X: pixelX + dx, Y: pixelY + dy}).In(img.Rect) {
+					img.SetNRGBA(pixelX+dx, pixelY+dy, lineColor)
+				}
+			}
+		}
+	}
+}
+
+// ParseColor reads a color written as #rrggbb or #rrggbbaa
+func ParseColor(hex string) (color.NRGBA, error) {
+	parsed := color.NRGBA{A: 255}
+
+	var err error
+	if len(hex) == 9 {
+		_, err = fmt.Sscanf(hex, "#%02x%02x%02x%02x", &parsed.R, &parsed.G, &parsed.B, &parsed.A)
+	} else if len(hex) == 7 {
+		_, err = fmt.Sscanf(hex, "#%02x%02x%02x", &parsed.R, &parsed.G, &parsed.B)
+	} else {
+		err = fmt.Errorf("color %q is not #rrggbb or #rrggbbaa", hex)
+	}
+
+	return parsed, err
+}
@@ -0,0 +1,95 @@
+package mapView
+
+import (
+	"image"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/lighting"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+// LightTintStrength is how strongly a fully lit pixel takes on the color of the lights reaching it
+const LightTintStrength float32 = 0.35
+
+// SetLighting darkens the map outside its lights, nil turns lighting off
+func (view *MapView) SetLighting(layer *lighting.Layer) {
+	view.Lighting = layer
+
+	view.RedrawLighting()
+}
+
+// RedrawLighting draws the lighting again after the lights changed or flickered
+func (view *MapView) RedrawLighting() {
+	if view.LightingOverlay != nil {
+		view.LightingOverlay.Refresh()
+	}
+}
+
+// LoadLighting sets up the lights of a map from its metadata, nil when lighting is off for the map
+func LoadLighting(file *mapFile.MapFile) *lighting.Layer {
+	settings := file.Metadata.Lighting
+	if settings == nil {
+		return nil
+	}
+
+	walls := vision.SceneWalls(file.Scene, file.Metadata.Doors, float32(file.Width), float32(file.Height))
+	layer := lighting.New(walls, file.Width, file.Height)
+	layer.SetAmbient(settings.Ambient)
+	layer.SetLights(settings.Lights)
+
+	return layer
+}
+
+// drawLighting darkens the part of the map that is on screen where the lights don't reach and tints it
+// where they do, it is the generator of LightingOverlay
+func (view *MapView) drawLighting(width int, height int) image.Image {
+
+	img := view.lightingRaster.clear(width, height)
+	layer := view.Lighting
+	if layer == nil || view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return img
+	}
+
+	view.lightingRaster.locate(view, view.LightingOverlay, layer.Scale)
+	columns, rows := view.lightingRaster.columns, view.lightingRaster.rows
+
+	opacity := fyne.Min(fyne.Max(view.LightingOpacity, 0), 1)
+	layer.Read(time.Now(), func(light *image.NRGBA) {
+		for y, row := range rows {
+			if row < 0 || row >= light.Rect.Max.Y {
+				continue
+			}
+
+			for x, column := range columns {
+				if column < 0 || column >= light.Rect.Max.X {
+					continue
+				}
+
+				lit := light.NRGBAAt(column, row)
+				darkness := (1 - float32(lit.A)/255) * opacity
+
+				// white light only brightens, colored light tints the map by how saturated it is
+				high := fyne.Max(float32(lit.R), fyne.Max(float32(lit.G), float32(lit.B)))
+				low := fyne.Min(float32(lit.R), fyne.Min(float32(lit.G), float32(lit.B)))
+				tint := float32(lit.A) / 255 * LightTintStrength * (high - low) / 255 * opacity
+
+				alpha := darkness + tint*(1-darkness)
+				if alpha <= 0 {
+					continue
+				}
+
+				share := tint * (1 - darkness) / alpha
+				lit.R = uint8(float32(lit.R) * share)
+				lit.G = uint8(float32(lit.G) * share)
+				lit.B = uint8(float32(lit.B) * share)
+				lit.A = uint8(alpha * 255)
+				img.SetNRGBA(x, y, lit)
+			}
+		}
+	})
+
+	return img
+}
@@ -0,0 +1,503 @@
+package mapView
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/fog"
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/lighting"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/touch"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+const ScreenDimensionWidth int = 30
+const ScreenDimensionHeight int = 16
+const ZoomSliderWidth float32 = 150
+const ZoomSliderHeight float32 = 50
+const ZoomSliderXOffset int = 200
+const ZoomSliderYOffset int = 150
+const DefaultMaxZoom float64 = 2
+
+// MapView is the scrollable, zoomable surface the current map is shown on, together with its grid and zoom control
+type MapView struct {
+	ScreenWidth  int
+	ScreenHeight int
+
+	CurrentMap      *canvas.Image
+	CurrentMapFile  *mapFile.MapFile
+	CurrentMapSize  fyne.Size
+	MapContent      *fyne.Container
+	MapControl      *container.Scroll
+	LightingOverlay *canvas.Raster
+	GridOverlay     *canvas.Raster
+	TemplateOverlay *canvas.Raster
+	FogOverlay      *canvas.Raster
+	VisionOverlay   *canvas.Raster
+	ZoomControl     *fyne.Container
+	ZoomSlider      *widget.Slider
+	Panning         *PanController
+
+	// CellWidth and CellHeight are the on screen size of one grid square, one inch once the display is calibrated
+	CellWidth  float32
+	CellHeight float32
+
+	// MapGrid is the grid printed on the current map, when set the grid follows the map instead of the screen
+	MapGrid   *mapFile.GridSettings
+	GridStyle GridStyle
+
+	// Fog hides the parts of the current map the players haven't explored, nil when the map has no fog
+	Fog        *fog.Mask
+	FogColor   color.NRGBA
+	FogOpacity float32
+	fogRaster  overlayRaster
+
+	// Vision hides what the viewers can't see and dims what they saw before, nil when line of sight is off
+	Vision          *vision.Vision
+	VisionColor     color.NRGBA
+	VisionOpacity   float32
+	ExploredOpacity float32
+	visionRaster    overlayRaster
+
+	// Lighting darkens the map outside its lights, nil when lighting is off
+	Lighting        *lighting.Layer
+	LightingOpacity float32
+	lightingRaster  overlayRaster
+
+	// Tokens are the creatures on the map, TokenDir is the folder their pictures are in
+	Tokens         []mapFile.Token
+	TokenDir       string
+	tokens         []tokenObjects
+	tokenContainer *fyne.Container
+	// ShowAllHealth shows the hit points of every token, not only those shown to the players
+	ShowAllHealth bool
+
+	// Templates are the areas of effect on the map, drawn with the cells they affect
+	Templates     []mapFile.Template
+	TemplateColor color.NRGBA
+
+	tiles *tileLayer
+
+	// CopyMapImages makes the view create its own image of each map it shows, so a second window can show the same maps
+	CopyMapImages bool
+
+	// MinZoom and MaxZoom limit the zoom slider, a MinZoom of 0 lets the map be zoomed out until it fits the screen
+	MinZoom float64
+	MaxZoom float64
+
+	// OnViewChanged is called after the map was panned or zoomed
+	OnViewChanged func()
+
+	gridVisible bool
+	// zoom is kept apart from the slider, which snaps its value to the slider steps
+	zoom float64
+}
+
+// NewMapView creates a view filling a screen of the given size, showing image once ShowCurrentMap is called
+func NewMapView(screenWidth int, screenHeight int, image *canvas.Image) *MapView {
+
+	view := &MapView{ScreenWidth: screenWidth, ScreenHeight: screenHeight, GridStyle: DefaultGridStyle, FogColor: DefaultFogColor, FogOpacity: 1, MaxZoom: DefaultMaxZoom, zoom: 1}
+	view.VisionColor, view.VisionOpacity, view.ExploredOpacity = DefaultVisionColor, 1, DefaultExploredOpacity
+	view.LightingOpacity = 1
+	view.TemplateColor = DefaultTemplateColor
+	view.CellWidth = float32(screenWidth / ScreenDimensionWidth)
+	view.CellHeight = float32(screenHeight / ScreenDimensionHeight)
+
+	view.buildZoomControls()
+	view.Panning = NewPanController(view.scroll)
+
+	view.tokenContainer = container.NewWithoutLayout()
+	view.MapControl = container.NewScroll(container.NewWithoutLayout())
+	view.MapControl.Resize(fyne.NewSize(float32(screenWidth), float32(screenHeight)))
+	view.MapControl.Move(fyne.Position{X: -2, Y: -2})
+	view.MapControl.OnScrolled = func(fyne.Position) {
+		view.refreshLayers()
+		view.viewChanged()
+	}
+
+	view.LightingOverlay = canvas.NewRaster(view.drawLighting)
+	view.LightingOverlay.Resize(view.MapControl.Size())
+	view.LightingOverlay.Move(view.MapControl.Position())
+
+	view.GridOverlay = canvas.NewRaster(view.drawGrid)
+	view.GridOverlay.Resize(view.MapControl.Size())
+	view.GridOverlay.Move(view.MapControl.Position())
+	view.GridOverlay.Hide()
+
+	view.TemplateOverlay = canvas.NewRaster(view.drawTemplates)
+	view.TemplateOverlay.Resize(view.MapControl.Size())
+	view.TemplateOverlay.Move(view.MapControl.Position())
+
+	view.VisionOverlay = canvas.NewRaster(view.drawVision)
+	view.VisionOverlay.Resize(view.MapControl.Size())
+	view.VisionOverlay.Move(view.MapControl.Position())
+
+	view.FogOverlay = canvas.NewRaster(view.drawFog)
+	view.FogOverlay.Resize(view.MapControl.Size())
+	view.FogOverlay.Move(view.MapControl.Position())
+
+	if image != nil {
+		view.SetCurrentMap(image)
+		view.CurrentMap.Hide()
+	}
+
+	return view
+}
+
+// IsShowing returns true when the view is displaying a map with the given file or resource name
+func (view *MapView) IsShowing(resourceName string) bool {
+	if view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return false
+	}
+
+	if view.CurrentMapFile != nil {
+		return view.CurrentMapFile.FileName+"."+view.CurrentMapFile.Extension == resourceName
+	}
+
+	return view.CurrentMap.Resource != nil && view.CurrentMap.Resource.Name() == resourceName
+}
+
+// IsShowingFile returns true when the view is displaying the given map
+func (view *MapView) IsShowingFile(file *mapFile.MapFile) bool {
+	return file != nil && view.CurrentMapFile == file && view.CurrentMap != nil && !view.CurrentMap.Hidden
+}
+
+// HideCurrentMap hides the map and its zoom control
+func (view *MapView) HideCurrentMap() {
+	if view.CurrentMap != nil {
+		view.CurrentMap.Hide()
+		view.ZoomControl.Hide()
+		view.refreshLayers()
+	}
+}
+
+// ShowCurrentMap shows the map and resets the zoom range to fit it
+func (view *MapView) ShowCurrentMap() {
+	if view.CurrentMap != nil {
+		view.CurrentMap.Show()
+		view.ZoomControl.Show()
+		view.SetZoomSliderRange()
+	}
+}
+
+// ShowMapFile displays a map from the library along with its grid settings
+func (view *MapView) ShowMapFile(file *mapFile.MapFile) {
+
+	// large maps are shown from a preview with tiles drawn over it instead of decoding the whole image
+	var image *canvas.Image
+	var layer *tileLayer
+	if !file.Loaded() {
+		fmt.Println(file.FileName + " has not been loaded")
+		return
+	} else if file.Tiled() {
+		var tileError error
+		if image, layer, tileError = view.openTiles(file); tileError != nil {
+			fmt.Println(tileError)
+			return
+		}
+	} else if view.CopyMapImages {
+		image = canvas.NewImageFromImage(file.Image.Image)
+	} else {
+		image = file.Image
+	}
+
+	view.CurrentMapFile = file
+	view.MapGrid = file.Metadata.Grid
+	view.Fog = nil
+	if len(file.Metadata.Fog) > 0 {
+		mask, fogError := fog.Decode(file.Metadata.Fog, file.Width, file.Height)
+		if fogError != nil {
+			fmt.Println(fogError)
+		}
+		view.Fog = mask
+	}
+	view.Vision = LoadVision(file)
+	view.Lighting = LoadLighting(file)
+
+	view.tiles = layer
+	view.setCurrentMap(image, fyne.NewSize(float32(file.Width), float32(file.Height)))
+	view.SetTokens(file.Metadata.Tokens)
+	view.SetTemplates(file.Metadata.Templates)
+	view.ShowCurrentMap()
+}
+
+// SetCurrentMap replaces the displayed map with an image that has no grid settings
+func (view *MapView) SetCurrentMap(image *canvas.Image) {
+	view.CurrentMapFile = nil
+	view.MapGrid = nil
+	view.Fog = nil
+	view.Vision = nil
+	view.Lighting = nil
+	view.tiles = nil
+	view.setCurrentMap(image, image.Size())
+	view.SetTokens(nil)
+	view.SetTemplates(nil)
+}
+
+func (view *MapView) setCurrentMap(image *canvas.Image, size fyne.Size) {
+	view.CurrentMap = image
+	view.CurrentMap.FillMode = canvas.ImageFillStretch
+	view.CurrentMap.Move(fyne.Position{X: 0, Y: 0})
+	view.CurrentMapSize = size
+
+	fmt.Println(view.CurrentMapSize.Width, view.CurrentMapSize.Height)
+
+	view.MapContent = container.NewWithoutLayout(view.CurrentMap)
+	if view.tiles != nil {
+		view.MapContent.Add(view.tiles.container)
+	}
+	view.MapContent.Add(view.tokenContainer)
+
+	view.MapControl.Content = view.MapContent
+	view.MapControl.Refresh()
+	view.refreshLayers()
+}
+
+// GridVisible returns true if the grid lines are shown
+func (view *MapView) GridVisible() bool {
+	return view.gridVisible
+}
+
+// SetGridVisible shows or hides the grid lines
+func (view *MapView) SetGridVisible(visible bool) {
+	view.gridVisible = visible
+
+	if visible {
+		view.GridOverlay.Show()
+		view.RedrawGrid()
+	} else {
+		view.GridOverlay.Hide()
+	}
+}
+
+// ToggleGrid shows or hides the grid lines and returns true if they are now visible
+func (view *MapView) ToggleGrid() bool {
+	view.SetGridVisible(!view.gridVisible)
+
+	return view.gridVisible
+}
+
+// SetCellSize changes the on screen size of a grid square and redraws the grid
+func (view *MapView) SetCellSize(width float32, height float32) {
+	view.CellWidth = width
+	view.CellHeight = height
+
+	view.RedrawGrid()
+}
+
+// refreshLayers updates the map tiles and draws the layers over the map again after it moved or changed
+func (view *MapView) refreshLayers() {
+	view.updateTiles()
+	view.layoutTokens()
+	view.RedrawLighting()
+	view.RedrawGrid()
+	view.RedrawTemplates()
+	view.RedrawVision()
+	view.RedrawFog()
+}
+
+// SetGridStyle changes how the grid lines are drawn
+func (view *MapView) SetGridStyle(style GridStyle) {
+	view.GridStyle = style
+
+	view.RedrawGrid()
+}
+
+// RedrawGrid draws the grid again after the grid settings, zoom or scroll position changed
+func (view *MapView) RedrawGrid() {
+	if view.GridOverlay != nil && view.gridVisible {
+		view.GridOverlay.Refresh()
+	}
+}
+
+// Grid returns the grid shown over the map, in map image pixels
+func (view *MapView) Grid() grid.Grid {
+	return view.scaledGrid(1)
+}
+
+// scaledGrid returns the grid for the map drawn at scale. Without map settings the grid is fixed to the screen.
+func (view *MapView) scaledGrid(scale float32) grid.Grid {
+	if settings := view.MapGrid; settings != nil && settings.PixelsPerSquare > 0 {
+		size := settings.PixelsPerSquare * scale
+
+		return grid.New(grid.ParseKind(settings.Type), fyne.NewPos(settings.OffsetX*scale, settings.OffsetY*scale), size, size, settings.Rotation)
+	}
+
+	zoom := float32(view.Zoom())
+
+	return grid.New(grid.Square, fyne.Position{}, view.CellWidth/zoom*scale, view.CellHeight/zoom*scale, 0)
+}
+
+func (view *MapView) buildZoomControls() {
+
+	// the slider is written directly by setZoom, so it isn't bound to any data that would write it as well
+	view.ZoomSlider = widget.NewSlider(0.1, view.MaxZoom)
+	view.ZoomSlider.Value = 1
+	view.ZoomSlider.Step = 0.1
+	view.ZoomSlider.Resize(fyne.NewSize(ZoomSliderWidth, ZoomSliderHeight))
+	view.ZoomSlider.OnChanged = view.applyZoom
+	view.ZoomControl = container.NewWithoutLayout(view.ZoomSlider)
+	view.ZoomControl.Resize(fyne.NewSize(ZoomSliderWidth, ZoomSliderHeight))
+	view.ZoomControl.Move(fyne.NewPos(float32(view.ScreenWidth-ZoomSliderXOffset), float32(view.ScreenHeight-ZoomSliderYOffset)))
+	view.ZoomControl.Hide()
+}
+
+// InchZoom returns the zoom at which one square of the map grid is one square of the screen grid, which is an inch once calibrated
+func (view *MapView) InchZoom() (float64, bool) {
+	if view.MapGrid == nil || view.MapGrid.PixelsPerSquare <= 0 {
+		return 0, false
+	}
+
+	return float64(view.CellWidth / view.MapGrid.PixelsPerSquare), true
+}
+
+func (view *MapView) SetZoomSliderRange() {
+
+	heightRatio := float32(view.ScreenHeight) / view.CurrentMapSize.Height
+	widthRatio := float32(view.ScreenWidth) / view.CurrentMapSize.Width
+
+	if view.MinZoom > 0 {
+		view.ZoomSlider.Min = view.MinZoom
+	} else if heightRatio > widthRatio {
+		view.ZoomSlider.Min = float64(math.Round(float64(heightRatio)*100) / 100)
+	} else {
+		view.ZoomSlider.Min = float64(math.Round(float64(widthRatio)*100) / 100)
+	}
+	view.ZoomSlider.Max = math.Max(view.MaxZoom, view.ZoomSlider.Min)
+
+	zoom := 1.0
+	if inchZoom, found := view.InchZoom(); found {
+		zoom = inchZoom
+		view.ZoomSlider.Min = math.Min(view.ZoomSlider.Min, zoom)
+		view.ZoomSlider.Max = math.Max(view.ZoomSlider.Max, zoom)
+	}
+	fmt.Println(view.ZoomSlider.Min)
+
+	view.setZoom(zoom)
+	view.ZoomControl.Refresh()
+}
+
+// Zoom returns the current zoom factor of the map
+func (view *MapView) Zoom() float64 {
+	return view.zoom
+}
+
+// applyZoom resizes the map and grid for a new zoom factor
+func (view *MapView) applyZoom(value float64) {
+	fmt.Println("Zoom Changed " + fmt.Sprintf("%f", value))
+	view.zoom = value
+
+	if view.CurrentMap != nil {
+		newWidth := float32(math.Abs(float64(view.CurrentMapSize.Width) * value))
+		newHeight := float32(math.Abs(float64(view.CurrentMapSize.Height) * value))
+
+		view.CurrentMap.Resize(fyne.NewSize(newWidth, newHeight))
+		view.CurrentMap.SetMinSize(fyne.NewSize(newWidth, newHeight))
+		view.MapControl.Refresh()
+		view.refreshLayers()
+	}
+
+	view.viewChanged()
+}
+
+// setZoom changes the zoom without snapping it to the slider steps, so exact scales like one inch per square are kept
+func (view *MapView) setZoom(value float64) {
+	value = math.Max(view.ZoomSlider.Min, math.Min(view.ZoomSlider.Max, value))
+
+	view.applyZoom(value)
+	view.ZoomSlider.Value = value
+	view.ZoomSlider.Refresh()
+}
+
+// ZoomAt changes the zoom while keeping the map point under the given screen position in place
+func (view *MapView) ZoomAt(value float64, anchor fyne.Position) {
+
+	previous := view.zoom
+	if previous == 0 {
+		return
+	}
+
+	viewAnchor := anchor.Subtract(view.MapControl.Position())
+	mapX := (view.MapControl.Offset.X + viewAnchor.X) / float32(previous)
+	mapY := (view.MapControl.Offset.Y + viewAnchor.Y) / float32(previous)
+
+	view.setZoom(value)
+	value = view.zoom
+
+	view.MapControl.Offset = fyne.NewPos(mapX*float32(value)-viewAnchor.X, mapY*float32(value)-viewAnchor.Y)
+	view.MapControl.Refresh()
+	view.refreshLayers()
+	view.viewChanged()
+}
+
+// Offset returns how far the map is scrolled, in zoomed map pixels
+func (view *MapView) Offset() fyne.Position {
+	return view.MapControl.Offset
+}
+
+// SetViewport zooms and scrolls the map to show exactly what another view shows, without any anchoring
+func (view *MapView) SetViewport(zoom float64, offset fyne.Position) {
+	view.setZoom(zoom)
+
+	view.MapControl.Offset = offset
+	view.MapControl.Refresh()
+	view.refreshLayers()
+	view.viewChanged()
+}
+
+func (view *MapView) viewChanged() {
+	if view.OnViewChanged != nil {
+		view.OnViewChanged()
+	}
+}
+
+// MapToScreen converts map image pixels to a screen position at the current zoom and scroll
+func (view *MapView) MapToScreen(position fyne.Position) fyne.Position {
+	zoom := float32(view.Zoom())
+
+	return fyne.NewPos(position.X*zoom-view.MapControl.Offset.X, position.Y*zoom-view.MapControl.Offset.Y).Add(view.MapControl.Position())
+}
+
+// ScreenToMap converts a screen position to map image pixels at the current zoom and scroll
+func (view *MapView) ScreenToMap(position fyne.Position) fyne.Position {
+	zoom := float32(view.Zoom())
+	viewPosition := position.Subtract(view.MapControl.Position())
+
+	return fyne.NewPos((view.MapControl.Offset.X+viewPosition.X)/zoom, (view.MapControl.Offset.Y+viewPosition.Y)/zoom)
+}
+
+// scroll moves the map by a delta that has already been scaled for sensitivity
+func (view *MapView) scroll(delta fyne.Delta) {
+	view.MapControl.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{AbsolutePosition: fyne.NewPos(0, 0), Position: fyne.NewPos(0, 0)}, Scrolled: delta})
+}
+
+// HandleGesture applies a recognized touch gesture to the view
+func (view *MapView) HandleGesture(gesture touch.Gesture) {
+
+	mapVisible := view.CurrentMap != nil && !view.CurrentMap.Hidden
+
+	switch gesture.Type {
+	case touch.GestureDown:
+		view.Panning.Stop()
+	case touch.GesturePan:
+		view.Panning.Move(gesture.Delta, gesture.Time)
+	case touch.GesturePanEnd:
+		view.Panning.Release(gesture.Time)
+	case touch.GesturePinch:
+		view.Panning.Stop()
+		if mapVisible {
+			view.ZoomAt(view.Zoom()*float64(gesture.Scale), gesture.Position)
+		}
+	case touch.GestureDoubleTap:
+		if mapVisible {
+			view.ZoomAt(1, gesture.Position)
+		}
+	}
+}
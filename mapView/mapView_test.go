@@ -0,0 +1,407 @@
+package mapView
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+
+	"github.com/JonCSykes/DragonTable/fog"
+	"github.com/JonCSykes/DragonTable/lighting"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/tiles"
+	"github.com/JonCSykes/DragonTable/touch"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+const testScreenWidth int = 1920
+const testScreenHeight int = 1080
+
+func newTestView(t *testing.T) *MapView {
+	test.NewApp()
+
+	mapImage := canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 30)))
+	mapImage.Resize(fyne.NewSize(4000, 3000))
+	mapImage.SetMinSize(fyne.NewSize(4000, 3000))
+
+	view := NewMapView(testScreenWidth, testScreenHeight, mapImage)
+	view.Panning.Animate = false
+
+	window := test.NewWindow(view.MapControl)
+	window.Resize(fyne.NewSize(float32(testScreenWidth), float32(testScreenHeight)))
+	view.MapControl.Resize(fyne.NewSize(float32(testScreenWidth), float32(testScreenHeight)))
+	t.Cleanup(window.Close)
+
+	view.ShowCurrentMap()
+
+	return view
+}
+
+func replay(t *testing.T, view *MapView, path string) {
+	recording, err := touch.LoadRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, gesture := range touch.NewGestureRecognizer().Replay(recording) {
+		view.HandleGesture(gesture)
+	}
+}
+
+func assertNear(t *testing.T, name string, expected float64, actual float64, tolerance float64) {
+	t.Helper()
+	if math.Abs(expected-actual) > tolerance {
+		t.Errorf("%s: expected %.2f, got %.2f", name, expected, actual)
+	}
+}
+
+func TestReplayPan(t *testing.T) {
+	view := newTestView(t)
+
+	replay(t, view, "testdata/pan.jsonl")
+
+	assertNear(t, "offset x", float64(200*DefaultPanSensitivity), float64(view.MapControl.Offset.X), 0.5)
+	assertNear(t, "offset y", float64(100*DefaultPanSensitivity), float64(view.MapControl.Offset.Y), 0.5)
+	assertNear(t, "zoom", 1, view.Zoom(), 0.001)
+}
+
+func TestReplayPanMomentum(t *testing.T) {
+	view := newTestView(t)
+
+	replay(t, view, "testdata/pan.jsonl")
+	if !view.Panning.Gliding() {
+		t.Fatal("expected the map to keep gliding after a flick")
+	}
+
+	released := view.MapControl.Offset
+	frames := 0
+	for view.Panning.Step(MomentumFrameInterval) {
+		frames++
+		if frames > 1000 {
+			t.Fatal("glide never came to rest")
+		}
+	}
+
+	// the glide continues in the direction of the flick, 2:1 like the finger movement
+	glideX := view.MapControl.Offset.X - released.X
+	glideY := view.MapControl.Offset.Y - released.Y
+	if glideX <= 0 || glideY <= 0 {
+		t.Fatalf("expected the glide to continue the pan, moved %.2f, %.2f", glideX, glideY)
+	}
+	assertNear(t, "glide direction", 2, float64(glideX/glideY), 0.05)
+}
+
+func TestPanMomentumStopsOnTouch(t *testing.T) {
+	view := newTestView(t)
+
+	replay(t, view, "testdata/pan.jsonl")
+	view.HandleGesture(touch.Gesture{Type: touch.GestureDown})
+
+	if view.Panning.Gliding() || view.Panning.Step(MomentumFrameInterval) {
+		t.Error("expected a new touch to stop the glide")
+	}
+}
+
+func TestReplayPinchZoom(t *testing.T) {
+	view := newTestView(t)
+
+	replay(t, view, "testdata/pinch.jsonl")
+
+	assertNear(t, "zoom", 1.5, view.Zoom(), 0.001)
+
+	// the map point under the pinch centre stays under the fingers
+	anchor := fyne.NewPos(960, 540).Subtract(view.MapControl.Position())
+	assertNear(t, "offset x", float64(anchor.X)*0.5, float64(view.MapControl.Offset.X), 2)
+	assertNear(t, "offset y", float64(anchor.Y)*0.5, float64(view.MapControl.Offset.Y), 2)
+}
+
+func TestReplayDoubleTapResetsZoom(t *testing.T) {
+	view := newTestView(t)
+	view.ZoomSlider.SetValue(1.8)
+
+	replay(t, view, "testdata/doubletap.jsonl")
+
+	assertNear(t, "zoom", 1, view.Zoom(), 0.001)
+}
+
+func TestShowMapFileZoomsToOneInchSquares(t *testing.T) {
+	view := newTestView(t)
+	view.SetCellSize(64, 64)
+
+	file := &mapFile.MapFile{Image: view.CurrentMap, Width: 4000, Height: 3000}
+	file.Metadata.Grid = &mapFile.GridSettings{PixelsPerSquare: 140, OffsetX: 12, OffsetY: 20}
+	view.ShowMapFile(file)
+
+	// the zoom is kept exact rather than snapped to the slider steps
+	assertNear(t, "zoom", 64.0/140.0, view.Zoom(), 0.0001)
+	assertNear(t, "map width", 4000*64.0/140.0, float64(view.CurrentMap.Size().Width), 0.5)
+}
+
+func TestTokensFollowGridAndZoom(t *testing.T) {
+	view := newTestView(t)
+	view.SetCellSize(64, 64)
+
+	file := &mapFile.MapFile{Image: view.CurrentMap, Width: 4000, Height: 3000}
+	file.Metadata.Grid = &mapFile.GridSettings{PixelsPerSquare: 128}
+	file.Metadata.Tokens = []mapFile.Token{
+		{Name: "goblin", Image: "goblin.png", Position: fyne.NewPos(192, 192)},
+		{Name: "ogre", Image: "ogre.png", Position: fyne.NewPos(1024, 1024), Size: "large"},
+	}
+	view.ShowMapFile(file)
+
+	// one square is 64 screen pixels, so the ogre is two squares across
+	assertNear(t, "zoom", 0.5, view.Zoom(), 0.0001)
+	if size := view.tokens[1].image.Size(); size.Width != 128 || size.Height != 128 {
+		t.Errorf("expected the large token two squares across, got %v", size)
+	}
+	if position := view.tokens[0].image.Position(); position != fyne.NewPos(64, 64) {
+		t.Errorf("expected the goblin centered on its square, got %v", position)
+	}
+
+	if found := view.TokenAt(view.MapToScreen(fyne.NewPos(1100, 1000))); found != 1 {
+		t.Errorf("expected to find the ogre, got %d", found)
+	}
+	if found := view.TokenAt(view.MapToScreen(fyne.NewPos(600, 600))); found != -1 {
+		t.Errorf("expected no token between them, got %d", found)
+	}
+
+	ogre := view.tokens[1].image
+	moved := append([]mapFile.Token(nil), view.Tokens...)
+	moved[1].Position = fyne.NewPos(2048, 1024)
+	view.SetTokens(moved)
+	if view.tokens[1].image != ogre || view.tokens[1].image.Position() != fyne.NewPos(960, 448) {
+		t.Error("expected a moved token to keep its image and follow its position")
+	}
+}
+
+func TestTokenStatusIsDrawnAroundTokens(t *testing.T) {
+	view := newTestView(t)
+	view.SetCellSize(64, 64)
+
+	file := &mapFile.MapFile{Image: view.CurrentMap, Width: 4000, Height: 3000}
+	file.Metadata.Grid = &mapFile.GridSettings{PixelsPerSquare: 64}
+	file.Metadata.Tokens = []mapFile.Token{
+		{Name: "ogre", Position: fyne.NewPos(320, 320), Team: "enemy", HP: 15, MaxHP: 60, Conditions: []string{"prone", "stunned"}},
+		{Name: "fighter", Position: fyne.NewPos(640, 320), Team: "party", HP: 30, MaxHP: 40, ShowHP: true},
+	}
+	view.ShowMapFile(file)
+
+	ogre := view.tokens[0]
+	if ogre.ring == nil || ogre.ring.StrokeColor != color.Color(color.NRGBA{R: 220, G: 50, B: 50, A: 255}) {
+		t.Error("expected a red ring around the enemy")
+	}
+	if ogre.health != nil {
+		t.Error("expected the players not to see the ogre's hit points")
+	}
+	if len(ogre.conditions) != 2 || ogre.conditions[1].text.Text != "St" {
+		t.Errorf("expected markers for both conditions, got %d", len(ogre.conditions))
+	}
+	if fighter := view.tokens[1]; fighter.health == nil || fighter.healthBar.Size().Width != fighter.health.Size().Width*0.75 {
+		t.Error("expected the fighter's hit point bar three quarters full")
+	}
+
+	view.ShowAllHealth = true
+	view.SetTokens(view.Tokens)
+	if health := view.tokens[0]; health.health == nil || health.healthBar.FillColor != color.Color(healthBadColor) {
+		t.Error("expected the GM to see the ogre badly hurt")
+	}
+}
+
+func TestGridOverlayDrawsVisibleLines(t *testing.T) {
+	view := newTestView(t)
+	view.SetCellSize(100, 100)
+	view.SetGridVisible(true)
+	view.MapControl.Offset = fyne.NewPos(30, 0)
+
+	img := view.drawGrid(testScreenWidth, testScreenHeight).(*image.NRGBA)
+
+	// the line at content x 100 is 70 pixels into the screen once scrolled by 30
+	if img.NRGBAAt(70, 50).A == 0 {
+		t.Error("expected a grid line at x 70")
+	}
+	if img.NRGBAAt(120, 50).A != 0 {
+		t.Error("expected no grid line inside a cell")
+	}
+
+	view.SetGridStyle(GridStyle{Color: DefaultGridStyle.Color, Opacity: 0.5, LineWidth: 1, Dash: 10, Gap: 10})
+	img = view.drawGrid(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if alpha := img.NRGBAAt(70, 5).A; alpha != 127 {
+		t.Errorf("expected a half transparent dash at the top of the line, got alpha %d", alpha)
+	}
+	if img.NRGBAAt(70, 15).A != 0 {
+		t.Error("expected a gap in the dashed line")
+	}
+}
+
+func TestFogOverlayHidesUnrevealedMap(t *testing.T) {
+	view := newTestView(t)
+
+	mask := fog.NewMask(4000, 3000)
+	mask.Rect(fyne.NewPos(0, 0), fyne.NewPos(100, 100), true)
+	view.SetFog(mask)
+
+	img := view.drawFog(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if img.NRGBAAt(50, 50).A != 0 {
+		t.Error("expected the revealed corner to be clear")
+	}
+	if img.NRGBAAt(500, 500).A != 255 {
+		t.Error("expected the rest of the map to be fogged")
+	}
+
+	view.FogOpacity = 0.5
+	img = view.drawFog(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if alpha := img.NRGBAAt(500, 500).A; alpha != 127 {
+		t.Errorf("expected half transparent fog, got alpha %d", alpha)
+	}
+}
+
+func TestOverlayReusesItsImage(t *testing.T) {
+	view := newTestView(t)
+	view.SetFog(fog.NewMask(4000, 3000))
+
+	first := view.drawFog(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	view.SetFog(nil)
+	second := view.drawFog(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if first != second {
+		t.Error("expected the fog to be drawn into the same image again")
+	}
+	if second.NRGBAAt(500, 500).A != 0 {
+		t.Error("expected the image to be cleared before it is drawn again")
+	}
+
+	if resized := view.drawFog(testScreenWidth/2, testScreenHeight/2).(*image.NRGBA); resized.Rect.Dx() != testScreenWidth/2 {
+		t.Errorf("expected the image to follow the raster's size, got width %d", resized.Rect.Dx())
+	}
+}
+
+func TestVisionOverlayDimsExploredMap(t *testing.T) {
+	view := newTestView(t)
+
+	// a wall splits the map down the middle
+	walls := vision.NewWalls([]vision.Segment{{A: fyne.NewPos(1000, 0), B: fyne.NewPos(1000, 3000)}}, 4000, 3000)
+	sight := vision.New(walls, 4000, 3000)
+	sight.SetViewers([]fyne.Position{fyne.NewPos(1500, 500)})
+	sight.SetViewers([]fyne.Position{fyne.NewPos(500, 500)})
+	view.SetVision(sight)
+
+	img := view.drawVision(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if img.NRGBAAt(500, 500).A != 0 {
+		t.Error("expected what the viewer sees to be clear")
+	}
+	if alpha := img.NRGBAAt(1500, 500).A; alpha != uint8(255*DefaultExploredOpacity) {
+		t.Errorf("expected the explored side of the wall to be dimmed, got alpha %d", alpha)
+	}
+
+	view.SetVision(nil)
+	if img = view.drawVision(testScreenWidth, testScreenHeight).(*image.NRGBA); img.NRGBAAt(1500, 500).A != 0 {
+		t.Error("expected nothing hidden with line of sight off")
+	}
+}
+
+func TestLightingOverlayDarkensUnlitMap(t *testing.T) {
+	view := newTestView(t)
+
+	layer := lighting.New(nil, 4000, 3000)
+	layer.SetAmbient(0)
+	layer.SetLights([]mapFile.LightSource{{Position: fyne.NewPos(500, 500), Bright: 200, Dim: 400, Color: color.NRGBA{R: 255, G: 255, B: 255, A: 255}, On: true}})
+	view.SetLighting(layer)
+
+	img := view.drawLighting(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if img.NRGBAAt(500, 500).A != 0 {
+		t.Error("expected the brightly lit map to be left as it is")
+	}
+	if alpha := img.NRGBAAt(1500, 500).A; alpha != 255 {
+		t.Errorf("expected the unlit map to be dark, got alpha %d", alpha)
+	}
+
+	view.LightingOpacity = 0.5
+	if alpha := view.drawLighting(testScreenWidth, testScreenHeight).(*image.NRGBA).NRGBAAt(1500, 500).A; alpha < 126 || alpha > 128 {
+		t.Errorf("expected the GM's view to see through the darkness, got alpha %d", alpha)
+	}
+}
+
+func TestTemplateOverlayHighlightsAffectedCells(t *testing.T) {
+	view := newTestView(t)
+	view.MapGrid = &mapFile.GridSettings{PixelsPerSquare: 100}
+
+	// a 10 ft sphere on squares of 5 ft is 200 pixels across
+	view.SetTemplates([]mapFile.Template{{Shape: "sphere", Origin: fyne.NewPos(800, 500), Size: 10}})
+	cellOpacity := TemplateCellOpacity
+	cellAlpha := uint8(255 * cellOpacity)
+
+	img := view.drawTemplates(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if alpha := img.NRGBAAt(850, 550).A; alpha <= cellAlpha {
+		t.Errorf("expected the sphere to be drawn over the cell it covers, got alpha %d", alpha)
+	}
+	if alpha := img.NRGBAAt(995, 595).A; alpha < cellAlpha-1 || alpha > cellAlpha+1 {
+		t.Errorf("expected the corner of a cell whose middle is in the sphere to be highlighted, got alpha %d", alpha)
+	}
+	if img.NRGBAAt(995, 695).A != 0 {
+		t.Error("expected a cell whose middle is outside the sphere to be left clear")
+	}
+
+	view.CurrentMapFile = &mapFile.MapFile{Metadata: mapFile.Metadata{Scale: &mapFile.ScaleSettings{Distance: 5, Unit: "ft", Coverage: "overlap"}}}
+	img = view.drawTemplates(testScreenWidth, testScreenHeight).(*image.NRGBA)
+	if img.NRGBAAt(995, 695).A == 0 {
+		t.Error("expected every cell the sphere overlaps to be highlighted")
+	}
+
+	view.SetTemplates(nil)
+	if img = view.drawTemplates(testScreenWidth, testScreenHeight).(*image.NRGBA); img.NRGBAAt(850, 550).A != 0 {
+		t.Error("expected nothing drawn once the template was dismissed")
+	}
+}
+
+func TestLargeMapShowsOnlyVisibleTiles(t *testing.T) {
+	tiledMapSize, tileSize, previewSize := tiles.TiledMapSize, tiles.TileSize, tiles.PreviewSize
+	t.Cleanup(func() { tiles.TiledMapSize, tiles.TileSize, tiles.PreviewSize = tiledMapSize, tileSize, previewSize })
+	tiles.TiledMapSize, tiles.TileSize, tiles.PreviewSize = 1000, 128, 512
+
+	cacheDir := mapFile.TileCacheDir
+	t.Cleanup(func() { mapFile.TileCacheDir = cacheDir })
+	mapFile.TileCacheDir = t.TempDir()
+
+	path := filepath.Join(t.TempDir(), "large.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = png.Encode(file, image.NewRGBA(image.Rect(0, 0, 2400, 1600))); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	large := &mapFile.MapFile{FileName: "large", Extension: "png", FullPath: path, Width: 2400, Height: 1600}
+	if err = large.Load(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	view := newTestView(t)
+	view.ShowMapFile(large)
+
+	if view.tiles == nil {
+		t.Fatal("expected a large map to be shown from tiles")
+	}
+	if !view.IsShowing("large.png") {
+		t.Error("expected the tiled map to be reported as showing")
+	}
+
+	// 19x13 tiles cover the map, a 1920x1080 screen at full size needs 15x9 of them
+	if count := len(view.tiles.images); count != 15*9 {
+		t.Errorf("expected 135 tiles on screen, got %d", count)
+	}
+
+	view.scroll(fyne.NewDelta(-300, 0))
+	for key := range view.tiles.images {
+		if key.Level != 0 || key.X < 2 {
+			t.Errorf("expected only full size tiles from column 2 after scrolling, got %v", key)
+		}
+	}
+}
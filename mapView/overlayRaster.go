@@ -0,0 +1,69 @@
+package mapView
+
+import (
+	"image"
+
+	"fyne.io/fyne/v2"
+)
+
+// overlayRaster is what an overlay over the map keeps between refreshes, so panning doesn't allocate a screen sized
+// image every frame: the image it is drawn into, and which cell of a mask covering the map every column and row of the
+// image falls in
+type overlayRaster struct {
+	image   *image.NRGBA
+	columns []int
+	rows    []int
+}
+
+// clear returns the overlay's image emptied, at the size the raster is drawn at
+func (raster *overlayRaster) clear(width int, height int) *image.NRGBA {
+	if raster.image == nil || raster.image.Rect.Dx() != width || raster.image.Rect.Dy() != height {
+		raster.image = image.NewNRGBA(image.Rect(0, 0, width, height))
+		return raster.image
+	}
+
+	for i := range raster.image.Pix {
+		raster.image.Pix[i] = 0
+	}
+
+	return raster.image
+}
+
+// locate works out which cell of a mask with the given scale every column and row of the image covers on the part of the
+// map that is on screen, -1 for those off the map. overlay is the raster the image is shown in.
+func (raster *overlayRaster) locate(view *MapView, overlay fyne.CanvasObject, maskScale float32) {
+	width, height := raster.image.Rect.Dx(), raster.image.Rect.Dy()
+
+	scale := float32(1)
+	if size := overlay.Size(); size.Width > 0 {
+		scale = float32(width) / size.Width
+	}
+
+	zoom := float32(view.Zoom())
+	offset := view.MapControl.Offset
+	toMask := func(pixel int, offset float32, mapSize float32) int {
+		mapPosition := (float32(pixel)/scale + offset) / zoom
+		if mapPosition < 0 || mapPosition >= mapSize {
+			return -1
+		}
+		return int(mapPosition / maskScale)
+	}
+
+	raster.columns = resizeCells(raster.columns, width)
+	for x := range raster.columns {
+		raster.columns[x] = toMask(x, offset.X, view.CurrentMapSize.Width)
+	}
+
+	raster.rows = resizeCells(raster.rows, height)
+	for y := range raster.rows {
+		raster.rows[y] = toMask(y, offset.Y, view.CurrentMapSize.Height)
+	}
+}
+
+func resizeCells(cells []int, size int) []int {
+	if cap(cells) < size {
+		return make([]int, size)
+	}
+
+	return cells[:size]
+}
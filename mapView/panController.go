@@ -0,0 +1,166 @@
+package mapView
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const DefaultPanSensitivity float32 = 0.5
+const DefaultPanFriction float64 = 4
+const DefaultVelocitySmoothing float32 = 0.6
+const MinMomentumSpeed float32 = 20
+const MaxReleaseDelay time.Duration = 100 * time.Millisecond
+const MomentumFrameInterval time.Duration = 16 * time.Millisecond
+
+// PanController scrolls the map from finger movement and keeps it gliding after the finger lifts.
+// Sensitivity scales finger movement into scroll distance, Friction is how fast the glide decays per second
+// and VelocitySmoothing is how much of the previous velocity is kept with each new sample.
+type PanController struct {
+	Sensitivity       float32
+	Friction          float64
+	VelocitySmoothing float32
+	Momentum          bool
+	// Animate runs the glide on a ticker, with it off the glide only advances through Step
+	Animate bool
+
+	scroll   func(delta fyne.Delta)
+	velocity fyne.Delta
+	lastMove time.Time
+	gliding  bool
+	stop     chan struct{}
+	mutex    sync.Mutex
+}
+
+// NewPanController creates a controller that applies scroll deltas through scroll
+func NewPanController(scroll func(delta fyne.Delta)) *PanController {
+	return &PanController{
+		Sensitivity:       DefaultPanSensitivity,
+		Friction:          DefaultPanFriction,
+		VelocitySmoothing: DefaultVelocitySmoothing,
+		Momentum:          true,
+		Animate:           true,
+		scroll:            scroll,
+	}
+}
+
+// Move applies a finger movement in screen pixels made at the given time
+func (controller *PanController) Move(delta fyne.Delta, at time.Time) {
+	controller.Stop()
+
+	controller.mutex.Lock()
+	if !controller.lastMove.IsZero() {
+		elapsed := float32(math.Max(at.Sub(controller.lastMove).Seconds(), 0.001))
+		smoothing := controller.VelocitySmoothing
+		if at.Sub(controller.lastMove) > MaxReleaseDelay {
+			smoothing = 0
+		}
+		controller.velocity = fyne.NewDelta(
+			smoothing*controller.velocity.DX+(1-smoothing)*delta.DX/elapsed,
+			smoothing*controller.velocity.DY+(1-smoothing)*delta.DY/elapsed)
+	}
+	controller.lastMove = at
+	sensitivity := controller.Sensitivity
+	controller.mutex.Unlock()
+
+	controller.scroll(fyne.NewDelta(delta.DX*sensitivity, delta.DY*sensitivity))
+}
+
+// Release starts the glide when the finger lifts at the given time while still moving
+func (controller *PanController) Release(at time.Time) {
+	controller.mutex.Lock()
+
+	recent := !controller.lastMove.IsZero() && at.Sub(controller.lastMove) <= MaxReleaseDelay
+	controller.lastMove = time.Time{}
+
+	if !controller.Momentum || !recent || speed(controller.velocity) < MinMomentumSpeed {
+		controller.velocity = fyne.Delta{}
+		controller.mutex.Unlock()
+		return
+	}
+
+	controller.gliding = true
+	animate := controller.Animate
+	if animate {
+		controller.stop = make(chan struct{})
+	}
+	stop := controller.stop
+	controller.mutex.Unlock()
+
+	if animate {
+		go controller.glide(stop)
+	}
+}
+
+// Stop ends any glide in progress
+func (controller *PanController) Stop() {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+
+	if controller.stop != nil {
+		close(controller.stop)
+		controller.stop = nil
+	}
+	if controller.gliding {
+		controller.gliding = false
+		controller.velocity = fyne.Delta{}
+	}
+}
+
+// Gliding returns true while the map is still moving after a release
+func (controller *PanController) Gliding() bool {
+	controller.mutex.Lock()
+	defer controller.mutex.Unlock()
+
+	return controller.gliding
+}
+
+// Step advances the glide by elapsed time and returns false once it has come to rest
+func (controller *PanController) Step(elapsed time.Duration) bool {
+	controller.mutex.Lock()
+
+	if !controller.gliding {
+		controller.mutex.Unlock()
+		return false
+	}
+
+	decay := float32(math.Exp(-controller.Friction * elapsed.Seconds()))
+	seconds := float32(elapsed.Seconds())
+	delta := fyne.NewDelta(controller.velocity.DX*seconds*controller.Sensitivity, controller.velocity.DY*seconds*controller.Sensitivity)
+	controller.velocity = fyne.NewDelta(controller.velocity.DX*decay, controller.velocity.DY*decay)
+
+	if speed(controller.velocity) < MinMomentumSpeed {
+		controller.gliding = false
+		controller.velocity = fyne.Delta{}
+	}
+	gliding := controller.gliding
+	controller.mutex.Unlock()
+
+	controller.scroll(delta)
+
+	return gliding
+}
+
+func (controller *PanController) glide(stop chan struct{}) {
+	ticker := time.NewTicker(MomentumFrameInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if !controller.Step(now.Sub(last)) {
+				return
+			}
+			last = now
+		}
+	}
+}
+
+func speed(velocity fyne.Delta) float32 {
+	return float32(math.Hypot(float64(velocity.DX), float64(velocity.DY)))
+}
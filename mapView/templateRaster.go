@@ -0,0 +1,129 @@
+package mapView
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/aoe"
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/measure"
+)
+
+// TemplateOpacity is how strongly areas of effect are drawn over the map, the cells they affect are tinted by
+// TemplateCellOpacity on top
+const TemplateOpacity float32 = 0.3
+const TemplateCellOpacity float32 = 0.25
+
+var DefaultTemplateColor = color.NRGBA{R: 255, G: 110, B: 40, A: 255}
+
+var templateOutlineStyle = GridStyle{Opacity: 1, LineWidth: 2}
+
+// SetTemplates shows areas of effect over the map
+func (view *MapView) SetTemplates(templates []mapFile.Template) {
+	view.Templates = append([]mapFile.Template(nil), templates...)
+
+	view.RedrawTemplates()
+}
+
+// RedrawTemplates draws the areas of effect again after they changed
+func (view *MapView) RedrawTemplates() {
+	if view.TemplateOverlay != nil {
+		view.TemplateOverlay.Refresh()
+	}
+}
+
+// TemplateScale returns how far a grid cell of the current map is, which template sizes are converted through
+func (view *MapView) TemplateScale() measure.Scale {
+	if view.CurrentMapFile == nil {
+		return measure.DefaultScale
+	}
+
+	return measure.ParseScale(view.CurrentMapFile.Metadata.Scale)
+}
+
+// TemplateCoverage returns the rule for which cells of the current map the templates affect
+func (view *MapView) TemplateCoverage() aoe.Coverage {
+	if view.CurrentMapFile == nil || view.CurrentMapFile.Metadata.Scale == nil {
+		return aoe.Center
+	}
+
+	return aoe.ParseCoverage(view.CurrentMapFile.Metadata.Scale.Coverage)
+}
+
+// drawTemplates renders the areas of effect on screen with the cells they affect, it is the generator of TemplateOverlay
+func (view *MapView) drawTemplates(width int, height int) image.Image {
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if len(view.Templates) == 0 || view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return img
+	}
+
+	scale := float32(1)
+	if size := view.TemplateOverlay.Size(); size.Width > 0 {
+		scale = float32(width) / size.Width
+	}
+
+	zoom := float32(view.Zoom())
+	offset := view.MapControl.Offset
+	toContent := func(polygon geometry.Polygon) geometry.Polygon {
+		content := make(geometry.Polygon, len(polygon))
+		for i, corner := range polygon {
+			content[i] = fyne.NewPos(corner.X*zoom, corner.Y*zoom)
+		}
+		return content
+	}
+
+	mapGrid, unitScale, coverage := view.Grid(), view.TemplateScale(), view.TemplateCoverage()
+	for _, template := range view.Templates {
+		footprint := aoe.Footprint(template, mapGrid, unitScale)
+
+		for _, cell := range aoe.Cells(mapGrid, footprint, coverage) {
+			fillPolygon(img, toContent(mapGrid.Outline(cell)), offset, scale, view.TemplateColor, TemplateCellOpacity)
+		}
+		content := toContent(footprint)
+		fillPolygon(img, content, offset, scale, view.TemplateColor, TemplateOpacity)
+		for i, j := 0, len(content)-1; i < len(content); j, i = i, i+1 {
+			drawGridLine(img, content[j], content[i], offset, scale, view.TemplateColor, templateOutlineStyle)
+		}
+	}
+
+	return img
+}
+
+// fillPolygon blends a color over the pixels inside a polygon of map content positions
+func fillPolygon(img *image.NRGBA, polygon geometry.Polygon, offset fyne.Position, scale float32, fill color.NRGBA, opacity float32) {
+	min, max := polygon.Bounds()
+	top := int(math.Max(math.Floor(float64((min.Y-offset.Y)*scale)), 0))
+	bottom := int(math.Min(math.Ceil(float64((max.Y-offset.Y)*scale)), float64(img.Rect.Max.Y)))
+
+	for y := top; y < bottom; y++ {
+		crossings := polygon.Crossings((float32(y)+0.5)/scale + offset.Y)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			left := int(math.Max(math.Round(float64((crossings[i]-offset.X)*scale)), 0))
+			right := int(math.Min(math.Round(float64((crossings[i+1]-offset.X)*scale)), float64(img.Rect.Max.X)))
+			for x := left; x < right; x++ {
+				img.SetNRGBA(x, y, blend(img.NRGBAAt(x, y), fill, opacity))
+			}
+		}
+	}
+}
+
+// blend lays a color at an opacity over a pixel
+func blend(under color.NRGBA, over color.NRGBA, opacity float32) color.NRGBA {
+	overAlpha := float32(over.A) / 255 * opacity
+	underAlpha := float32(under.A) / 255 * (1 - overAlpha)
+	alpha := overAlpha + underAlpha
+	if alpha <= 0 {
+		return color.NRGBA{}
+	}
+
+	mix := func(a uint8, b uint8) uint8 {
+		return uint8((float32(a)*underAlpha + float32(b)*overAlpha) / alpha)
+	}
+
+	return color.NRGBA{R: mix(under.R, over.R), G: mix(under.G, over.G), B: mix(under.B, over.B), A: uint8(alpha * 255)}
+}
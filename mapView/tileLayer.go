@@ -0,0 +1,112 @@
+package mapView
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/tiles"
+)
+
+// tileLayer draws the tiles of a large map that are on screen over its preview, at the detail the zoom needs
+type tileLayer struct {
+	pyramid   *tiles.Pyramid
+	images    map[tiles.TileKey]*canvas.Image
+	container *fyne.Container
+
+	// failed are the tiles that couldn't be read, they are loaded again the next time they are on screen
+	failed map[tiles.TileKey]bool
+	mutex  sync.Mutex
+}
+
+// openTiles returns the preview of a large map and the layer its tiles are drawn on
+func (view *MapView) openTiles(file *mapFile.MapFile) (*canvas.Image, *tileLayer, error) {
+	// the map's tiles were opened when it was loaded
+	pyramid := file.Pyramid
+
+	preview, err := pyramid.Preview()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layer := &tileLayer{pyramid: pyramid, images: make(map[tiles.TileKey]*canvas.Image), container: container.NewWithoutLayout(), failed: make(map[tiles.TileKey]bool)}
+
+	return canvas.NewImageFromImage(preview), layer, nil
+}
+
+// updateTiles shows the tiles covering the screen at the current zoom and drops the rest
+func (view *MapView) updateTiles() {
+	layer := view.tiles
+	if layer == nil || view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return
+	}
+
+	zoom := view.Zoom()
+	offset, size := view.MapControl.Offset, view.MapControl.Size()
+	area := image.Rect(
+		int(math.Floor(float64(offset.X)/zoom)), int(math.Floor(float64(offset.Y)/zoom)),
+		int(math.Ceil(float64(offset.X+size.Width)/zoom)), int(math.Ceil(float64(offset.Y+size.Height)/zoom)),
+	)
+
+	visible := make(map[tiles.TileKey]*canvas.Image)
+	var objects []fyne.CanvasObject
+	for _, key := range layer.pyramid.TilesIn(layer.pyramid.LevelFor(zoom), area) {
+		tileImage, found := layer.images[key]
+		if !found || layer.retry(key) {
+			tileImage = layer.loadTile(key)
+		}
+
+		bounds := layer.pyramid.TileBounds(key)
+		tileImage.Move(fyne.NewPos(float32(float64(bounds.Min.X)*zoom), float32(float64(bounds.Min.Y)*zoom)))
+		tileImage.Resize(fyne.NewSize(float32(float64(bounds.Dx())*zoom), float32(float64(bounds.Dy())*zoom)))
+
+		visible[key] = tileImage
+		objects = append(objects, tileImage)
+	}
+
+	layer.images = visible
+	layer.container.Objects = objects
+	layer.container.Resize(view.CurrentMap.Size())
+	layer.container.Refresh()
+}
+
+// loadTile returns an image for a tile that appears once the tile has been read from the cache
+func (layer *tileLayer) loadTile(key tiles.TileKey) *canvas.Image {
+	tileImage := canvas.NewImageFromImage(nil)
+	tileImage.FillMode = canvas.ImageFillStretch
+	tileImage.Hide()
+
+	go func() {
+		tile, err := layer.pyramid.Tile(key)
+		if err != nil {
+			fmt.Println(err)
+			layer.mutex.Lock()
+			layer.failed[key] = true
+			layer.mutex.Unlock()
+			return
+		}
+
+		tileImage.Image = tile
+		tileImage.Show()
+		tileImage.Refresh()
+	}()
+
+	return tileImage
+}
+
+// retry returns true if a tile failed to load, clearing the failure so it is loaded again
+func (layer *tileLayer) retry(key tiles.TileKey) bool {
+	layer.mutex.Lock()
+	defer layer.mutex.Unlock()
+
+	failed := layer.failed[key]
+	delete(layer.failed, key)
+
+	return failed
+}
@@ -0,0 +1,171 @@
+package mapView
+
+import (
+	"image/color"
+	"math"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/token"
+)
+
+// conditionMarkerStep is the angle between condition markers going clockwise around a token, in degrees
+const conditionMarkerStep float64 = 40
+
+// firstConditionAngle is where the first condition marker sits on a token's edge, in degrees clockwise from the right
+const firstConditionAngle float64 = -60
+
+var healthBackgroundColor = color.NRGBA{R: 30, G: 30, B: 30, A: 200}
+var healthGoodColor = color.NRGBA{R: 60, G: 200, B: 70, A: 255}
+var healthHurtColor = color.NRGBA{R: 240, G: 200, B: 40, A: 255}
+var healthBadColor = color.NRGBA{R: 220, G: 40, B: 40, A: 255}
+var conditionTextColor = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+// tokenObjects are what is drawn for one token: its picture, team ring, hit point bar and condition markers
+type tokenObjects struct {
+	image      *canvas.Image
+	ring       *canvas.Circle
+	health     *canvas.Rectangle
+	healthBar  *canvas.Rectangle
+	conditions []conditionMarker
+}
+
+type conditionMarker struct {
+	circle *canvas.Circle
+	text   *canvas.Text
+}
+
+// SetTokens shows the tokens on the map, keeping the images of tokens whose picture didn't change
+func (view *MapView) SetTokens(tokens []mapFile.Token) {
+	previous := view.Tokens
+	view.Tokens = append([]mapFile.Token(nil), tokens...)
+
+	drawn := make([]tokenObjects, len(view.Tokens))
+	var objects []fyne.CanvasObject
+	for i, placed := range view.Tokens {
+		if i < len(previous) && i < len(view.tokens) && previous[i].Image == placed.Image {
+			drawn[i].image = view.tokens[i].image
+		} else {
+			drawn[i].image = canvas.NewImageFromFile(view.tokenPath(placed))
+			drawn[i].image.FillMode = canvas.ImageFillContain
+		}
+		objects = append(objects, drawn[i].image)
+
+		if team, found := token.FindTeam(placed.Team); found {
+			drawn[i].ring = canvas.NewCircle(color.Transparent)
+			drawn[i].ring.StrokeColor = team.Color
+			objects = append(objects, drawn[i].ring)
+		}
+
+		if fraction, tracked := token.HealthFraction(placed); tracked && (placed.ShowHP || view.ShowAllHealth) {
+			drawn[i].health = canvas.NewRectangle(healthBackgroundColor)
+			drawn[i].healthBar = canvas.NewRectangle(healthColor(fraction))
+			objects = append(objects, drawn[i].health, drawn[i].healthBar)
+		}
+
+		for _, name := range placed.Conditions {
+			condition, found := token.FindCondition(name)
+			if !found {
+				continue
+			}
+
+			marker := conditionMarker{circle: canvas.NewCircle(condition.Color), text: canvas.NewText(condition.Short, conditionTextColor)}
+			marker.circle.StrokeColor = conditionTextColor
+			marker.text.Alignment = fyne.TextAlignCenter
+			marker.text.TextStyle = fyne.TextStyle{Bold: true}
+			drawn[i].conditions = append(drawn[i].conditions, marker)
+			objects = append(objects, marker.circle, marker.text)
+		}
+	}
+
+	view.tokens = drawn
+	view.tokenContainer.Objects = objects
+	view.layoutTokens()
+}
+
+// TokenAt returns the index of the topmost token under a screen position, -1 when there is none
+func (view *MapView) TokenAt(position fyne.Position) int {
+	if view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return -1
+	}
+
+	return token.At(view.Tokens, view.ScreenToMap(position), view.Grid().CellSize())
+}
+
+func (view *MapView) tokenPath(placed mapFile.Token) string {
+	if view.TokenDir == "" || filepath.IsAbs(placed.Image) {
+		return placed.Image
+	}
+
+	return filepath.Join(view.TokenDir, placed.Image)
+}
+
+// layoutTokens sizes the tokens for the grid and zoom, they scroll with the map because they are part of its content
+func (view *MapView) layoutTokens() {
+	if view.CurrentMap == nil || view.CurrentMap.Hidden {
+		view.tokenContainer.Hide()
+		return
+	}
+	view.tokenContainer.Show()
+
+	zoom := float32(view.Zoom())
+	cellSize := view.Grid().CellSize()
+	for i, placed := range view.Tokens {
+		diameter := token.Diameter(placed, cellSize) * zoom
+		center := fyne.NewPos(placed.Position.X*zoom, placed.Position.Y*zoom)
+		topLeft := center.Subtract(fyne.NewPos(diameter/2, diameter/2))
+		drawn := view.tokens[i]
+
+		drawn.image.Resize(fyne.NewSize(diameter, diameter))
+		drawn.image.Move(topLeft)
+
+		if drawn.ring != nil {
+			drawn.ring.StrokeWidth = fyne.Max(2, diameter*0.06)
+			drawn.ring.Resize(fyne.NewSize(diameter, diameter))
+			drawn.ring.Move(topLeft)
+		}
+
+		if drawn.health != nil {
+			fraction, _ := token.HealthFraction(placed)
+			width, height := diameter*0.8, fyne.Max(4, diameter*0.1)
+			barPosition := fyne.NewPos(center.X-width/2, center.Y+diameter/2-height/2)
+
+			drawn.health.Resize(fyne.NewSize(width, height))
+			drawn.health.Move(barPosition)
+			drawn.healthBar.Resize(fyne.NewSize(width*fraction, height))
+			drawn.healthBar.Move(barPosition)
+		}
+
+		markerSize := fyne.Max(14, diameter*0.3)
+		for c, marker := range drawn.conditions {
+			angle := (firstConditionAngle + float64(c)*conditionMarkerStep) * math.Pi / 180
+			markerCenter := center.Add(fyne.NewPos(float32(math.Cos(angle))*diameter/2, float32(math.Sin(angle))*diameter/2))
+			markerPosition := markerCenter.Subtract(fyne.NewPos(markerSize/2, markerSize/2))
+
+			marker.circle.StrokeWidth = fyne.Max(1, markerSize*0.08)
+			marker.circle.Resize(fyne.NewSize(markerSize, markerSize))
+			marker.circle.Move(markerPosition)
+			marker.text.TextSize = markerSize * 0.45
+			marker.text.Resize(fyne.NewSize(markerSize, markerSize))
+			marker.text.Move(markerPosition)
+		}
+	}
+
+	view.tokenContainer.Resize(view.CurrentMap.Size())
+	view.tokenContainer.Refresh()
+}
+
+// healthColor shows how hurt a token is, green when above half its hit points, yellow above a quarter and red below
+func healthColor(fraction float32) color.NRGBA {
+	switch {
+	case fraction > 0.5:
+		return healthGoodColor
+	case fraction > 0.25:
+		return healthHurtColor
+	}
+
+	return healthBadColor
+}
@@ -0,0 +1,106 @@
+package mapView
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/fog"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/token"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+var DefaultVisionColor = color.NRGBA{R: 0, G: 0, B: 0, A: 255}
+
+// DefaultExploredOpacity is how dark the parts of the map that were seen before but can't be seen now are
+const DefaultExploredOpacity float32 = 0.6
+
+// SetVision hides what the viewers can't see, nil turns line of sight off
+func (view *MapView) SetVision(sight *vision.Vision) {
+	view.Vision = sight
+
+	view.RedrawVision()
+}
+
+// RedrawVision draws the line of sight again after the viewers or walls changed
+func (view *MapView) RedrawVision() {
+	if view.VisionOverlay != nil {
+		view.VisionOverlay.Refresh()
+	}
+}
+
+// LoadVision sets up line of sight for a map from its metadata, nil when it is off for the map
+func LoadVision(file *mapFile.MapFile) *vision.Vision {
+	settings := file.Metadata.Vision
+	if settings == nil {
+		return nil
+	}
+
+	walls := vision.SceneWalls(file.Scene, file.Metadata.Doors, float32(file.Width), float32(file.Height))
+	sight := vision.New(walls, file.Width, file.Height)
+	sight.Range = settings.Range
+
+	if len(file.Metadata.Explored) > 0 {
+		explored, exploredError := fog.Decode(file.Metadata.Explored, file.Width, file.Height)
+		if exploredError != nil {
+			fmt.Println(exploredError)
+		} else {
+			sight.Explored = explored
+		}
+	}
+
+	sight.SetViewers(settings.Viewers)
+	sight.SetTokenViewers(token.Viewers(file.Metadata.Tokens))
+
+	return sight
+}
+
+// drawVision darkens what the viewers can't see on the part of the map that is on screen, it is the generator of VisionOverlay
+func (view *MapView) drawVision(width int, height int) image.Image {
+
+	img := view.visionRaster.clear(width, height)
+	sight := view.Vision
+	if sight == nil || view.CurrentMap == nil || view.CurrentMap.Hidden {
+		return img
+	}
+
+	// both masks cover the same map so they share their pixel positions
+	view.visionRaster.locate(view, view.VisionOverlay, sight.Visible.Scale)
+	columns, rows := view.visionRaster.columns, view.visionRaster.rows
+
+	opacity := fyne.Min(fyne.Max(view.VisionOpacity, 0), 1)
+	hidden := view.VisionColor
+	hidden.A = uint8(float32(hidden.A) * opacity)
+	explored := view.VisionColor
+	explored.A = uint8(float32(explored.A) * opacity * view.ExploredOpacity)
+
+	sight.Visible.Read(func(visible *image.Alpha) {
+		sight.Explored.Read(func(seen *image.Alpha) {
+			for y, row := range rows {
+				if row < 0 || row >= visible.Rect.Max.Y || row >= seen.Rect.Max.Y {
+					continue
+				}
+
+				for x, column := range columns {
+					if column < 0 || column >= visible.Rect.Max.X || column >= seen.Rect.Max.X {
+						continue
+					}
+					if visible.Pix[row*visible.Stride+column] == fog.Revealed {
+						continue
+					}
+
+					if seen.Pix[row*seen.Stride+column] == fog.Revealed {
+						img.SetNRGBA(x, y, explored)
+					} else {
+						img.SetNRGBA(x, y, hidden)
+					}
+				}
+			}
+		})
+	})
+
+	return img
+}
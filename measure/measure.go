@@ -0,0 +1,202 @@
+package measure
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+// Rule is how distances on the grid are counted
+type Rule string
+
+const (
+	// Simple counts every step, straight or diagonal, as one cell
+	Simple Rule = "5-5-5"
+	// Alternating counts every second diagonal step as two cells
+	Alternating Rule = "5-10-5"
+	// Euclidean measures the straight line between the cells
+	Euclidean Rule = "euclidean"
+	// Hex counts the hexes stepped through
+	Hex Rule = "hex"
+)
+
+// Rules lists the rules in the order they are offered to the GM
+var Rules = []Rule{Simple, Alternating, Euclidean, Hex}
+
+// Unit is what distances in the world are measured in
+type Unit string
+
+const (
+	Feet   Unit = "ft"
+	Meters Unit = "m"
+)
+
+var Units = []Unit{Feet, Meters}
+
+const MetersPerFoot float64 = 0.3048
+
+// DefaultScale is the usual 5 feet to a square
+var DefaultScale = Scale{Distance: 5, Unit: Feet}
+
+// Scales are the scales offered to the GM
+var Scales = []Scale{{Distance: 5, Unit: Feet}, {Distance: 10, Unit: Feet}, {Distance: 1, Unit: Meters}, {Distance: 1.5, Unit: Meters}, {Distance: 2, Unit: Meters}}
+
+// Label names a rule for the GM
+func (rule Rule) Label() string {
+	switch rule {
+	case Simple:
+		return "5/5/5"
+	case Alternating:
+		return "5/10/5"
+	case Euclidean:
+		return "Euclidean"
+	case Hex:
+		return "Hex"
+	}
+
+	return string(rule)
+}
+
+// RulesFor returns the rules that make sense on a kind of grid, hex grids have no diagonals to count
+func RulesFor(kind grid.Kind) []Rule {
+	if kind == grid.HexFlatTop || kind == grid.HexPointyTop {
+		return []Rule{Hex, Euclidean}
+	}
+
+	return []Rule{Simple, Alternating, Euclidean}
+}
+
+// ParseRule returns the rule with the given name when it makes sense on the kind of grid, otherwise the first that does
+func ParseRule(name string, kind grid.Kind) Rule {
+	rules := RulesFor(kind)
+	for _, rule := range rules {
+		if string(rule) == name {
+			return rule
+		}
+	}
+
+	return rules[0]
+}
+
+// ParseUnit returns the unit with the given name, falling back to feet
+func ParseUnit(name string) Unit {
+	for _, unit := range Units {
+		if string(unit) == name {
+			return unit
+		}
+	}
+
+	return Feet
+}
+
+// Scale is how far one grid cell is in the world
+type Scale struct {
+	Distance float64
+	Unit     Unit
+}
+
+// ParseScale returns the scale stored for a map, DefaultScale when it has none
+func ParseScale(settings *mapFile.ScaleSettings) Scale {
+	if settings == nil || settings.Distance <= 0 {
+		return DefaultScale
+	}
+
+	return Scale{Distance: float64(settings.Distance), Unit: ParseUnit(settings.Unit)}
+}
+
+// Label names a scale for the GM, such as "5 ft per square"
+func (scale Scale) Label() string {
+	return fmt.Sprintf("%s %s per square", formatNumber(scale.Distance), scale.Unit)
+}
+
+// In returns how far a number of cells is in a unit
+func (scale Scale) In(cells float64, unit Unit) float64 {
+	distance := cells * scale.Distance
+	switch {
+	case scale.Unit == Feet && unit == Meters:
+		return distance * MetersPerFoot
+	case scale.Unit == Meters && unit == Feet:
+		return distance / MetersPerFoot
+	}
+
+	return distance
+}
+
+// Format writes a number of cells out in the scale's unit followed by the other unit, such as "30 ft (9.1 m)"
+func (scale Scale) Format(cells float64) string {
+	other := Meters
+	if scale.Unit == Meters {
+		other = Feet
+	}
+
+	return fmt.Sprintf("%s %s (%s %s)", formatNumber(scale.In(cells, scale.Unit)), scale.Unit, formatNumber(scale.In(cells, other)), other)
+}
+
+// formatNumber drops the decimal from whole numbers and keeps one otherwise
+func formatNumber(value float64) string {
+	rounded := math.Round(value*10) / 10
+	if rounded == math.Trunc(rounded) {
+		return strconv.FormatFloat(rounded, 'f', 0, 64)
+	}
+
+	return strconv.FormatFloat(rounded, 'f', 1, 64)
+}
+
+// Measurement is the length of a path in cells, leg by leg between its waypoints
+type Measurement struct {
+	Legs  []float64
+	Cells float64
+}
+
+// Snap moves a position to the middle of its cell, where the ruler measures from
+func Snap(g grid.Grid, position fyne.Position) fyne.Position {
+	return g.Center(g.CellAt(position))
+}
+
+// Measure returns how far it is along a path of positions through their cells, counted by rule.
+// The 5/10/5 rule keeps counting diagonals across waypoints, so turning a corner doesn't reset the alternation.
+func Measure(g grid.Grid, rule Rule, path []fyne.Position) Measurement {
+	var measurement Measurement
+	diagonals := 0
+
+	for i := 1; i < len(path); i++ {
+		from, to := g.CellAt(path[i-1]), g.CellAt(path[i])
+
+		var leg float64
+		switch {
+		case rule == Euclidean:
+			if g.CellSize() > 0 {
+				leg = float64(geometry.Distance(g.Center(from), g.Center(to)) / g.CellSize())
+			}
+		case rule == Alternating && g.Kind() == grid.Square:
+			cols, rows := abs(to.Col-from.Col), abs(to.Row-from.Row)
+			diagonal, straight := cols, rows-cols
+			if rows < cols {
+				diagonal, straight = rows, cols-rows
+			}
+			leg = float64(straight + diagonal + (diagonals%2+diagonal)/2)
+			diagonals += diagonal
+		default:
+			leg = float64(g.Distance(from, to))
+		}
+
+		measurement.Legs = append(measurement.Legs, leg)
+		measurement.Cells += leg
+	}
+
+	return measurement
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,68 @@
+package measure
+
+import (
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+func TestSquareRules(t *testing.T) {
+	square := grid.New(grid.Square, fyne.NewPos(0, 0), 50, 50, 0)
+	cell := func(col int, row int) fyne.Position {
+		return square.Center(grid.Cell{Col: col, Row: row})
+	}
+
+	// three squares diagonally then two straight
+	path := []fyne.Position{cell(0, 0), cell(3, 3), cell(5, 3)}
+
+	for _, test := range []struct {
+		rule  Rule
+		cells float64
+	}{
+		{Simple, 5},
+		{Alternating, 6},
+		{Euclidean, 3*math.Sqrt2 + 2},
+	} {
+		measurement := Measure(square, test.rule, path)
+		if math.Abs(measurement.Cells-test.cells) > 0.001 || len(measurement.Legs) != 2 {
+			t.Errorf("%s: expected %.2f cells over 2 legs, got %+v", test.rule, test.cells, measurement)
+		}
+	}
+}
+
+func TestAlternatingCarriesAcrossWaypoints(t *testing.T) {
+	square := grid.New(grid.Square, fyne.NewPos(0, 0), 50, 50, 0)
+	var path []fyne.Position
+	for i := 0; i <= 4; i++ {
+		path = append(path, square.Center(grid.Cell{Col: i, Row: i}))
+	}
+
+	measurement := Measure(square, Alternating, path)
+	if measurement.Cells != 6 {
+		t.Errorf("expected four diagonals one at a time to count 1, 2, 1, 2, got %+v", measurement.Legs)
+	}
+}
+
+func TestHexRule(t *testing.T) {
+	hex := grid.New(grid.HexFlatTop, fyne.NewPos(0, 0), 50, 50, 0)
+	path := []fyne.Position{hex.Center(grid.Cell{}), hex.Center(grid.Cell{Col: 3, Row: 2})}
+
+	if measurement := Measure(hex, ParseRule("5-10-5", grid.HexFlatTop), path); measurement.Cells != 5 {
+		t.Errorf("expected 5 hexes, got %.2f", measurement.Cells)
+	}
+}
+
+func TestScale(t *testing.T) {
+	if formatted := ParseScale(nil).Format(6); formatted != "30 ft (9.1 m)" {
+		t.Errorf("unexpected distance %q", formatted)
+	}
+
+	metric := ParseScale(&mapFile.ScaleSettings{Distance: 1.5, Unit: "m"})
+	if formatted := metric.Format(3); formatted != "4.5 m (14.8 ft)" {
+		t.Errorf("unexpected distance %q", formatted)
+	}
+}
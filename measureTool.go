@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/measure"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const MeasurePanelWidth float32 = 320
+const MeasurePanelHeight float32 = 340
+
+// MeasureWaypointRadius is the size of the dots drawn at the start and the waypoints of the ruler
+const MeasureWaypointRadius float32 = 8
+
+// MeasureLabelSize is the text size of the distance shown at the end of the ruler
+const MeasureLabelSize float32 = 28
+
+var MeasureOverlay *fyne.Container
+
+var measureLineColor = color.NRGBA{R: 255, G: 230, B: 80, A: 255}
+var measureLabelColor = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+var measureLabelBackground = color.NRGBA{R: 0, G: 0, B: 0, A: 170}
+
+// measureDrag is a finger on the table, either drawing a leg of the ruler or about to tap
+type measureDrag struct {
+	start      fyne.Position
+	moved      bool
+	continuing bool
+}
+
+// MeasureTool is a ruler for the map on the table. Dragging measures from where the finger lands to where it lifts,
+// dragging on from the end of the ruler or tapping adds a waypoint so a path around corners can be measured.
+// Distances are counted in grid cells by Rule and shown in the map's Scale.
+type MeasureTool struct {
+	Rule         measure.Rule
+	Scale        measure.Scale
+	OnDeactivate func()
+
+	panel   fyne.CanvasObject
+	preview *fyne.Container
+	readout *widget.Label
+	// path is the start and waypoints of the ruler in map image pixels, snapped to the middle of their cells
+	path   []fyne.Position
+	end    *fyne.Position
+	finger int
+	drag   *measureDrag
+}
+
+// NewMeasureTool starts measuring the map on the table with the rule and scale stored for it
+func NewMeasureTool(onDeactivate func()) *MeasureTool {
+	tool := &MeasureTool{
+		Scale:        measure.DefaultScale,
+		OnDeactivate: onDeactivate,
+		preview:      container.NewWithoutLayout(),
+		readout:      widget.NewLabel(""),
+	}
+
+	kind := TableView.Grid().Kind()
+	tool.Rule = measure.RulesFor(kind)[0]
+	if file := TableView.CurrentMapFile; file != nil && file.Metadata.Scale != nil {
+		tool.Rule = measure.ParseRule(file.Metadata.Scale.Rule, kind)
+		tool.Scale = measure.ParseScale(file.Metadata.Scale)
+	}
+
+	return tool
+}
+
+// HandleTouch draws the ruler with one finger, other fingers are ignored while it is drawing
+func (tool *MeasureTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+
+	switch event.Status {
+	case touch.InitialTouch:
+		if tool.drag != nil || touchesObject(tool.panel, position) {
+			return
+		}
+		continuing := len(tool.path) > 0 && geometry.Distance(ToolView().MapToScreen(tool.path[len(tool.path)-1]), position) <= VisionTapDistance
+		tool.finger = event.ID
+		tool.drag = &measureDrag{start: position, continuing: continuing}
+	case touch.StreamTouch:
+		if tool.drag == nil || event.ID != tool.finger {
+			return
+		}
+		if !tool.drag.moved && geometry.Distance(tool.drag.start, position) > VisionTapDistance {
+			tool.drag.moved = true
+			if !tool.drag.continuing {
+				tool.path = []fyne.Position{tool.snap(tool.drag.start)}
+			}
+		}
+		if tool.drag.moved {
+			end := tool.snap(position)
+			tool.end = &end
+			tool.show()
+		}
+	case touch.UnTouch:
+		if tool.drag == nil || event.ID != tool.finger {
+			return
+		}
+		tool.drag, tool.end = nil, nil
+		tool.addWaypoint(tool.snap(position))
+		tool.show()
+	}
+}
+
+// addWaypoint extends the ruler to the middle of a cell, unless it already ends there
+func (tool *MeasureTool) addWaypoint(waypoint fyne.Position) {
+	if len(tool.path) > 0 && tool.path[len(tool.path)-1] == waypoint {
+		return
+	}
+
+	tool.path = append(tool.path, waypoint)
+}
+
+func (tool *MeasureTool) snap(position fyne.Position) fyne.Position {
+	return measure.Snap(TableView.Grid(), clampToMap(ToolView().ScreenToMap(position)))
+}
+
+// Clear takes the ruler off the map
+func (tool *MeasureTool) Clear() {
+	tool.path, tool.end = nil, nil
+	tool.show()
+}
+
+// SetRule changes how the ruler counts and stores it for the map
+func (tool *MeasureTool) SetRule(rule measure.Rule) {
+	tool.Rule = rule
+	tool.save()
+	tool.show()
+}
+
+// SetScale changes how far a cell is and stores it for the map
+func (tool *MeasureTool) SetScale(scale measure.Scale) {
+	tool.Scale = scale
+	tool.save()
+	tool.show()
+}
+
+func (tool *MeasureTool) save() {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	coverage := ""
+	if file.Metadata.Scale != nil {
+		coverage = file.Metadata.Scale.Coverage
+	}
+	file.Metadata.Scale = &mapFile.ScaleSettings{Distance: float32(tool.Scale.Distance), Unit: string(tool.Scale.Unit), Rule: string(tool.Rule), Coverage: coverage}
+	RedrawTemplates()
+
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// show draws the ruler over the table and writes out how long it is
+func (tool *MeasureTool) show() {
+	path := tool.path
+	if tool.end != nil {
+		path = append(append([]fyne.Position(nil), path...), *tool.end)
+	}
+	measurement := measure.Measure(TableView.Grid(), tool.Rule, path)
+
+	var objects []fyne.CanvasObject
+	for i := 1; i < len(path); i++ {
+		line := canvas.NewLine(measureLineColor)
+		line.StrokeWidth = 4
+		line.Position1, line.Position2 = ToolView().MapToScreen(path[i-1]), ToolView().MapToScreen(path[i])
+		objects = append(objects, line)
+	}
+	for _, waypoint := range path {
+		dot := canvas.NewCircle(measureLineColor)
+		dot.Move(ToolView().MapToScreen(waypoint).Subtract(fyne.NewPos(MeasureWaypointRadius, MeasureWaypointRadius)))
+		dot.Resize(fyne.NewSize(MeasureWaypointRadius*2, MeasureWaypointRadius*2))
+		objects = append(objects, dot)
+	}
+
+	if len(path) > 1 {
+		text := canvas.NewText(tool.Scale.Format(measurement.Cells), measureLabelColor)
+		text.TextSize = MeasureLabelSize
+		text.TextStyle = fyne.TextStyle{Bold: true}
+		size := text.MinSize()
+
+		position := ToolView().MapToScreen(path[len(path)-1]).Add(fyne.NewPos(MeasureWaypointRadius*2, -size.Height-MeasureWaypointRadius))
+		background := canvas.NewRectangle(measureLabelBackground)
+		background.Move(position.Subtract(fyne.NewPos(6, 2)))
+		background.Resize(size.Add(fyne.NewSize(12, 4)))
+		text.Move(position)
+		text.Resize(size)
+		objects = append(objects, background, text)
+	}
+
+	tool.preview.Objects = objects
+	tool.preview.Refresh()
+
+	tool.readout.SetText(tool.describe(measurement))
+}
+
+// describe writes out the length of the ruler and of each leg when it has waypoints
+func (tool *MeasureTool) describe(measurement measure.Measurement) string {
+	if len(measurement.Legs) == 0 {
+		return "Drag to measure"
+	}
+
+	text := fmt.Sprintf("%s, %.1f squares", tool.Scale.Format(measurement.Cells), measurement.Cells)
+	if len(measurement.Legs) > 1 {
+		var legs []string
+		for _, leg := range measurement.Legs {
+			legs = append(legs, tool.Scale.Format(leg))
+		}
+		text += "\n" + strings.Join(legs, " + ")
+	}
+
+	return text
+}
+
+// Deactivate takes the ruler off the table
+func (tool *MeasureTool) Deactivate() {
+	tool.drag, tool.path, tool.end = nil, nil, nil
+	tool.preview.Objects = nil
+	tool.preview.Refresh()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// ShowMeasureTool opens the ruler for the map on the table
+func ShowMeasureTool(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to measure")
+		return false
+	}
+
+	tool := NewMeasureTool(nil)
+	MeasureOverlay = BuildMeasureOverlay(tool)
+	AddToolOverlay(MeasureOverlay)
+	tool.show()
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(MeasureOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildMeasureOverlay(tool *MeasureTool) *fyne.Container {
+
+	rules := measure.RulesFor(TableView.Grid().Kind())
+	var ruleNames []string
+	for _, rule := range rules {
+		ruleNames = append(ruleNames, rule.Label())
+	}
+	ruleSelect := widget.NewSelect(ruleNames, func(name string) {
+		for _, rule := range rules {
+			if rule.Label() == name && rule != tool.Rule {
+				tool.SetRule(rule)
+			}
+		}
+	})
+	ruleSelect.SetSelected(tool.Rule.Label())
+
+	// a scale set by hand in the map's file is offered along with the usual ones
+	scales := measure.Scales
+	known := false
+	for _, scale := range scales {
+		known = known || scale == tool.Scale
+	}
+	if !known {
+		scales = append([]measure.Scale{tool.Scale}, scales...)
+	}
+	var scaleNames []string
+	for _, scale := range scales {
+		scaleNames = append(scaleNames, scale.Label())
+	}
+	scaleSelect := widget.NewSelect(scaleNames, func(name string) {
+		for _, scale := range scales {
+			if scale.Label() == name && scale != tool.Scale {
+				tool.SetScale(scale)
+			}
+		}
+	})
+	scaleSelect.SetSelected(tool.Scale.Label())
+
+	clearButton := widget.NewButton("Clear", tool.Clear)
+
+	doneButton := widget.NewButton("Done", func() {
+		SetActiveTool(nil)
+	})
+	doneButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabel("Drag to measure. Drag on from the end of\nthe ruler or tap to add a waypoint."),
+		container.NewBorder(nil, nil, widget.NewLabel("Diagonals"), nil, ruleSelect),
+		scaleSelect,
+		tool.readout,
+		container.NewGridWithColumns(2, clearButton, doneButton),
+	)
+	panel.Resize(fyne.NewSize(MeasurePanelWidth, MeasurePanelHeight))
+	panel.Move(ToolPanelPosition(MeasurePanelHeight))
+	tool.panel = panel
+
+	return container.NewWithoutLayout(tool.preview, panel)
+}
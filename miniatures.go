@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+// MiniatureRingWidth is the width of the highlight ring drawn under each mini standing on the table
+const MiniatureRingWidth float32 = 3
+
+// MiniatureLabelSize is the text size of the grid cell shown beside each mini
+const MiniatureLabelSize float32 = 14
+
+var MiniatureOverlay *fyne.Container
+
+var miniatureRingColor = color.NRGBA{R: 255, G: 255, B: 255, A: 110}
+var miniatureLabelColor = color.NRGBA{R: 255, G: 255, B: 255, A: 200}
+
+// MiniatureCell is the grid cell of the map a mini on the table is standing on
+type MiniatureCell struct {
+	Miniature touch.Miniature
+	Cell      grid.Cell
+}
+
+var miniatureMutex sync.Mutex
+
+// BuildMiniatureOverlay creates the layer the rings under the minis are drawn on
+func BuildMiniatureOverlay() *fyne.Container {
+	overlay := container.NewWithoutLayout()
+	overlay.Resize(fyne.NewSize(float32(ScreenWidth), float32(ScreenHeight)))
+
+	return overlay
+}
+
+// MiniatureCells returns the cells of the map the minis on the table are standing on, empty when no map is shown
+func MiniatureCells() []MiniatureCell {
+	if Rejector == nil || TableView == nil || TableView.CurrentMap == nil || TableView.CurrentMap.Hidden {
+		return nil
+	}
+
+	var cells []MiniatureCell
+	for _, miniature := range Rejector.Miniatures() {
+		cell := TableView.Grid().CellAt(TableView.ScreenToMap(miniature.Position))
+		cells = append(cells, MiniatureCell{Miniature: miniature, Cell: cell})
+	}
+
+	return cells
+}
+
+// RefreshMiniatures rings the minis on the table and labels each with the grid cell it is standing on
+func RefreshMiniatures() {
+	if MiniatureOverlay == nil || Rejector == nil {
+		return
+	}
+
+	// the rejector's goroutine and the map view both refresh the rings
+	miniatureMutex.Lock()
+	defer miniatureMutex.Unlock()
+
+	radius := Rejector.LearnedZoneRadius
+
+	var objects []fyne.CanvasObject
+	for _, miniature := range Rejector.Miniatures() {
+		ring := canvas.NewCircle(color.Transparent)
+		ring.StrokeColor = miniatureRingColor
+		ring.StrokeWidth = MiniatureRingWidth
+		ring.Move(miniature.Position.Subtract(fyne.NewPos(radius, radius)))
+		ring.Resize(fyne.NewSize(radius*2, radius*2))
+
+		objects = append(objects, ring)
+	}
+
+	for _, placed := range MiniatureCells() {
+		label := canvas.NewText(fmt.Sprintf("%d, %d", placed.Cell.Col, placed.Cell.Row), miniatureLabelColor)
+		label.TextSize = MiniatureLabelSize
+		size := label.MinSize()
+
+		// beside the ring, where the mini itself doesn't cover it
+		label.Move(placed.Miniature.Position.Add(fyne.NewPos(radius+MiniatureRingWidth*2, -size.Height/2)))
+		label.Resize(size)
+
+		objects = append(objects, label)
+	}
+
+	MiniatureOverlay.Objects = objects
+	MiniatureOverlay.Refresh()
+}
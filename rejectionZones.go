@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+// MinZoneSize is the smallest rectangle the zone tool creates, anything smaller is treated as a tap
+const MinZoneSize float32 = 20
+
+var Rejector *touch.Rejector
+var ZoneOverlay *fyne.Container
+
+var zoneColor = color.NRGBA{R: 200, G: 40, B: 40, A: 90}
+var learnedZoneColor = color.NRGBA{R: 230, G: 160, B: 40, A: 90}
+
+// ZoneTool lets the GM drag out rectangles where touches are ignored, tapping inside a zone removes it
+type ZoneTool struct {
+	OnDeactivate func()
+
+	start   fyne.Position
+	preview *canvas.Rectangle
+	drawing bool
+}
+
+// NewZoneTool creates the tool and shows the zone overlay
+func NewZoneTool(onDeactivate func()) *ZoneTool {
+	tool := &ZoneTool{OnDeactivate: onDeactivate}
+	tool.preview = canvas.NewRectangle(zoneColor)
+	tool.preview.Hide()
+
+	// touches inside the zones have to reach the tool to remove them or draw over them
+	Rejector.SetPaused(true)
+	applyClipRectangles()
+	ZoneOverlay.Show()
+	RefreshZoneOverlay()
+
+	return tool
+}
+
+// HandleTouch draws a zone from where the contact starts to where it lifts
+func (tool *ZoneTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+
+	switch event.Status {
+	case touch.InitialTouch:
+		tool.start = position
+		tool.drawing = true
+	case touch.StreamTouch:
+		if !tool.drawing {
+			return
+		}
+		tool.showPreview(geometry.RectPolygon(tool.start, position))
+	case touch.UnTouch:
+		if !tool.drawing {
+			return
+		}
+		tool.drawing = false
+		tool.preview.Hide()
+
+		polygon := geometry.RectPolygon(tool.start, position)
+		min, max := polygon.Bounds()
+		if max.X-min.X < MinZoneSize || max.Y-min.Y < MinZoneSize {
+			Rejector.RemoveZoneAt(position)
+			return
+		}
+
+		fmt.Println("Rejection zone added : ", min, max)
+		Rejector.AddZone(polygon)
+	}
+}
+
+// Deactivate hides the zone overlay again
+func (tool *ZoneTool) Deactivate() {
+	tool.drawing = false
+	tool.preview.Hide()
+	ZoneOverlay.Hide()
+	Rejector.SetPaused(false)
+	applyClipRectangles()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+func (tool *ZoneTool) showPreview(polygon geometry.Polygon) {
+	min, max := polygon.Bounds()
+	tool.preview.Move(min)
+	tool.preview.Resize(fyne.NewSize(max.X-min.X, max.Y-min.Y))
+	tool.preview.Show()
+
+	RefreshZoneOverlay(tool.preview)
+}
+
+// BuildZoneOverlay creates the hidden layer the rejection zones are drawn on
+func BuildZoneOverlay() *fyne.Container {
+	overlay := container.NewWithoutLayout()
+	overlay.Resize(fyne.NewSize(float32(ScreenWidth), float32(ScreenHeight)))
+	overlay.Hide()
+
+	return overlay
+}
+
+// RefreshZoneOverlay redraws the zones, followed by any extra objects such as a zone being drawn
+func RefreshZoneOverlay(extra ...fyne.CanvasObject) {
+	if ZoneOverlay == nil || Rejector == nil {
+		return
+	}
+
+	var objects []fyne.CanvasObject
+	for _, zone := range Rejector.Zones() {
+		min, max := zone.Polygon.Bounds()
+
+		var shape fyne.CanvasObject
+		if zone.Learned {
+			shape = canvas.NewCircle(learnedZoneColor)
+		} else {
+			shape = canvas.NewRectangle(zoneColor)
+		}
+		shape.Move(min)
+		shape.Resize(fyne.NewSize(max.X-min.X, max.Y-min.Y))
+
+		objects = append(objects, shape)
+	}
+
+	ZoneOverlay.Objects = append(objects, extra...)
+	ZoneOverlay.Refresh()
+}
+
+// applyClipRectangles mirrors the GM's zones onto backends that can reject touches in hardware
+func applyClipRectangles() {
+	if clipper, ok := BaseTouchSource().(touch.Clipper); ok {
+		if clipError := clipper.SetClipRectangles(Rejector.ClipRectangles()); clipError != nil {
+			fmt.Println(clipError)
+		}
+	}
+}
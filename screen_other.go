@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+var screenWidthFlag = flag.Int("width", 1920, "screen width in pixels")
+var screenHeightFlag = flag.Int("height", 1080, "screen height in pixels")
+
+// GetScreenResolution has no portable way to query the display outside of Windows, so it uses the -width and -height flags
+func GetScreenResolution() {
+	ScreenWidth = *screenWidthFlag
+	ScreenHeight = *screenHeightFlag
+
+	fmt.Println(strconv.Itoa(ScreenWidth) + " x " + strconv.Itoa(ScreenHeight))
+}
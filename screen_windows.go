@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/lxn/win"
+)
+
+func GetScreenResolution() {
+	hDC := win.GetDC(0)
+	defer win.ReleaseDC(0, hDC)
+	ScreenWidth = int(win.GetDeviceCaps(hDC, win.HORZRES))
+	ScreenHeight = int(win.GetDeviceCaps(hDC, win.VERTRES))
+
+	fmt.Println(strconv.Itoa(ScreenWidth) + " x " + strconv.Itoa(ScreenHeight))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JonCSykes/DragonTable/config"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/mapView"
+)
+
+// LoadSettings reads the settings file, with anything given on the command line taking its place.
+// A settings file with the defaults is written the first time so there is something to edit.
+func LoadSettings(path string) {
+
+	// the flags have already written into Settings, remember them to put back over the file
+	overrides := make(map[string]string)
+	flag.Visit(func(setFlag *flag.Flag) {
+		overrides[setFlag.Name] = setFlag.Value.String()
+	})
+
+	if path == "" {
+		var pathError error
+		if path, pathError = config.DefaultPath(); pathError != nil {
+			fmt.Println(pathError)
+		}
+	}
+
+	*Settings = *config.Default()
+	if path != "" {
+		if loadError := Settings.Load(path); loadError != nil {
+			fmt.Println(path, loadError)
+		}
+
+		if _, statError := os.Stat(path); errors.Is(statError, os.ErrNotExist) {
+			if saveError := Settings.Save(); saveError != nil {
+				fmt.Println(saveError)
+			}
+		}
+	}
+
+	for name, value := range overrides {
+		if setError := flag.Set(name, value); setError != nil {
+			fmt.Println(setError)
+		}
+	}
+
+	fmt.Println("Settings : " + Settings.Path)
+}
+
+// ApplySettings hands the settings to the packages that use them
+func ApplySettings() {
+	mapFile.MapPaths = Settings.MapPaths()
+
+	MapCache = mapFile.NewCache(int64(Settings.MapMemory) * 1024 * 1024)
+	MapCache.InUse = MapInUse
+
+	GridStyle = mapView.DefaultGridStyle
+	GridStyle.Opacity = Settings.Grid.Opacity
+	GridStyle.LineWidth = Settings.Grid.LineWidth
+	GridStyle.Dash = Settings.Grid.Dash
+	GridStyle.Gap = Settings.Grid.Gap
+
+	var colorError error
+	if GridStyle.Color, colorError = mapView.ParseColor(Settings.Grid.Color); colorError != nil {
+		fmt.Println(colorError)
+		GridStyle.Color = mapView.DefaultGridStyle.Color
+	}
+	if GridStyle.Dash > 0 && GridStyle.Gap == 0 {
+		GridStyle.Gap = GridStyle.Dash
+	}
+}
+
+// pathListFlag sets a list of folders from the command line, separated like the PATH variable
+type pathListFlag struct {
+	paths *[]string
+}
+
+func (flag pathListFlag) String() string {
+	if flag.paths == nil {
+		return ""
+	}
+
+	return strings.Join(*flag.paths, string(os.PathListSeparator))
+}
+
+func (flag pathListFlag) Set(text string) error {
+	*flag.paths = filepath.SplitList(text)
+
+	return nil
+}
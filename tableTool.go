@@ -0,0 +1,199 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/mapView"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+// TableTool takes over touch input on the table while it is active, instead of the gestures driving the map
+type TableTool interface {
+	HandleTouch(event touch.Event)
+	Deactivate()
+}
+
+// TickingTool is a tool that also acts while no touches arrive, such as on a finger resting long enough for a long press.
+// Tick is called on the same goroutine as HandleTouch.
+type TickingTool interface {
+	TableTool
+	Tick(now time.Time)
+}
+
+var activeTool TableTool
+var activeToolMutex sync.Mutex
+
+// ActiveTool returns the tool currently receiving touch input, or nil when the map has it
+func ActiveTool() TableTool {
+	activeToolMutex.Lock()
+	defer activeToolMutex.Unlock()
+
+	return activeTool
+}
+
+// SetActiveTool hands touch input to tool, pass nil to give it back to the map
+func SetActiveTool(tool TableTool) {
+	activeToolMutex.Lock()
+	previous := activeTool
+	activeTool = tool
+	activeToolMutex.Unlock()
+
+	if previous != nil && previous != tool {
+		previous.Deactivate()
+	}
+	showGMPointer(tool != nil && !onTable(tool))
+}
+
+// onTable returns true for tools that work on the table's screen rather than on the map, such as drawing rejection
+// zones, which keep the table's touches when the other tools are used from the GM screen
+func onTable(tool TableTool) bool {
+	switch tool.(type) {
+	case *ZoneTool, *CalibrationTool:
+		return true
+	}
+
+	return false
+}
+
+// routeTouchEvents sends touch events to the active tool and passes the rest on to the gestures.
+// With a GM screen the tools are used from there, and the table's touches are dropped so the map stays still.
+func routeTouchEvents(events <-chan touch.Event) <-chan touch.Event {
+
+	routed := make(chan touch.Event, touch.EventBufferSize)
+
+	go func() {
+		defer close(routed)
+
+		ticker := time.NewTicker(touch.GestureTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if tool := ActiveTool(); tool != nil {
+					if GMWindow == nil || onTable(tool) {
+						tool.HandleTouch(event)
+					}
+					continue
+				}
+				routed <- event
+			case now := <-ticker.C:
+				tickActiveTool(now, true)
+			}
+		}
+	}()
+
+	return routed
+}
+
+// tickActiveTool lets the active tool act on time passing, from the goroutine its touches come from: the table's
+// when fromTable is set, the GM screen's otherwise
+func tickActiveTool(now time.Time, fromTable bool) {
+	tool, ticking := ActiveTool().(TickingTool)
+	if ticking && (GMWindow == nil || onTable(tool)) == fromTable {
+		tool.Tick(now)
+	}
+}
+
+// ToolView returns the view the tools are used on: the GM screen when there is one, leaving the table a clean
+// player view, otherwise the table. Tools get touches and draw their markers in the screen positions of this view.
+func ToolView() *mapView.MapView {
+	if GMWindow != nil {
+		return GMView
+	}
+
+	return TableView
+}
+
+// ToolScreenSize returns the size of the screen the tools are used on
+func ToolScreenSize() fyne.Size {
+	if GMWindow != nil {
+		return fyne.NewSize(float32(GMWidth), float32(GMHeight))
+	}
+
+	return fyne.NewSize(float32(ScreenWidth), float32(ScreenHeight))
+}
+
+// ToolPanelPosition returns where a tool's panel of the given height goes, down the left edge of the tool screen
+func ToolPanelPosition(height float32) fyne.Position {
+	screen := ToolScreenSize()
+
+	return fyne.NewPos(20, fyne.Max(fyne.Min(screen.Height/4, screen.Height-height-10), 0))
+}
+
+// AddToolOverlay shows a tool's panel and markers on the screen the tools are used on
+func AddToolOverlay(overlay fyne.CanvasObject) {
+	if GMWindow != nil {
+		gmMapArea.Add(overlay)
+		return
+	}
+
+	mainContent.Add(overlay)
+}
+
+// RemoveToolOverlay takes a tool's panel and markers off again
+func RemoveToolOverlay(overlay fyne.CanvasObject) {
+	if GMWindow != nil {
+		gmMapArea.Remove(overlay)
+		return
+	}
+
+	mainContent.Remove(overlay)
+}
+
+// toolPointer returns the surface the pointer on the tool screen reaches the tools through, nil when the table's
+// touches come from hardware
+func toolPointer() *touch.PointerSurface {
+	if GMWindow != nil {
+		return gmPointer.Surface
+	}
+	if pointerSource, ok := BaseTouchSource().(*touch.PointerSource); ok {
+		return pointerSource.Surface
+	}
+
+	return nil
+}
+
+// toolOrigin returns where the tool screen starts in its window, touches are given to the tools from there
+func toolOrigin() fyne.Position {
+	if GMWindow != nil {
+		return fyne.CurrentApp().Driver().AbsolutePositionForObject(gmMapArea)
+	}
+
+	return fyne.Position{}
+}
+
+// touchesObject returns true if a touch at position lands on a visible object, such as a tool's panel
+func touchesObject(object fyne.CanvasObject, position fyne.Position) bool {
+	if object == nil || !object.Visible() {
+		return false
+	}
+
+	min, size := object.Position(), object.Size()
+
+	return position.X >= min.X && position.Y >= min.Y && position.X <= min.X+size.Width && position.Y <= min.Y+size.Height
+}
+
+// clampToMap keeps a map position on the map on the table
+func clampToMap(position fyne.Position) fyne.Position {
+	size := TableView.CurrentMapSize
+
+	return fyne.NewPos(fyne.Min(fyne.Max(position.X, 0), size.Width-1), fyne.Min(fyne.Max(position.Y, 0), size.Height-1))
+}
+
+// touchesNestedObject is touchesObject for an object inside a container, such as a picture in a tool's panel
+func touchesNestedObject(object fyne.CanvasObject, position fyne.Position) bool {
+	if object == nil || !object.Visible() {
+		return false
+	}
+
+	min, size := fyne.CurrentApp().Driver().AbsolutePositionForObject(object).Subtract(toolOrigin()), object.Size()
+
+	return position.X >= min.X && position.Y >= min.Y && position.X <= min.X+size.Width && position.Y <= min.Y+size.Height
+}
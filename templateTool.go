@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/aoe"
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const TemplatePanelWidth float32 = 320
+const TemplatePanelHeight float32 = 400
+
+// TemplateHandleRadius is the size of the handle templates are turned with
+const TemplateHandleRadius float32 = 16
+
+var TemplateOverlay *fyne.Container
+
+var templateHandleColor = color.NRGBA{R: 255, G: 230, B: 80, A: 255}
+
+// TemplateCoverageNames are the coverage rules offered to the GM
+var TemplateCoverageNames = map[aoe.Coverage]string{aoe.Center: "Center of square", aoe.Overlap: "Any overlap"}
+
+// templateDrag is a finger on the table, moving or turning a template or about to tap
+type templateDrag struct {
+	template int
+	handle   bool
+	grab     fyne.Position
+	start    fyne.Position
+	moved    bool
+}
+
+// TemplateTool places spell templates on the map on the table. A tap places a template of the chosen shape and size,
+// or selects the template under it. Templates are dragged to move them and turned by the handle of the selected one.
+// They stay on the map when the tool is closed, until they are dismissed.
+type TemplateTool struct {
+	Shape        aoe.Shape
+	Size         float32
+	OnDeactivate func()
+
+	panel       fyne.CanvasObject
+	preview     *fyne.Container
+	drags       map[int]*templateDrag
+	selected    int
+	shapeSelect *widget.Select
+	sizeEntry   *widget.Entry
+	selecting   bool
+}
+
+// NewTemplateTool starts placing spheres on the map on the table
+func NewTemplateTool(onDeactivate func()) *TemplateTool {
+	return &TemplateTool{
+		Shape:        aoe.Sphere,
+		Size:         aoe.DefaultSize(aoe.Sphere, TableView.TemplateScale().Unit),
+		OnDeactivate: onDeactivate,
+		preview:      container.NewWithoutLayout(),
+		drags:        make(map[int]*templateDrag),
+		selected:     -1,
+	}
+}
+
+// HandleTouch places, selects, moves and turns templates
+func (tool *TemplateTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+
+	drag, dragging := tool.drags[event.ID]
+	switch event.Status {
+	case touch.InitialTouch:
+		if touchesObject(tool.panel, position) {
+			return
+		}
+
+		drag = &templateDrag{template: tool.templateAt(position), start: position}
+		if tool.handleAt(position) {
+			drag.template, drag.handle = tool.selected, true
+		}
+		if drag.template >= 0 {
+			drag.grab = TableView.Templates[drag.template].Origin.Subtract(ToolView().ScreenToMap(position))
+		}
+		tool.drags[event.ID] = drag
+	case touch.StreamTouch:
+		if !dragging {
+			return
+		}
+		if geometry.Distance(drag.start, position) > VisionTapDistance {
+			drag.moved = true
+		}
+		if !drag.moved || drag.template < 0 || drag.template >= len(TableView.Templates) {
+			return
+		}
+
+		templates := append([]mapFile.Template(nil), TableView.Templates...)
+		placed := &templates[drag.template]
+		if drag.handle {
+			toward := ToolView().ScreenToMap(position).Subtract(placed.Origin)
+			placed.Angle = float32(math.Atan2(float64(toward.Y), float64(toward.X)) * 180 / math.Pi)
+		} else {
+			placed.Origin = clampToMap(ToolView().ScreenToMap(position).Add(drag.grab))
+		}
+		SetTemplates(templates)
+		tool.showHandles()
+	case touch.UnTouch:
+		if !dragging {
+			return
+		}
+		delete(tool.drags, event.ID)
+
+		if drag.moved {
+			SaveTemplates()
+		} else if drag.template >= 0 {
+			tool.Select(drag.template)
+		} else {
+			tool.place(position)
+		}
+	}
+}
+
+// place adds a template of the chosen shape and size at a screen position
+func (tool *TemplateTool) place(position fyne.Position) {
+	placed := mapFile.Template{Shape: string(tool.Shape), Origin: clampToMap(ToolView().ScreenToMap(position)), Size: tool.Size}
+	SetTemplates(append(append([]mapFile.Template(nil), TableView.Templates...), placed))
+	SaveTemplates()
+
+	tool.Select(len(TableView.Templates) - 1)
+}
+
+// templateAt returns the index of the topmost template under a screen position, -1 when there is none
+func (tool *TemplateTool) templateAt(position fyne.Position) int {
+	mapPosition := ToolView().ScreenToMap(position)
+	for i := len(TableView.Templates) - 1; i >= 0; i-- {
+		if aoe.Footprint(TableView.Templates[i], TableView.Grid(), TableView.TemplateScale()).Contains(mapPosition) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// handleAt returns true if a screen position is on the handle of the selected template
+func (tool *TemplateTool) handleAt(position fyne.Position) bool {
+	if tool.selected < 0 || tool.selected >= len(TableView.Templates) || !aoe.Rotates(aoe.ParseShape(TableView.Templates[tool.selected].Shape)) {
+		return false
+	}
+
+	handle := aoe.Handle(TableView.Templates[tool.selected], TableView.Grid(), TableView.TemplateScale())
+
+	return geometry.Distance(ToolView().MapToScreen(handle), position) <= TemplateHandleRadius*2
+}
+
+// Select makes a template the one the shape and size settings apply to, -1 selects nothing
+func (tool *TemplateTool) Select(index int) {
+	if index >= len(TableView.Templates) {
+		index = -1
+	}
+	tool.selected = index
+
+	if index >= 0 && tool.shapeSelect != nil {
+		// show the template's settings without applying them back to it
+		tool.selecting = true
+		tool.shapeSelect.SetSelected(string(aoe.ParseShape(TableView.Templates[index].Shape)))
+		tool.sizeEntry.SetText(formatSize(TableView.Templates[index].Size))
+		tool.selecting = false
+	}
+
+	tool.showHandles()
+}
+
+// SetShape changes the shape of new templates and of the selected template
+func (tool *TemplateTool) SetShape(shape aoe.Shape) {
+	if tool.selecting {
+		return
+	}
+	tool.Shape = shape
+	tool.Size = aoe.DefaultSize(shape, TableView.TemplateScale().Unit)
+	if tool.sizeEntry != nil {
+		// changing the size from here applies it to the selected template as well
+		tool.sizeEntry.SetText(formatSize(tool.Size))
+	}
+
+	tool.change(func(placed *mapFile.Template) { placed.Shape = string(shape) })
+}
+
+// SetSize changes the size of new templates and of the selected template, in the unit of the map's scale
+func (tool *TemplateTool) SetSize(size float32) {
+	if tool.selecting || size <= 0 {
+		return
+	}
+	tool.Size = size
+
+	tool.change(func(placed *mapFile.Template) { placed.Size = size })
+}
+
+// change applies a setting to the selected template and saves it
+func (tool *TemplateTool) change(apply func(placed *mapFile.Template)) {
+	if tool.selected < 0 || tool.selected >= len(TableView.Templates) {
+		return
+	}
+
+	templates := append([]mapFile.Template(nil), TableView.Templates...)
+	apply(&templates[tool.selected])
+	SetTemplates(templates)
+	SaveTemplates()
+	tool.showHandles()
+}
+
+// Dismiss takes the selected template off the map
+func (tool *TemplateTool) Dismiss() {
+	if tool.selected < 0 || tool.selected >= len(TableView.Templates) {
+		return
+	}
+
+	templates := append([]mapFile.Template(nil), TableView.Templates[:tool.selected]...)
+	SetTemplates(append(templates, TableView.Templates[tool.selected+1:]...))
+	SaveTemplates()
+	tool.Select(-1)
+}
+
+// DismissAll takes every template off the map
+func (tool *TemplateTool) DismissAll() {
+	SetTemplates(nil)
+	SaveTemplates()
+	tool.Select(-1)
+}
+
+// showHandles marks the origin of the selected template and the handle it is turned with
+func (tool *TemplateTool) showHandles() {
+	var objects []fyne.CanvasObject
+
+	if tool.selected >= 0 && tool.selected < len(TableView.Templates) {
+		selected := TableView.Templates[tool.selected]
+		origin := ToolView().MapToScreen(selected.Origin)
+
+		marker := canvas.NewCircle(color.Transparent)
+		marker.StrokeColor = templateHandleColor
+		marker.StrokeWidth = 3
+		marker.Move(origin.Subtract(fyne.NewPos(TemplateHandleRadius/2, TemplateHandleRadius/2)))
+		marker.Resize(fyne.NewSize(TemplateHandleRadius, TemplateHandleRadius))
+		objects = append(objects, marker)
+
+		if aoe.Rotates(aoe.ParseShape(selected.Shape)) {
+			handle := ToolView().MapToScreen(aoe.Handle(selected, TableView.Grid(), TableView.TemplateScale()))
+
+			line := canvas.NewLine(templateHandleColor)
+			line.StrokeWidth = 2
+			line.Position1, line.Position2 = origin, handle
+			dot := canvas.NewCircle(templateHandleColor)
+			dot.Move(handle.Subtract(fyne.NewPos(TemplateHandleRadius, TemplateHandleRadius)))
+			dot.Resize(fyne.NewSize(TemplateHandleRadius*2, TemplateHandleRadius*2))
+			objects = append(objects, line, dot)
+		}
+	}
+
+	tool.preview.Objects = objects
+	tool.preview.Refresh()
+}
+
+// Deactivate closes the template panel, the templates stay on the map
+func (tool *TemplateTool) Deactivate() {
+	tool.drags = make(map[int]*templateDrag)
+	tool.preview.Objects = nil
+	tool.preview.Refresh()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+func formatSize(size float32) string {
+	return strconv.FormatFloat(float64(size), 'f', -1, 32)
+}
+
+// SetTemplates shows areas of effect on the table and the GM screen
+func SetTemplates(templates []mapFile.Template) {
+	TableView.SetTemplates(templates)
+
+	if GMView != nil {
+		GMView.SetTemplates(templates)
+	}
+}
+
+// RedrawTemplates draws the areas of effect again on the table and the GM screen after the map's scale changed
+func RedrawTemplates() {
+	TableView.RedrawTemplates()
+
+	if GMView != nil {
+		GMView.RedrawTemplates()
+	}
+}
+
+// SaveTemplates stores the templates on the table next to the map
+func SaveTemplates() {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	file.Metadata.Templates = TableView.Templates
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// SetTemplateCoverage changes which cells of the map on the table the templates affect and saves it
+func SetTemplateCoverage(coverage aoe.Coverage) {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	if file.Metadata.Scale == nil {
+		scale := TableView.TemplateScale()
+		file.Metadata.Scale = &mapFile.ScaleSettings{Distance: float32(scale.Distance), Unit: string(scale.Unit)}
+	}
+	file.Metadata.Scale.Coverage = string(coverage)
+	RedrawTemplates()
+
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// ShowTemplateTool opens the template panel for the map on the table
+func ShowTemplateTool(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to place templates on")
+		return false
+	}
+
+	tool := NewTemplateTool(nil)
+	TemplateOverlay = BuildTemplateOverlay(tool)
+	AddToolOverlay(TemplateOverlay)
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(TemplateOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildTemplateOverlay(tool *TemplateTool) *fyne.Container {
+
+	var shapeNames []string
+	for _, shape := range aoe.Shapes {
+		shapeNames = append(shapeNames, string(shape))
+	}
+	tool.shapeSelect = widget.NewSelect(shapeNames, func(name string) {
+		tool.SetShape(aoe.ParseShape(name))
+	})
+
+	tool.sizeEntry = widget.NewEntry()
+	tool.sizeEntry.OnChanged = func(text string) {
+		size, parseError := strconv.ParseFloat(text, 32)
+		if parseError == nil {
+			tool.SetSize(float32(size))
+		}
+	}
+	tool.shapeSelect.SetSelected(string(tool.Shape))
+
+	scale := TableView.TemplateScale()
+	step := func(change float64) func() {
+		return func() {
+			tool.sizeEntry.SetText(formatSize(fyne.Max(float32(scale.Distance), tool.Size+float32(change))))
+		}
+	}
+	sizeRow := container.NewBorder(nil, nil, widget.NewButton("-", step(-scale.Distance)), widget.NewButton("+", step(scale.Distance)), tool.sizeEntry)
+
+	var coverageNames []string
+	for _, coverage := range aoe.Coverages {
+		coverageNames = append(coverageNames, TemplateCoverageNames[coverage])
+	}
+	coverageSelect := widget.NewSelect(coverageNames, func(name string) {
+		for coverage, coverageName := range TemplateCoverageNames {
+			if coverageName == name && coverage != TableView.TemplateCoverage() {
+				SetTemplateCoverage(coverage)
+			}
+		}
+	})
+	coverageSelect.SetSelected(TemplateCoverageNames[TableView.TemplateCoverage()])
+
+	dismissButton := widget.NewButton("Dismiss", tool.Dismiss)
+	dismissAllButton := widget.NewButton("Dismiss All", tool.DismissAll)
+
+	doneButton := widget.NewButton("Done", func() {
+		SetActiveTool(nil)
+	})
+	doneButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabel("Tap to place a template, tap one to select\nit and drag it to move it. Drag the handle\nto turn cones, lines and cubes."),
+		tool.shapeSelect,
+		widget.NewLabel(fmt.Sprintf("Size in %s, the radius of spheres\nand cylinders", scale.Unit)),
+		sizeRow,
+		coverageSelect,
+		container.NewGridWithColumns(2, dismissButton, dismissAllButton),
+		doneButton,
+	)
+	panel.Resize(fyne.NewSize(TemplatePanelWidth, TemplatePanelHeight))
+	panel.Move(ToolPanelPosition(TemplatePanelHeight))
+	tool.panel = panel
+
+	return container.NewWithoutLayout(tool.preview, panel)
+}
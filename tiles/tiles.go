@@ -0,0 +1,441 @@
+package tiles
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TileSize is the width and height of a tile in pixels
+var TileSize = 512
+
+// PreviewSize is the largest width or height of the preview, the level shown in one piece when zoomed out
+var PreviewSize = 2048
+
+// TiledMapSize is the width or height above which a map is shown from tiles instead of one image
+var TiledMapSize = 4096
+
+// MaxCachedTiles is how many decoded tiles are kept in memory across all pyramids
+var MaxCachedTiles = 256
+
+const manifestName string = "manifest.json"
+const previewName string = "preview"
+
+// Pyramid is a map cut into tiles at full size and at every halving down to the preview, cached on disk.
+// Level 0 is the full size map and each level after it is half the size of the one before.
+type Pyramid struct {
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	TileSize int    `json:"tileSize"`
+	Levels   int    `json:"levels"`
+	Format   string `json:"format"`
+
+	dir string
+}
+
+// TileKey identifies one tile of a pyramid
+type TileKey struct {
+	Level int
+	X     int
+	Y     int
+}
+
+// openPyramid is a pyramid that is opened once, the lock on the list of pyramids is only held to find it so cutting
+// one map into tiles doesn't hold up the tiles of the others
+type openPyramid struct {
+	once    sync.Once
+	pyramid *Pyramid
+	err     error
+}
+
+var pyramids = make(map[string]*openPyramid)
+var pyramidsMutex sync.Mutex
+
+// DefaultCacheDir returns the tile cache directory under the user cache directory
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "DragonTable", "tiles"), nil
+}
+
+// NeedsTiles returns true if a map of this size is too large to show as one image
+func NeedsTiles(width int, height int) bool {
+	return width > TiledMapSize || height > TiledMapSize
+}
+
+// Open returns the pyramid for a map image, cutting it into tiles under cacheDir the first time the map is opened
+// or after the file changed. The tiles of earlier versions of the map are removed then.
+func Open(path string, cacheDir string) (*Pyramid, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		absolutePath = path
+	}
+	// the folder is named by the map's path and then its version, so the versions of one map can be found
+	pathHash := sha1.Sum([]byte(absolutePath))
+	versionHash := sha1.Sum([]byte(strconv.FormatInt(info.Size(), 10) + "|" + strconv.FormatInt(info.ModTime().UnixNano(), 10) + "|" + strconv.Itoa(TileSize)))
+	prefix := filepath.Join(cacheDir, hex.EncodeToString(pathHash[:]))
+	dir := prefix + "-" + hex.EncodeToString(versionHash[:])
+
+	pyramidsMutex.Lock()
+	opened, found := pyramids[dir]
+	if !found {
+		opened = &openPyramid{}
+		pyramids[dir] = opened
+	}
+	pyramidsMutex.Unlock()
+
+	opened.once.Do(func() {
+		if opened.pyramid, opened.err = loadManifest(dir); opened.err != nil {
+			opened.pyramid, opened.err = build(path, dir)
+		}
+		if opened.err == nil {
+			prune(prefix, dir)
+		}
+	})
+
+	if opened.err != nil {
+		// forget the failure so the map can be tried again
+		pyramidsMutex.Lock()
+		if pyramids[dir] == opened {
+			delete(pyramids, dir)
+		}
+		pyramidsMutex.Unlock()
+
+		return nil, opened.err
+	}
+
+	return opened.pyramid, nil
+}
+
+// prune removes the tiles of the other versions of a map, leaving those still open in case they are on screen
+func prune(prefix string, keep string) {
+	versions, err := filepath.Glob(prefix + "-*")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, version := range versions {
+		pyramidsMutex.Lock()
+		_, open := pyramids[version]
+		pyramidsMutex.Unlock()
+
+		if version == keep || open {
+			continue
+		}
+		if err = os.RemoveAll(version); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+func loadManifest(dir string) (*Pyramid, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	pyramid := &Pyramid{dir: dir}
+	if err = json.Unmarshal(data, pyramid); err != nil {
+		return nil, err
+	}
+
+	return pyramid, nil
+}
+
+// build decodes the map once and writes every level's tiles and the preview
+func build(path string, dir string) (*Pyramid, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source, format, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// photos are stored as jpeg, anything that may have transparency as png
+	if format != "jpeg" {
+		format = "png"
+	}
+
+	bounds := source.Bounds()
+	pyramid := &Pyramid{Width: bounds.Dx(), Height: bounds.Dy(), TileSize: TileSize, Format: format, dir: dir}
+
+	if err = os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	level := toRGBA(source)
+	for {
+		size := level.Bounds().Size()
+		if size.X <= PreviewSize && size.Y <= PreviewSize {
+			if err = pyramid.write(filepath.Join(dir, previewName+"."+pyramid.extension()), level); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		if err = pyramid.writeLevel(pyramid.Levels, level); err != nil {
+			return nil, err
+		}
+		pyramid.Levels++
+		level = halve(level)
+	}
+
+	data, err := json.Marshal(pyramid)
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, manifestName), data, 0644); err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Cut " + path + " into " + strconv.Itoa(pyramid.Levels) + " levels of tiles")
+
+	return pyramid, nil
+}
+
+func (pyramid *Pyramid) writeLevel(level int, img *image.RGBA) error {
+	size := img.Bounds().Size()
+
+	for y := 0; y*pyramid.TileSize < size.Y; y++ {
+		for x := 0; x*pyramid.TileSize < size.X; x++ {
+			tileBounds := image.Rect(x*pyramid.TileSize, y*pyramid.TileSize, (x+1)*pyramid.TileSize, (y+1)*pyramid.TileSize).Intersect(img.Bounds())
+			if err := pyramid.write(pyramid.tilePath(TileKey{Level: level, X: x, Y: y}), img.SubImage(tileBounds)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (pyramid *Pyramid) write(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if pyramid.Format == "jpeg" {
+		return jpeg.Encode(file, img, &jpeg.Options{Quality: 90})
+	}
+
+	return png.Encode(file, img)
+}
+
+func (pyramid *Pyramid) extension() string {
+	if pyramid.Format == "jpeg" {
+		return "jpg"
+	}
+
+	return "png"
+}
+
+func (pyramid *Pyramid) tilePath(key TileKey) string {
+	return filepath.Join(pyramid.dir, strconv.Itoa(key.Level), strconv.Itoa(key.X)+"_"+strconv.Itoa(key.Y)+"."+pyramid.extension())
+}
+
+// Preview loads the whole map at the size of the level after the last tiled one
+func (pyramid *Pyramid) Preview() (image.Image, error) {
+	return readImage(filepath.Join(pyramid.dir, previewName+"."+pyramid.extension()))
+}
+
+// PreviewScale returns how many map pixels one preview pixel covers
+func (pyramid *Pyramid) PreviewScale() float32 {
+	return float32(math.Pow(2, float64(pyramid.Levels)))
+}
+
+// LevelFor returns the level to draw the map from at a zoom, or Levels when the preview is detailed enough
+func (pyramid *Pyramid) LevelFor(zoom float64) int {
+	if zoom <= 0 {
+		return pyramid.Levels
+	}
+
+	level := int(math.Floor(math.Log2(1 / zoom)))
+	if level < 0 {
+		return 0
+	}
+	if level > pyramid.Levels {
+		return pyramid.Levels
+	}
+
+	return level
+}
+
+// TileBounds returns the area of the full size map a tile covers
+func (pyramid *Pyramid) TileBounds(key TileKey) image.Rectangle {
+	span := pyramid.TileSize << key.Level
+
+	return image.Rect(key.X*span, key.Y*span, (key.X+1)*span, (key.Y+1)*span).Intersect(image.Rect(0, 0, pyramid.Width, pyramid.Height))
+}
+
+// TilesIn returns the tiles of a level that cover an area of the full size map
+func (pyramid *Pyramid) TilesIn(level int, area image.Rectangle) []TileKey {
+	var keys []TileKey
+	if level < 0 || level >= pyramid.Levels {
+		return keys
+	}
+
+	area = area.Intersect(image.Rect(0, 0, pyramid.Width, pyramid.Height))
+	if area.Empty() {
+		return keys
+	}
+
+	span := pyramid.TileSize << level
+	for y := area.Min.Y / span; y <= (area.Max.Y-1)/span; y++ {
+		for x := area.Min.X / span; x <= (area.Max.X-1)/span; x++ {
+			keys = append(keys, TileKey{Level: level, X: x, Y: y})
+		}
+	}
+
+	return keys
+}
+
+// Tile loads a tile, keeping recently used tiles in memory
+func (pyramid *Pyramid) Tile(key TileKey) (image.Image, error) {
+	path := pyramid.tilePath(key)
+	if img, found := cache.get(path); found {
+		return img, nil
+	}
+
+	img, err := readImage(path)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(path, img)
+
+	return img, nil
+}
+
+func readImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(path, ".jpg") {
+		return jpeg.Decode(file)
+	}
+
+	return png.Decode(file)
+}
+
+func toRGBA(source image.Image) *image.RGBA {
+	if rgba, ok := source.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := source.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), source, bounds.Min, draw.Src)
+
+	return rgba
+}
+
+// halve shrinks an image to half its size, averaging each 2x2 block of pixels
+func halve(source *image.RGBA) *image.RGBA {
+	size := source.Bounds().Size()
+	width, height := (size.X+1)/2, (size.Y+1)/2
+	halved := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sum [4]int
+			count := 0
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					sourceX, sourceY := x*2+dx, y*2+dy
+					if sourceX >= size.X || sourceY >= size.Y {
+						continue
+					}
+					offset := sourceY*source.Stride + sourceX*4
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(source.Pix[offset+channel])
+					}
+					count++
+				}
+			}
+
+			offset := y*halved.Stride + x*4
+			for channel := 0; channel < 4; channel++ {
+				halved.Pix[offset+channel] = uint8(sum[channel] / count)
+			}
+		}
+	}
+
+	return halved
+}
+
+// tileCache keeps the most recently used decoded tiles
+type tileCache struct {
+	order   *list.List
+	entries map[string]*list.Element
+	mutex   sync.Mutex
+}
+
+type cachedTile struct {
+	path  string
+	image image.Image
+}
+
+var cache = &tileCache{order: list.New(), entries: make(map[string]*list.Element)}
+
+func (cache *tileCache) get(path string) (image.Image, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, found := cache.entries[path]
+	if !found {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+
+	return element.Value.(*cachedTile).image, true
+}
+
+func (cache *tileCache) put(path string, img image.Image) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.entries[path]; found {
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[path] = cache.order.PushFront(&cachedTile{path: path, image: img})
+	for cache.order.Len() > MaxCachedTiles {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cachedTile).path)
+	}
+}
@@ -0,0 +1,113 @@
+package tiles
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestMap(t *testing.T, img image.Image) string {
+	path := filepath.Join(t.TempDir(), "map.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = png.Encode(file, img)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestPyramid(t *testing.T) {
+	tileSize, previewSize := TileSize, PreviewSize
+	t.Cleanup(func() { TileSize, PreviewSize = tileSize, previewSize })
+	TileSize, PreviewSize = 64, 128
+
+	img := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 600; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x / 4), G: uint8(y / 4), B: 100, A: 255})
+		}
+	}
+	path := writeTestMap(t, img)
+	cacheDir := t.TempDir()
+
+	pyramid, err := Open(path, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 600 -> 300 -> 150 are tiled, 75 is the preview
+	if pyramid.Levels != 3 {
+		t.Fatalf("expected 3 tiled levels, got %d", pyramid.Levels)
+	}
+
+	preview, err := pyramid.Preview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := preview.Bounds().Size(); size.X != 75 || size.Y != 38 {
+		t.Errorf("expected a 75x38 preview, got %v", size)
+	}
+
+	tile, err := pyramid.Tile(TileKey{Level: 0, X: 9, Y: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := tile.Bounds().Size(); size.X != 24 || size.Y != 44 {
+		t.Errorf("expected the corner tile to be cut to the map, got %v", size)
+	}
+	if r, g, _, _ := tile.At(0, 0).RGBA(); r>>8 != 576/4 || g>>8 != 256/4 {
+		t.Errorf("expected the tile to hold the map pixels from 576,256, got %d,%d", r>>8, g>>8)
+	}
+
+	if keys := pyramid.TilesIn(1, image.Rect(100, 100, 250, 200)); len(keys) != 4 {
+		t.Errorf("expected 4 tiles of level 1 to cover the area, got %v", keys)
+	}
+	if level := pyramid.LevelFor(0.3); level != 1 {
+		t.Errorf("expected level 1 at 30%% zoom, got %d", level)
+	}
+
+	pyramids = make(map[string]*openPyramid)
+	reopened, err := Open(path, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Levels != pyramid.Levels || reopened.Width != 600 {
+		t.Error("expected the pyramid to be read back from the cache")
+	}
+}
+
+func TestOpenRemovesTilesOfChangedMaps(t *testing.T) {
+	tileSize, previewSize := TileSize, PreviewSize
+	t.Cleanup(func() { TileSize, PreviewSize = tileSize, previewSize })
+	TileSize, PreviewSize = 64, 128
+
+	path := writeTestMap(t, image.NewRGBA(image.Rect(0, 0, 300, 200)))
+	cacheDir := t.TempDir()
+	if _, err := Open(path, cacheDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// the map is edited and the app started again
+	changed := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, changed, changed); err != nil {
+		t.Fatal(err)
+	}
+	pyramids = make(map[string]*openPyramid)
+
+	pyramid, err := Open(path, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := filepath.Glob(filepath.Join(cacheDir, "*")); len(versions) != 1 || versions[0] != pyramid.dir {
+		t.Errorf("expected only the tiles of the changed map to be kept, got %v", versions)
+	}
+}
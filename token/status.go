@@ -0,0 +1,128 @@
+package token
+
+import (
+	"image/color"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+// Team is who a token belongs to, shown as the color of the ring around it
+type Team struct {
+	Name  string
+	Color color.NRGBA
+}
+
+// Teams are the rings offered for tokens
+var Teams = []Team{
+	{Name: "party", Color: color.NRGBA{R: 60, G: 130, B: 240, A: 255}},
+	{Name: "ally", Color: color.NRGBA{R: 70, G: 200, B: 90, A: 255}},
+	{Name: "neutral", Color: color.NRGBA{R: 240, G: 200, B: 50, A: 255}},
+	{Name: "enemy", Color: color.NRGBA{R: 220, G: 50, B: 50, A: 255}},
+}
+
+// Condition is a state a creature can be in, shown as a small marker with its short name around the token
+type Condition struct {
+	Name  string
+	Short string
+	Color color.NRGBA
+}
+
+// Conditions are the markers offered for tokens
+var Conditions = []Condition{
+	{Name: "blinded", Short: "Bl", Color: color.NRGBA{R: 90, G: 90, B: 90, A: 255}},
+	{Name: "charmed", Short: "Ch", Color: color.NRGBA{R: 230, G: 100, B: 180, A: 255}},
+	{Name: "concentrating", Short: "Co", Color: color.NRGBA{R: 80, G: 160, B: 255, A: 255}},
+	{Name: "frightened", Short: "Fr", Color: color.NRGBA{R: 150, G: 60, B: 200, A: 255}},
+	{Name: "grappled", Short: "Gr", Color: color.NRGBA{R: 170, G: 110, B: 50, A: 255}},
+	{Name: "invisible", Short: "In", Color: color.NRGBA{R: 180, G: 220, B: 240, A: 255}},
+	{Name: "paralyzed", Short: "Pa", Color: color.NRGBA{R: 240, G: 220, B: 60, A: 255}},
+	{Name: "poisoned", Short: "Po", Color: color.NRGBA{R: 90, G: 180, B: 60, A: 255}},
+	{Name: "prone", Short: "Pr", Color: color.NRGBA{R: 200, G: 120, B: 60, A: 255}},
+	{Name: "restrained", Short: "Re", Color: color.NRGBA{R: 120, G: 120, B: 160, A: 255}},
+	{Name: "stunned", Short: "St", Color: color.NRGBA{R: 250, G: 160, B: 40, A: 255}},
+	{Name: "unconscious", Short: "Un", Color: color.NRGBA{R: 40, G: 40, B: 80, A: 255}},
+}
+
+// FindTeam returns the team with the given name
+func FindTeam(name string) (Team, bool) {
+	for _, team := range Teams {
+		if team.Name == name {
+			return team, true
+		}
+	}
+
+	return Team{}, false
+}
+
+// FindCondition returns the condition with the given name
+func FindCondition(name string) (Condition, bool) {
+	for _, condition := range Conditions {
+		if condition.Name == name {
+			return condition, true
+		}
+	}
+
+	return Condition{}, false
+}
+
+// HasCondition returns true if the token is marked with the condition
+func HasCondition(token mapFile.Token, name string) bool {
+	for _, condition := range token.Conditions {
+		if condition == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ToggleCondition marks the token with a condition, or takes the mark off when it already has it.
+// Conditions stay in the order they were added, so markers don't jump around as others come and go.
+func ToggleCondition(token *mapFile.Token, name string) {
+	for i, condition := range token.Conditions {
+		if condition == name {
+			token.Conditions = append(token.Conditions[:i:i], token.Conditions[i+1:]...)
+			return
+		}
+	}
+
+	token.Conditions = append(token.Conditions, name)
+}
+
+// AdjustHP heals or damages a token, keeping its hit points between 0 and its maximum
+func AdjustHP(token *mapFile.Token, change int) {
+	token.HP = clampHP(token.HP+change, token.MaxHP)
+}
+
+// SetMaxHP changes a token's maximum hit points, a token without any yet starts at full health
+func SetMaxHP(token *mapFile.Token, max int) {
+	if max < 0 {
+		max = 0
+	}
+	if token.MaxHP == 0 {
+		token.HP = max
+	}
+
+	token.MaxHP = max
+	token.HP = clampHP(token.HP, max)
+}
+
+// HealthFraction returns how much of its hit points a token has left, false when it has no hit points to track
+func HealthFraction(token mapFile.Token) (float32, bool) {
+	if token.MaxHP <= 0 {
+		return 0, false
+	}
+
+	return float32(clampHP(token.HP, token.MaxHP)) / float32(token.MaxHP), true
+}
+
+func clampHP(hp int, max int) int {
+	if hp > max {
+		hp = max
+	}
+	if hp < 0 {
+		hp = 0
+	}
+
+	return hp
+}
@@ -0,0 +1,64 @@
+package token
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+func TestToggleConditionKeepsOrder(t *testing.T) {
+	var goblin mapFile.Token
+
+	ToggleCondition(&goblin, "prone")
+	ToggleCondition(&goblin, "poisoned")
+	ToggleCondition(&goblin, "stunned")
+	ToggleCondition(&goblin, "poisoned")
+
+	if !reflect.DeepEqual(goblin.Conditions, []string{"prone", "stunned"}) {
+		t.Errorf("unexpected conditions %v", goblin.Conditions)
+	}
+	if !HasCondition(goblin, "stunned") || HasCondition(goblin, "poisoned") {
+		t.Error("expected the goblin to be stunned and no longer poisoned")
+	}
+}
+
+func TestHitPoints(t *testing.T) {
+	var ogre mapFile.Token
+	if _, tracked := HealthFraction(ogre); tracked {
+		t.Error("expected no hit points until a maximum is set")
+	}
+
+	SetMaxHP(&ogre, 60)
+	if ogre.HP != 60 {
+		t.Errorf("expected a new token to start at full health, got %d", ogre.HP)
+	}
+
+	AdjustHP(&ogre, -45)
+	if fraction, _ := HealthFraction(ogre); fraction != 0.25 {
+		t.Errorf("expected a quarter of its hit points left, got %.2f", fraction)
+	}
+
+	AdjustHP(&ogre, -100)
+	AdjustHP(&ogre, 5)
+	if ogre.HP != 5 {
+		t.Errorf("expected damage to stop at 0 before healing, got %d", ogre.HP)
+	}
+
+	SetMaxHP(&ogre, 3)
+	if ogre.HP != 3 {
+		t.Errorf("expected hit points to be lowered to a smaller maximum, got %d", ogre.HP)
+	}
+}
+
+func TestTeamsAndConditionsAreFound(t *testing.T) {
+	if team, found := FindTeam("enemy"); !found || team.Color.R != 220 {
+		t.Errorf("unexpected team %+v", team)
+	}
+	if _, found := FindTeam("pirates"); found {
+		t.Error("expected an unknown team not to be found")
+	}
+	if condition, found := FindCondition("concentrating"); !found || condition.Short != "Co" {
+		t.Errorf("unexpected condition %+v", condition)
+	}
+}
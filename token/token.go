@@ -0,0 +1,161 @@
+package token
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+// Size is how much of the grid a creature takes up, as stored in map settings
+type Size string
+
+const (
+	Tiny        Size = "tiny"
+	Small       Size = "small"
+	Medium      Size = "medium"
+	Large       Size = "large"
+	Huge        Size = "huge"
+	Gargantuan  Size = "gargantuan"
+	DefaultSize Size = Medium
+)
+
+// Sizes lists the creature sizes from smallest to largest
+var Sizes = []Size{Tiny, Small, Medium, Large, Huge, Gargantuan}
+
+// ImageExtensions are the file types read from the token folder
+var ImageExtensions = []string{"png", "jpg", "jpeg", "svg"}
+
+// ParseSize returns the size with the given name, falling back to DefaultSize
+func ParseSize(name string) Size {
+	for _, size := range Sizes {
+		if string(size) == name {
+			return size
+		}
+	}
+
+	return DefaultSize
+}
+
+// Cells returns how many grid cells across a creature of this size takes up
+func (size Size) Cells() float32 {
+	switch size {
+	case Tiny:
+		return 0.5
+	case Large:
+		return 2
+	case Huge:
+		return 3
+	case Gargantuan:
+		return 4
+	}
+
+	return 1
+}
+
+// Diameter returns how wide a token is for grid cells of the given size
+func Diameter(token mapFile.Token, cellSize float32) float32 {
+	return ParseSize(token.Size).Cells() * cellSize
+}
+
+// Snap returns where a token of the given size dropped at position comes to rest. Tokens an odd number of
+// cells across sit in the middle of a cell, even ones on the corner between cells. Hex tokens always sit in a hex.
+func Snap(g grid.Grid, position fyne.Position, size Size) fyne.Position {
+	cell := g.CellAt(position)
+	cells := size.Cells()
+	if g.Kind() != grid.Square || cells < 2 || int(cells)%2 == 1 {
+		return g.Center(cell)
+	}
+
+	nearest := position
+	distance := float32(-1)
+	for _, corner := range g.Outline(cell) {
+		if cornerDistance := geometry.Distance(corner, position); distance < 0 || cornerDistance < distance {
+			nearest, distance = corner, cornerDistance
+		}
+	}
+
+	return nearest
+}
+
+// At returns the index of the topmost token covering a map position, -1 when there is none
+func At(tokens []mapFile.Token, position fyne.Position, cellSize float32) int {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if geometry.Distance(tokens[i].Position, position) <= Diameter(tokens[i], cellSize)/2 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Viewers returns the positions of the tokens that line of sight follows
+func Viewers(tokens []mapFile.Token) []fyne.Position {
+	var viewers []fyne.Position
+	for _, token := range tokens {
+		if token.Sees {
+			viewers = append(viewers, token.Position)
+		}
+	}
+
+	return viewers
+}
+
+// Image is a picture in the token folder that tokens can be made from
+type Image struct {
+	// Name is the file name without its extension, used to name new tokens
+	Name string
+	// File is the path relative to the token folder
+	File string
+}
+
+// LoadImages lists the pictures in the token folder and its subfolders, sorted by name
+func LoadImages(directory string) ([]Image, error) {
+	var images []Image
+	if err := loadImagesIn(directory, "", &images); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		return strings.ToLower(images[i].Name) < strings.ToLower(images[j].Name)
+	})
+
+	return images, nil
+}
+
+func loadImagesIn(directory string, folder string, images *[]Image) error {
+	files, err := ioutil.ReadDir(filepath.Join(directory, folder))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		relativePath := filepath.Join(folder, file.Name())
+		if file.IsDir() {
+			if err = loadImagesIn(directory, relativePath, images); err != nil {
+				return err
+			}
+			continue
+		}
+
+		extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Name()), "."))
+		for _, imageExtension := range ImageExtensions {
+			if extension == imageExtension {
+				*images = append(*images, Image{Name: strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())), File: relativePath})
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/grid"
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+func TestSnapToCellsAndCorners(t *testing.T) {
+	square := grid.New(grid.Square, fyne.NewPos(0, 0), 100, 100, 0)
+
+	if snapped := Snap(square, fyne.NewPos(130, 260), Medium); snapped != fyne.NewPos(150, 250) {
+		t.Errorf("expected a medium token in the middle of its cell, got %v", snapped)
+	}
+	if snapped := Snap(square, fyne.NewPos(130, 260), Large); snapped != fyne.NewPos(100, 300) {
+		t.Errorf("expected a large token on the nearest corner, got %v", snapped)
+	}
+	if snapped := Snap(square, fyne.NewPos(130, 260), Huge); snapped != fyne.NewPos(150, 250) {
+		t.Errorf("expected a huge token in the middle of a cell, got %v", snapped)
+	}
+	if snapped := Snap(square, fyne.NewPos(170, 240), Gargantuan); snapped != fyne.NewPos(200, 200) {
+		t.Errorf("expected a gargantuan token on the nearest corner, got %v", snapped)
+	}
+
+	hex := grid.New(grid.HexFlatTop, fyne.NewPos(0, 0), 100, 100, 0)
+	position := fyne.NewPos(130, 260)
+	if snapped := Snap(hex, position, Large); snapped != hex.Center(hex.CellAt(position)) {
+		t.Errorf("expected a hex token in the middle of its hex, got %v", snapped)
+	}
+}
+
+func TestSizes(t *testing.T) {
+	if ParseSize("huge") != Huge || ParseSize("") != Medium || ParseSize("enormous") != Medium {
+		t.Error("unexpected parsed sizes")
+	}
+
+	token := mapFile.Token{Size: string(Gargantuan)}
+	if diameter := Diameter(token, 70); diameter != 280 {
+		t.Errorf("expected a gargantuan token 4 cells across, got %.0f", diameter)
+	}
+	if diameter := Diameter(mapFile.Token{Size: string(Tiny)}, 70); diameter != 35 {
+		t.Errorf("expected a tiny token half a cell across, got %.0f", diameter)
+	}
+}
+
+func TestAtFindsTopmostToken(t *testing.T) {
+	tokens := []mapFile.Token{
+		{Name: "ogre", Position: fyne.NewPos(100, 100), Size: string(Large)},
+		{Name: "goblin", Position: fyne.NewPos(120, 100), Sees: true},
+	}
+
+	if found := At(tokens, fyne.NewPos(125, 100), 50); found != 1 {
+		t.Errorf("expected the goblin drawn on top, got %d", found)
+	}
+	if found := At(tokens, fyne.NewPos(60, 100), 50); found != 0 {
+		t.Errorf("expected the ogre, got %d", found)
+	}
+	if found := At(tokens, fyne.NewPos(300, 300), 50); found != -1 {
+		t.Errorf("expected no token, got %d", found)
+	}
+
+	if viewers := Viewers(tokens); len(viewers) != 1 || viewers[0] != tokens[1].Position {
+		t.Errorf("expected only the goblin to see, got %v", viewers)
+	}
+}
+
+func TestLoadImages(t *testing.T) {
+	directory := t.TempDir()
+	for _, name := range []string{"Orc.png", "monsters/Dragon.svg", "notes.txt", ".hidden/Ghost.png"} {
+		path := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	images, err := LoadImages(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 || images[0].Name != "Dragon" || images[0].File != filepath.Join("monsters", "Dragon.svg") || images[1].Name != "Orc" {
+		t.Errorf("unexpected token images %+v", images)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/token"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const RadialItemWidth float32 = 110
+const RadialItemHeight float32 = 44
+
+// MinRadialMenuRadius is the distance from the middle of a radial menu to its buttons when there are only a few
+const MinRadialMenuRadius float32 = 120
+
+// RadialItem is one button of a radial menu, Selected highlights it
+type RadialItem struct {
+	Label    string
+	Selected bool
+	OnTapped func()
+}
+
+// BuildRadialMenu lays the items out in a circle around center with the middle button in the middle,
+// moving the circle in from the edges of the tool screen so every button can be reached
+func BuildRadialMenu(center fyne.Position, items []RadialItem, middle RadialItem) *fyne.Container {
+	radius := fyne.Max(MinRadialMenuRadius, float32(len(items))*(RadialItemWidth+10)/(2*math.Pi))
+	marginX, marginY := radius+RadialItemWidth/2+10, radius+RadialItemHeight/2+10
+	screen := ToolScreenSize()
+	center.X = fyne.Min(fyne.Max(center.X, marginX), screen.Width-marginX)
+	center.Y = fyne.Min(fyne.Max(center.Y, marginY), screen.Height-marginY)
+
+	place := func(item RadialItem, position fyne.Position) fyne.CanvasObject {
+		button := widget.NewButton(item.Label, item.OnTapped)
+		if item.Selected {
+			button.Importance = widget.HighImportance
+		}
+		button.Resize(fyne.NewSize(RadialItemWidth, RadialItemHeight))
+		button.Move(position.Subtract(fyne.NewPos(RadialItemWidth/2, RadialItemHeight/2)))
+
+		return button
+	}
+
+	menu := container.NewWithoutLayout()
+	for i, item := range items {
+		angle := 2*math.Pi*float64(i)/float64(len(items)) - math.Pi/2
+		menu.Add(place(item, center.Add(fyne.NewPos(radius*float32(math.Cos(angle)), radius*float32(math.Sin(angle))))))
+	}
+	menu.Add(place(middle, center))
+
+	return menu
+}
+
+// TokenMenu is the radial menu opened by a long press on a token, setting its team ring, hit points and conditions
+type TokenMenu struct {
+	Token   int
+	OnClose func()
+	Content *fyne.Container
+
+	center fyne.Position
+}
+
+// NewTokenMenu opens the menu for a token around a screen position
+func NewTokenMenu(index int, center fyne.Position, onClose func()) *TokenMenu {
+	menu := &TokenMenu{Token: index, OnClose: onClose, Content: container.NewWithoutLayout(), center: center}
+	menu.showMain()
+
+	return menu
+}
+
+// Touches returns true if a screen position is on one of the menu's buttons
+func (menu *TokenMenu) Touches(position fyne.Position) bool {
+	for _, object := range menu.page() {
+		if touchesNestedObject(object, position) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (menu *TokenMenu) page() []fyne.CanvasObject {
+	if len(menu.Content.Objects) == 0 {
+		return nil
+	}
+
+	return menu.Content.Objects[0].(*fyne.Container).Objects
+}
+
+func (menu *TokenMenu) show(items []RadialItem, middle RadialItem) {
+	menu.Content.Objects = []fyne.CanvasObject{BuildRadialMenu(menu.center, items, middle)}
+	menu.Content.Refresh()
+}
+
+// token returns the token the menu is for, false once it has been removed
+func (menu *TokenMenu) token() (mapFile.Token, bool) {
+	if menu.Token < 0 || menu.Token >= len(TableView.Tokens) {
+		return mapFile.Token{}, false
+	}
+
+	return TableView.Tokens[menu.Token], true
+}
+
+// update changes the token the menu is for and saves it
+func (menu *TokenMenu) update(change func(placed *mapFile.Token)) {
+	if _, found := menu.token(); !found {
+		return
+	}
+
+	tokens := append([]mapFile.Token(nil), TableView.Tokens...)
+	change(&tokens[menu.Token])
+	SetTokens(tokens)
+	SaveTokens()
+}
+
+func (menu *TokenMenu) close() {
+	if menu.OnClose != nil {
+		menu.OnClose()
+	}
+}
+
+func (menu *TokenMenu) showMain() {
+	placed, found := menu.token()
+	if !found {
+		menu.close()
+		return
+	}
+
+	showHP := "Show HP"
+	if placed.ShowHP {
+		showHP = "Hide HP"
+	}
+
+	menu.show([]RadialItem{
+		{Label: "Ring", Selected: placed.Team != "", OnTapped: menu.showTeams},
+		{Label: "HP", Selected: placed.MaxHP > 0, OnTapped: menu.showHP},
+		{Label: "Conditions", Selected: len(placed.Conditions) > 0, OnTapped: menu.showConditions},
+		{Label: showHP, Selected: placed.ShowHP, OnTapped: func() {
+			menu.update(func(placed *mapFile.Token) { placed.ShowHP = !placed.ShowHP })
+			menu.showMain()
+		}},
+	}, RadialItem{Label: "Close", OnTapped: menu.close})
+}
+
+func (menu *TokenMenu) showTeams() {
+	placed, _ := menu.token()
+
+	setTeam := func(name string) func() {
+		return func() {
+			menu.update(func(placed *mapFile.Token) { placed.Team = name })
+			menu.showMain()
+		}
+	}
+
+	items := []RadialItem{{Label: "None", Selected: placed.Team == "", OnTapped: setTeam("")}}
+	for _, team := range token.Teams {
+		items = append(items, RadialItem{Label: titleCase(team.Name), Selected: placed.Team == team.Name, OnTapped: setTeam(team.Name)})
+	}
+
+	menu.show(items, RadialItem{Label: "Back", OnTapped: menu.showMain})
+}
+
+func (menu *TokenMenu) showHP() {
+	placed, _ := menu.token()
+
+	adjust := func(change int) func() {
+		return func() {
+			menu.update(func(placed *mapFile.Token) { token.AdjustHP(placed, change) })
+			menu.showHP()
+		}
+	}
+	adjustMax := func(change int) func() {
+		return func() {
+			menu.update(func(placed *mapFile.Token) { token.SetMaxHP(placed, placed.MaxHP+change) })
+			menu.showHP()
+		}
+	}
+
+	menu.show([]RadialItem{
+		{Label: "+1", OnTapped: adjust(1)},
+		{Label: "+5", OnTapped: adjust(5)},
+		{Label: "+10", OnTapped: adjust(10)},
+		{Label: "Max +5", OnTapped: adjustMax(5)},
+		{Label: "Max -5", OnTapped: adjustMax(-5)},
+		{Label: "-10", OnTapped: adjust(-10)},
+		{Label: "-5", OnTapped: adjust(-5)},
+		{Label: "-1", OnTapped: adjust(-1)},
+	}, RadialItem{Label: fmt.Sprintf("%d / %d", placed.HP, placed.MaxHP), OnTapped: menu.showMain})
+}
+
+func (menu *TokenMenu) showConditions() {
+	placed, _ := menu.token()
+
+	var items []RadialItem
+	for _, condition := range token.Conditions {
+		name := condition.Name
+		items = append(items, RadialItem{Label: titleCase(name), Selected: token.HasCondition(placed, name), OnTapped: func() {
+			menu.update(func(placed *mapFile.Token) { token.ToggleCondition(placed, name) })
+			menu.showConditions()
+		}})
+	}
+
+	menu.show(items, RadialItem{Label: "Back", OnTapped: menu.showMain})
+}
+
+// titleCase capitalizes the first letter of a name for a button
+func titleCase(name string) string {
+	if name == "" {
+		return name
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// TokenMenuTool keeps the table still while a token menu opened by a long press on the map is open.
+// Touching anywhere but the menu closes it.
+type TokenMenuTool struct {
+	Menu         *TokenMenu
+	OnDeactivate func()
+}
+
+// HandleTouch closes the menu when the table is touched away from it
+func (tool *TokenMenuTool) HandleTouch(event touch.Event) {
+	if event.Status == touch.InitialTouch && !tool.Menu.Touches(fyne.NewPos(event.X, event.Y)) {
+		SetActiveTool(nil)
+	}
+}
+
+// Deactivate closes the menu
+func (tool *TokenMenuTool) Deactivate() {
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// ShowTokenMenu opens the menu of the token under a screen position, returning false when there is no token there
+func ShowTokenMenu(position fyne.Position) bool {
+	index := ToolView().TokenAt(position)
+	if index < 0 {
+		return false
+	}
+
+	tool := &TokenMenuTool{}
+	tool.Menu = NewTokenMenu(index, position, func() {
+		SetActiveTool(nil)
+	})
+	AddToolOverlay(tool.Menu.Content)
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(tool.Menu.Content)
+	}
+	SetActiveTool(tool)
+
+	return true
+}
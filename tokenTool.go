@@ -0,0 +1,506 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/token"
+	"github.com/JonCSykes/DragonTable/touch"
+)
+
+const TokenPanelWidth float32 = 320
+const TokenPanelHeight float32 = 640
+const TokenDrawerItemSize float32 = 90
+
+// TokenFolder is the folder in the resources the token pictures are read from
+const TokenFolder string = "tokens"
+
+var TokenOverlay *fyne.Container
+
+var tokenSelectedColor = color.NRGBA{R: 255, G: 200, B: 60, A: 255}
+
+// tokenDrawerItem is a picture in the drawer that new tokens are dragged from
+type tokenDrawerItem struct {
+	image  token.Image
+	object fyne.CanvasObject
+}
+
+// tokenDrawerPicture is a picture in the drawer that passes the pointer dragging it on to the tools,
+// where the drawer would otherwise scroll
+type tokenDrawerPicture struct {
+	widget.BaseWidget
+
+	content fyne.CanvasObject
+}
+
+func newTokenDrawerPicture(content fyne.CanvasObject) *tokenDrawerPicture {
+	picture := &tokenDrawerPicture{content: content}
+	picture.ExtendBaseWidget(picture)
+
+	return picture
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (picture *tokenDrawerPicture) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(picture.content)
+}
+
+// Dragged drags a new token out of the drawer
+func (picture *tokenDrawerPicture) Dragged(event *fyne.DragEvent) {
+	if surface := toolPointer(); surface != nil {
+		surface.Dragged(event)
+	}
+}
+
+// DragEnd drops the new token
+func (picture *tokenDrawerPicture) DragEnd() {
+	if surface := toolPointer(); surface != nil {
+		surface.DragEnd()
+	}
+}
+
+// tokenDrag is a finger on the table, dragging a new token out of the drawer, moving a token or about to tap
+type tokenDrag struct {
+	token int
+	image *token.Image
+	ghost *canvas.Image
+	start fyne.Position
+	moved bool
+	// down is when the finger was put on the token, pressed is set once it rested there long enough to open the menu
+	down    time.Time
+	pressed bool
+}
+
+// TokenTool places creature tokens dragged out of the drawer and moves the tokens on the map, snapping them to the grid.
+// Dragging a token back onto the panel removes it, tapping one selects it so its size can be changed and a long press
+// opens its menu.
+type TokenTool struct {
+	Size         token.Size
+	Sees         bool
+	OnDeactivate func()
+
+	panel      fyne.CanvasObject
+	drawer     fyne.CanvasObject
+	items      []tokenDrawerItem
+	preview    *fyne.Container
+	drags      map[int]*tokenDrag
+	selected   int
+	sizeSelect *widget.Select
+	seesCheck  *widget.Check
+	selecting  bool
+	overlay    *fyne.Container
+	menu       *TokenMenu
+	menuMutex  sync.Mutex
+}
+
+// NewTokenTool starts placing medium tokens on the map on the table
+func NewTokenTool(onDeactivate func()) *TokenTool {
+	return &TokenTool{
+		Size:         token.DefaultSize,
+		OnDeactivate: onDeactivate,
+		preview:      container.NewWithoutLayout(),
+		drags:        make(map[int]*tokenDrag),
+		selected:     -1,
+	}
+}
+
+// HandleTouch drags tokens out of the drawer and around the map
+func (tool *TokenTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+
+	if menu := tool.openMenu(); menu != nil {
+		// while a menu is open the table only closes it
+		delete(tool.drags, event.ID)
+		if event.Status == touch.InitialTouch && !menu.Touches(position) {
+			tool.closeMenu()
+		}
+		return
+	}
+
+	drag, dragging := tool.drags[event.ID]
+	switch event.Status {
+	case touch.InitialTouch:
+		if touchesObject(tool.panel, position) {
+			if item := tool.drawerItemAt(position); item >= 0 {
+				tool.drags[event.ID] = &tokenDrag{token: -1, image: &tool.items[item].image, start: position}
+			}
+			return
+		}
+
+		tool.drags[event.ID] = &tokenDrag{token: ToolView().TokenAt(position), start: position, down: event.Time}
+	case touch.StreamTouch:
+		if !dragging {
+			return
+		}
+		if geometry.Distance(drag.start, position) > VisionTapDistance {
+			drag.moved = true
+		}
+		if !drag.moved {
+			return
+		}
+
+		if drag.image != nil {
+			tool.moveGhost(drag, position)
+		} else if drag.token >= 0 && drag.token < len(TableView.Tokens) {
+			tokens := append([]mapFile.Token(nil), TableView.Tokens...)
+			tokens[drag.token].Position = clampToMap(ToolView().ScreenToMap(position))
+			SetTokens(tokens)
+			tool.showSelection()
+		}
+	case touch.UnTouch:
+		if !dragging {
+			return
+		}
+		delete(tool.drags, event.ID)
+		if drag.pressed {
+			// the long press opened the menu
+			return
+		}
+
+		if drag.image != nil {
+			tool.drop(drag, position)
+		} else if drag.moved && drag.token >= 0 {
+			tool.place(drag.token, position)
+		} else if !drag.moved {
+			tool.Select(drag.token)
+		}
+	}
+}
+
+// Tick opens the menu of a token a finger has rested on long enough
+func (tool *TokenTool) Tick(now time.Time) {
+	if tool.openMenu() != nil {
+		return
+	}
+
+	for _, drag := range tool.drags {
+		if drag.token >= 0 && !drag.moved && !drag.pressed && now.Sub(drag.down) >= touch.DefaultLongPressDuration {
+			drag.pressed = true
+			tool.showMenu(drag.token, drag.start)
+			return
+		}
+	}
+}
+
+// drop adds a token dragged out of the drawer where it was let go, unless that is back on the panel
+func (tool *TokenTool) drop(drag *tokenDrag, position fyne.Position) {
+	if drag.ghost != nil {
+		tool.preview.Remove(drag.ghost)
+	}
+	if !drag.moved || touchesObject(tool.panel, position) {
+		return
+	}
+
+	placed := mapFile.Token{
+		Name:     drag.image.Name,
+		Image:    drag.image.File,
+		Position: token.Snap(TableView.Grid(), clampToMap(ToolView().ScreenToMap(position)), tool.Size),
+		Size:     string(tool.Size),
+		Sees:     tool.Sees,
+	}
+	SetTokens(append(append([]mapFile.Token(nil), TableView.Tokens...), placed))
+	SaveTokens()
+
+	tool.Select(len(TableView.Tokens) - 1)
+}
+
+// place snaps a token that was moved to the grid, or removes it when it was dragged onto the panel
+func (tool *TokenTool) place(index int, position fyne.Position) {
+	if index >= len(TableView.Tokens) {
+		return
+	}
+
+	if touchesObject(tool.panel, position) {
+		tool.Remove(index)
+		return
+	}
+
+	tokens := append([]mapFile.Token(nil), TableView.Tokens...)
+	tokens[index].Position = token.Snap(TableView.Grid(), tokens[index].Position, token.ParseSize(tokens[index].Size))
+	SetTokens(tokens)
+	SaveTokens()
+
+	tool.Select(index)
+}
+
+func (tool *TokenTool) moveGhost(drag *tokenDrag, position fyne.Position) {
+	diameter := tool.Size.Cells() * TableView.Grid().CellSize() * float32(ToolView().Zoom())
+
+	if drag.ghost == nil {
+		drag.ghost = canvas.NewImageFromFile(filepath.Join(TableView.TokenDir, drag.image.File))
+		drag.ghost.FillMode = canvas.ImageFillContain
+		drag.ghost.Translucency = 0.3
+		tool.preview.Add(drag.ghost)
+	}
+
+	drag.ghost.Resize(fyne.NewSize(diameter, diameter))
+	drag.ghost.Move(position.Subtract(fyne.NewPos(diameter/2, diameter/2)))
+}
+
+// Select makes a token the one the size and sight settings apply to, -1 selects nothing
+func (tool *TokenTool) Select(index int) {
+	if index >= len(TableView.Tokens) {
+		index = -1
+	}
+	tool.selected = index
+
+	if index >= 0 && tool.sizeSelect != nil {
+		// show the token's settings without applying them back to it
+		tool.selecting = true
+		tool.sizeSelect.SetSelected(string(token.ParseSize(TableView.Tokens[index].Size)))
+		tool.seesCheck.SetChecked(TableView.Tokens[index].Sees)
+		tool.selecting = false
+	}
+
+	tool.showSelection()
+}
+
+// SetSize changes the size of new tokens and of the selected token, snapping it again
+func (tool *TokenTool) SetSize(size token.Size) {
+	tool.Size = size
+	if tool.selecting || tool.selected < 0 || tool.selected >= len(TableView.Tokens) {
+		return
+	}
+
+	tokens := append([]mapFile.Token(nil), TableView.Tokens...)
+	tokens[tool.selected].Size = string(size)
+	tokens[tool.selected].Position = token.Snap(TableView.Grid(), tokens[tool.selected].Position, size)
+	SetTokens(tokens)
+	SaveTokens()
+	tool.showSelection()
+}
+
+// SetSees makes line of sight follow new tokens and the selected token, or not
+func (tool *TokenTool) SetSees(sees bool) {
+	tool.Sees = sees
+	if tool.selecting || tool.selected < 0 || tool.selected >= len(TableView.Tokens) {
+		return
+	}
+
+	tokens := append([]mapFile.Token(nil), TableView.Tokens...)
+	tokens[tool.selected].Sees = sees
+	SetTokens(tokens)
+	SaveTokens()
+}
+
+// Remove takes a token off the map
+func (tool *TokenTool) Remove(index int) {
+	if index < 0 || index >= len(TableView.Tokens) {
+		return
+	}
+
+	tokens := append([]mapFile.Token(nil), TableView.Tokens[:index]...)
+	SetTokens(append(tokens, TableView.Tokens[index+1:]...))
+	SaveTokens()
+
+	tool.Select(-1)
+}
+
+// showSelection rings the selected token
+func (tool *TokenTool) showSelection() {
+	var objects []fyne.CanvasObject
+	for _, object := range tool.preview.Objects {
+		if _, ghost := object.(*canvas.Image); ghost {
+			objects = append(objects, object)
+		}
+	}
+
+	if tool.selected >= 0 && tool.selected < len(TableView.Tokens) {
+		selected := TableView.Tokens[tool.selected]
+		radius := token.Diameter(selected, TableView.Grid().CellSize()) * float32(ToolView().Zoom()) / 2
+
+		ring := canvas.NewCircle(color.Transparent)
+		ring.StrokeColor = tokenSelectedColor
+		ring.StrokeWidth = 4
+		ring.Move(ToolView().MapToScreen(selected.Position).Subtract(fyne.NewPos(radius, radius)))
+		ring.Resize(fyne.NewSize(radius*2, radius*2))
+		objects = append(objects, ring)
+	}
+
+	tool.preview.Objects = objects
+	tool.preview.Refresh()
+}
+
+// showMenu opens the radial menu of a token
+func (tool *TokenTool) showMenu(index int, position fyne.Position) {
+	tool.closeMenu()
+	tool.Select(index)
+
+	menu := NewTokenMenu(index, position, tool.closeMenu)
+	tool.menuMutex.Lock()
+	tool.menu = menu
+	tool.menuMutex.Unlock()
+
+	tool.overlay.Add(menu.Content)
+}
+
+func (tool *TokenTool) openMenu() *TokenMenu {
+	tool.menuMutex.Lock()
+	defer tool.menuMutex.Unlock()
+
+	return tool.menu
+}
+
+func (tool *TokenTool) closeMenu() {
+	tool.menuMutex.Lock()
+	menu := tool.menu
+	tool.menu = nil
+	tool.menuMutex.Unlock()
+
+	if menu != nil {
+		tool.overlay.Remove(menu.Content)
+		tool.showSelection()
+	}
+}
+
+// drawerItemAt returns the index of the drawer picture under a screen position, -1 when there is none
+func (tool *TokenTool) drawerItemAt(position fyne.Position) int {
+	if !touchesNestedObject(tool.drawer, position) {
+		return -1
+	}
+
+	for i, item := range tool.items {
+		if touchesNestedObject(item.object, position) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Deactivate closes the token drawer
+func (tool *TokenTool) Deactivate() {
+	tool.closeMenu()
+	tool.drags = make(map[int]*tokenDrag)
+	tool.preview.Objects = nil
+	tool.preview.Refresh()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// SetTokens shows the tokens on the table and the GM screen, and lets the tokens that see reveal the map
+func SetTokens(tokens []mapFile.Token) {
+	TableView.SetTokens(tokens)
+
+	if GMView != nil {
+		GMView.SetTokens(tokens)
+	}
+
+	if TableView.Vision != nil {
+		TableView.Vision.SetTokenViewers(token.Viewers(tokens))
+		RedrawVision()
+	}
+}
+
+// SaveTokens stores the tokens next to the map on the table
+func SaveTokens() {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	file.Metadata.Tokens = TableView.Tokens
+	if TableView.Vision != nil {
+		// tokens that see explore the map as they move
+		SaveVision()
+		return
+	}
+
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// ShowTokenTool opens the token drawer for the map on the table
+func ShowTokenTool(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to place tokens on")
+		return false
+	}
+
+	tool := NewTokenTool(nil)
+	TokenOverlay = BuildTokenOverlay(tool)
+	AddToolOverlay(TokenOverlay)
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(TokenOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildTokenOverlay(tool *TokenTool) *fyne.Container {
+
+	images, imagesError := token.LoadImages(TableView.TokenDir)
+	if imagesError != nil {
+		fmt.Println(imagesError)
+	}
+
+	var drawerItems []fyne.CanvasObject
+	for _, tokenImage := range images {
+		picture := canvas.NewImageFromFile(filepath.Join(TableView.TokenDir, tokenImage.File))
+		picture.FillMode = canvas.ImageFillContain
+		name := widget.NewLabelWithStyle(tokenImage.Name, fyne.TextAlignCenter, fyne.TextStyle{})
+		name.Wrapping = fyne.TextTruncate
+
+		item := newTokenDrawerPicture(container.NewBorder(nil, name, nil, nil, picture))
+		tool.items = append(tool.items, tokenDrawerItem{image: tokenImage, object: item})
+		drawerItems = append(drawerItems, item)
+	}
+	drawer := container.NewVScroll(container.NewGridWrap(fyne.NewSize(TokenDrawerItemSize, TokenDrawerItemSize), drawerItems...))
+	tool.drawer = drawer
+
+	var sizeNames []string
+	for _, size := range token.Sizes {
+		sizeNames = append(sizeNames, string(size))
+	}
+	tool.sizeSelect = widget.NewSelect(sizeNames, func(name string) {
+		tool.SetSize(token.ParseSize(name))
+	})
+	tool.sizeSelect.SetSelected(string(tool.Size))
+
+	tool.seesCheck = widget.NewCheck("Sees (line of sight)", tool.SetSees)
+
+	removeButton := widget.NewButton("Remove", func() {
+		tool.Remove(tool.selected)
+	})
+
+	doneButton := widget.NewButton("Done", func() {
+		SetActiveTool(nil)
+	})
+	doneButton.Importance = widget.HighImportance
+
+	panel := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("Drag a token onto the map, drag tokens\nto move them or back here to remove them.\nTap a token to change its size, press and\nhold it for its ring, hit points and\nconditions."),
+			tool.sizeSelect,
+			tool.seesCheck,
+		),
+		container.NewGridWithColumns(2, removeButton, doneButton),
+		nil, nil,
+		drawer,
+	)
+	panel.Resize(fyne.NewSize(TokenPanelWidth, TokenPanelHeight))
+	panel.Move(ToolPanelPosition(TokenPanelHeight))
+	tool.panel = panel
+
+	tool.overlay = container.NewWithoutLayout(tool.preview, panel)
+
+	return tool.overlay
+}
@@ -0,0 +1,123 @@
+//go:build elo
+// +build elo
+
+package touch
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/..
+#cgo LDFLAGS: -L${SRCDIR}/.. -lEloMtApi
+#include <EloInterface.h>
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// EloRetryInterval is how long to wait before polling again when the driver has no packet
+const EloRetryInterval time.Duration = 5 * time.Millisecond
+
+// EloSource reads touch packets from an Elo touchscreen through the Elo multi-touch SDK
+type EloSource struct {
+	ScreenIndex int
+
+	events  chan Event
+	mutex   sync.Mutex
+	running bool
+}
+
+// NewEloSource creates a source for the Elo screen with the given index
+func NewEloSource(screenIndex int) (TouchSource, error) {
+	if int(C.EloGetScreenCount()) <= screenIndex {
+		return nil, errors.New("no Elo touchscreen found")
+	}
+
+	return &EloSource{ScreenIndex: screenIndex, events: make(chan Event, EventBufferSize)}, nil
+}
+
+// Start begins polling the Elo driver for touch packets
+func (source *EloSource) Start() error {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.running {
+		return nil
+	}
+	source.running = true
+
+	go source.poll()
+
+	return nil
+}
+
+// Stop ends polling once the pending packet has been read
+func (source *EloSource) Stop() {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.running = false
+}
+
+// Events returns the channel touch events are delivered on
+func (source *EloSource) Events() <-chan Event {
+	return source.events
+}
+
+func (source *EloSource) isRunning() bool {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	return source.running
+}
+
+// poll reads multi-touch packets so every finger is reported with its own id
+func (source *EloSource) poll() {
+
+	var multiTouch C.MT_TOUCH
+
+	for source.isRunning() {
+		if !C.EloGetMultiTouch(C.int(source.ScreenIndex), &multiTouch) {
+			time.Sleep(EloRetryInterval)
+			continue
+		}
+
+		now := time.Now()
+		for i := 0; i < int(multiTouch.count) && i < len(multiTouch.touch); i++ {
+			finger := multiTouch.touch[i]
+			source.events <- Event{ID: int(finger.id), X: float32(finger.x), Y: float32(finger.y), Status: Status(finger.status), Time: now}
+		}
+	}
+}
+
+// SetClipRectangles asks the controller to ignore touches inside the given rectangles
+func (source *EloSource) SetClipRectangles(rectangles []geometry.Polygon) error {
+
+	var clipMode C.ELO_CLIPPING_MODE
+
+	if len(rectangles) > len(clipMode.Bounds) {
+		return fmt.Errorf("the Elo controller supports at most %d clipping rectangles", len(clipMode.Bounds))
+	}
+
+	clipMode.ClippingMode = C.enumVrtlBoundsClipped
+	if len(rectangles) == 0 {
+		clipMode.ClippingMode = C.enumVrtlDeskDisabled
+	}
+	clipMode.NumBounds = C.ULONG(len(rectangles))
+	clipMode.ExclusionFlag = 1
+	clipMode.MonitorNumber = C.int(source.ScreenIndex)
+
+	for i, rectangle := range rectangles {
+		min, max := rectangle.Bounds()
+		clipMode.Bounds[i] = C.ClippingBounds{X_Min: C.long(min.X), X_Max: C.long(max.X), Y_Min: C.long(min.Y), Y_Max: C.long(max.Y)}
+	}
+
+	if result := C.EloSetClipRectangles(C.int(source.ScreenIndex), &clipMode); result != 0 {
+		return fmt.Errorf("EloSetClipRectangles failed with %d", int(result))
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !elo
+// +build !elo
+
+package touch
+
+import "errors"
+
+// NewEloSource is unavailable unless the app is built with the elo tag and libEloMtApi
+func NewEloSource(screenIndex int) (TouchSource, error) {
+	return nil, errors.New("built without Elo support, rebuild with -tags elo")
+}
@@ -0,0 +1,311 @@
+//go:build linux
+// +build linux
+
+package touch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Linux input event types and codes used by multi-touch devices, see linux/input-event-codes.h
+const (
+	evSyn            uint16  = 0x00
+	evKey            uint16  = 0x01
+	evAbs            uint16  = 0x03
+	synReport        uint16  = 0x00
+	btnTouch         uint16  = 0x14a
+	absX             uint16  = 0x00
+	absY             uint16  = 0x01
+	absMtSlot        uint16  = 0x2f
+	absMtPositionX   uint16  = 0x35
+	absMtPositionY   uint16  = 0x36
+	absMtTrackingID  uint16  = 0x39
+	inputPropDirect  uint16  = 0x01
+	inputAbsInfoSize uintptr = 24
+
+	// the property and absolute axis bitmasks are big enough for INPUT_PROP_MAX and ABS_MAX
+	inputPropBytes int = 4
+	absBitBytes    int = 8
+)
+
+// an input_event starts with a struct timeval, which is 8 bytes on 32-bit ARM and 16 on 64-bit platforms,
+// followed by the 16 bit type, 16 bit code and 32 bit value
+var timevalSize = int(unsafe.Sizeof(syscall.Timeval{}))
+var inputEventSize = timevalSize + 8
+
+const EvdevDevicePattern string = "/dev/input/event*"
+
+type absInfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+type evdevSlot struct {
+	trackingID int32
+	x          int32
+	y          int32
+	dirty      bool
+	started    bool
+}
+
+// EvdevSource reads multi-touch contacts from a Linux /dev/input/event* device
+type EvdevSource struct {
+	DevicePath   string
+	ScreenWidth  int
+	ScreenHeight int
+
+	file       *os.File
+	xRange     absInfo
+	yRange     absInfo
+	multiTouch bool
+	events     chan Event
+	mutex      sync.Mutex
+	running    bool
+}
+
+// NewEvdevSource opens the given event device, or the first touch screen when path is empty. Only devices that
+// draw directly on a screen and track several contacts are picked on their own, so touchpads and tablets are passed over.
+func NewEvdevSource(path string, screenWidth int, screenHeight int) (TouchSource, error) {
+	if path != "" {
+		return openEvdev(path, screenWidth, screenHeight)
+	}
+
+	paths, _ := filepath.Glob(EvdevDevicePattern)
+	sortDevicePaths(paths)
+
+	for _, devicePath := range paths {
+		source, err := openEvdev(devicePath, screenWidth, screenHeight)
+		if err != nil {
+			continue
+		}
+		if isTouchScreen(source.file) {
+			return source, nil
+		}
+		source.file.Close()
+	}
+
+	return nil, errors.New("no evdev touch device found")
+}
+
+func openEvdev(path string, screenWidth int, screenHeight int) (*EvdevSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &EvdevSource{DevicePath: path, ScreenWidth: screenWidth, ScreenHeight: screenHeight, file: file, events: make(chan Event, EventBufferSize)}
+
+	if xRange, xErr := readAbsInfo(file, absMtPositionX); xErr == nil && xRange.Maximum > xRange.Minimum {
+		yRange, yErr := readAbsInfo(file, absMtPositionY)
+		if yErr != nil {
+			file.Close()
+			return nil, yErr
+		}
+		source.xRange, source.yRange, source.multiTouch = xRange, yRange, true
+	} else {
+		xRange, xErr = readAbsInfo(file, absX)
+		yRange, yErr := readAbsInfo(file, absY)
+		if xErr != nil || yErr != nil || xRange.Maximum <= xRange.Minimum || yRange.Maximum <= yRange.Minimum {
+			file.Close()
+			return nil, fmt.Errorf("%s is not a touch device", path)
+		}
+		source.xRange, source.yRange = xRange, yRange
+	}
+
+	return source, nil
+}
+
+// sortDevicePaths orders event devices by their number, so event10 comes after event2
+func sortDevicePaths(paths []string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		return deviceNumber(paths[i]) < deviceNumber(paths[j])
+	})
+}
+
+func deviceNumber(path string) int {
+	number, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "event"))
+	if err != nil {
+		return -1
+	}
+
+	return number
+}
+
+// isTouchScreen returns true if the device is direct input, as a touch screen is, and has multi-touch slots
+func isTouchScreen(file *os.File) bool {
+	properties, err := readBits(file, 0x09, inputPropBytes)
+	if err != nil || !hasBit(properties, inputPropDirect) {
+		return false
+	}
+
+	axes, err := readBits(file, 0x20+uintptr(evAbs), absBitBytes)
+	return err == nil && hasBit(axes, absMtSlot)
+}
+
+// readBits issues one of the EVIOCGPROP or EVIOCGBIT requests, which fill a bitmask of the given size
+func readBits(file *os.File, number uintptr, size int) ([]byte, error) {
+	bits := make([]byte, size)
+	request := uintptr(2<<30) | uintptr(size)<<16 | uintptr('E')<<8 | number
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), request, uintptr(unsafe.Pointer(&bits[0])))
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return bits, nil
+}
+
+func hasBit(bits []byte, bit uint16) bool {
+	return int(bit/8) < len(bits) && bits[bit/8]&(1<<(bit%8)) != 0
+}
+
+// readAbsInfo issues EVIOCGABS for the given axis
+func readAbsInfo(file *os.File, axis uint16) (absInfo, error) {
+	var info absInfo
+	request := uintptr(2<<30) | inputAbsInfoSize<<16 | uintptr('E')<<8 | uintptr(0x40+axis)
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), request, uintptr(unsafe.Pointer(&info)))
+	if errno != 0 {
+		return info, errno
+	}
+
+	return info, nil
+}
+
+// Start begins reading the device
+func (source *EvdevSource) Start() error {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.running {
+		return nil
+	}
+	source.running = true
+
+	go source.read()
+
+	return nil
+}
+
+// Stop closes the device, which ends the read loop
+func (source *EvdevSource) Stop() {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if !source.running {
+		return
+	}
+	source.running = false
+	source.file.Close()
+}
+
+// Events returns the channel touch events are delivered on
+func (source *EvdevSource) Events() <-chan Event {
+	return source.events
+}
+
+func (source *EvdevSource) read() {
+
+	slots := map[int32]*evdevSlot{0: {trackingID: -1}}
+	current := int32(0)
+	buffer := make([]byte, inputEventSize)
+
+	for {
+		if _, err := source.file.Read(buffer); err != nil {
+			fmt.Println("Evdev read stopped : ", err)
+			return
+		}
+
+		eventType, code, value := decodeInputEvent(buffer)
+
+		slot := slots[current]
+
+		switch eventType {
+		case evAbs:
+			switch code {
+			case absMtSlot:
+				current = value
+				if slots[current] == nil {
+					slots[current] = &evdevSlot{trackingID: -1}
+				}
+			case absMtTrackingID:
+				if value < 0 && slot.trackingID >= 0 {
+					source.send(int(slot.trackingID), slot.x, slot.y, UnTouch)
+					slot.started = false
+				}
+				slot.trackingID = value
+				slot.dirty = value >= 0
+			case absMtPositionX, absX:
+				if code == absX && source.multiTouch {
+					continue
+				}
+				slot.x = value
+				slot.dirty = true
+			case absMtPositionY, absY:
+				if code == absY && source.multiTouch {
+					continue
+				}
+				slot.y = value
+				slot.dirty = true
+			}
+		case evKey:
+			if code == btnTouch && !source.multiTouch {
+				if value == 0 {
+					source.send(0, slot.x, slot.y, UnTouch)
+					slot.trackingID = -1
+					slot.started = false
+				} else {
+					slot.trackingID = 0
+					slot.dirty = true
+				}
+			}
+		case evSyn:
+			if code != synReport {
+				continue
+			}
+			for _, contact := range slots {
+				if !contact.dirty || contact.trackingID < 0 {
+					continue
+				}
+				if contact.started {
+					source.send(int(contact.trackingID), contact.x, contact.y, StreamTouch)
+				} else {
+					source.send(int(contact.trackingID), contact.x, contact.y, InitialTouch)
+					contact.started = true
+				}
+				contact.dirty = false
+			}
+		}
+	}
+}
+
+// decodeInputEvent reads the type, code and value of an input_event, skipping its timestamp
+func decodeInputEvent(buffer []byte) (uint16, uint16, int32) {
+	eventType := binary.LittleEndian.Uint16(buffer[timevalSize : timevalSize+2])
+	code := binary.LittleEndian.Uint16(buffer[timevalSize+2 : timevalSize+4])
+	value := int32(binary.LittleEndian.Uint32(buffer[timevalSize+4 : timevalSize+8]))
+
+	return eventType, code, value
+}
+
+func (source *EvdevSource) send(id int, x int32, y int32, status Status) {
+	screenX := float32(x-source.xRange.Minimum) / float32(source.xRange.Maximum-source.xRange.Minimum) * float32(source.ScreenWidth)
+	screenY := float32(y-source.yRange.Minimum) / float32(source.yRange.Maximum-source.yRange.Minimum) * float32(source.ScreenHeight)
+
+	source.events <- Event{ID: id, X: screenX, Y: screenY, Status: status, Time: time.Now()}
+}
@@ -0,0 +1,35 @@
+//go:build linux
+// +build linux
+
+package touch
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeInputEventSkipsTimestamp(t *testing.T) {
+	buffer := make([]byte, inputEventSize)
+	for i := 0; i < timevalSize; i++ {
+		buffer[i] = 0xff
+	}
+	binary.LittleEndian.PutUint16(buffer[timevalSize:], evAbs)
+	binary.LittleEndian.PutUint16(buffer[timevalSize+2:], absMtPositionX)
+	binary.LittleEndian.PutUint32(buffer[timevalSize+4:], uint32(1234))
+
+	eventType, code, value := decodeInputEvent(buffer)
+	if eventType != evAbs || code != absMtPositionX || value != 1234 {
+		t.Errorf("expected an ABS_MT_POSITION_X of 1234, got type %d code %d value %d", eventType, code, value)
+	}
+}
+
+func TestSortDevicePathsNumerically(t *testing.T) {
+	paths := []string{"/dev/input/event10", "/dev/input/event2", "/dev/input/event0", "/dev/input/event1"}
+	sortDevicePaths(paths)
+
+	expected := []string{"/dev/input/event0", "/dev/input/event1", "/dev/input/event2", "/dev/input/event10"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+}
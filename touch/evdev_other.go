@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package touch
+
+import "errors"
+
+// NewEvdevSource is only available on Linux
+func NewEvdevSource(path string, screenWidth int, screenHeight int) (TouchSource, error) {
+	return nil, errors.New("evdev touch input is only supported on Linux")
+}
@@ -0,0 +1,287 @@
+package touch
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// GestureType identifies what a Gesture describes
+type GestureType int
+
+const (
+	GesturePan GestureType = iota
+	GesturePinch
+	GestureRotate
+	GestureLongPress
+	GestureDoubleTap
+	GestureDown
+	GesturePanEnd
+)
+
+const DefaultTapSlop float32 = 10
+const DefaultLongPressDuration time.Duration = 700 * time.Millisecond
+const DefaultDoubleTapInterval time.Duration = 350 * time.Millisecond
+const DefaultDoubleTapDistance float32 = 40
+const GestureTickInterval time.Duration = 50 * time.Millisecond
+
+// Gesture is a recognized interaction built from one or more contacts.
+// GestureDown is sent whenever a new contact starts and GesturePanEnd when the last moving contact lifts.
+// Position is the contact or the centroid of the contacts involved.
+// Delta is set for pans, Scale is the distance ratio since the last pinch and Rotation the angle change in radians.
+type Gesture struct {
+	Type     GestureType
+	Position fyne.Position
+	Delta    fyne.Delta
+	Scale    float32
+	Rotation float32
+	Fingers  int
+	Time     time.Time
+}
+
+type contact struct {
+	id          int
+	start       fyne.Position
+	last        fyne.Position
+	down        time.Time
+	moved       bool
+	longPressed bool
+	multi       bool
+}
+
+// GestureRecognizer tracks every contact by id and turns the raw touch stream into gestures
+type GestureRecognizer struct {
+	TapSlop           float32
+	LongPressDuration time.Duration
+	DoubleTapInterval time.Duration
+	DoubleTapDistance float32
+
+	contacts map[int]*contact
+	gestures chan Gesture
+
+	pairDistance float32
+	pairAngle    float32
+	pairCenter   fyne.Position
+	pairIDs      [2]int
+	paired       bool
+
+	lastTap     fyne.Position
+	lastTapTime time.Time
+}
+
+// NewGestureRecognizer creates a recognizer with the default thresholds
+func NewGestureRecognizer() *GestureRecognizer {
+	return &GestureRecognizer{
+		TapSlop:           DefaultTapSlop,
+		LongPressDuration: DefaultLongPressDuration,
+		DoubleTapInterval: DefaultDoubleTapInterval,
+		DoubleTapDistance: DefaultDoubleTapDistance,
+		contacts:          make(map[int]*contact),
+		gestures:          make(chan Gesture, EventBufferSize),
+	}
+}
+
+// Gestures returns the channel Run delivers gestures on
+func (recognizer *GestureRecognizer) Gestures() <-chan Gesture {
+	return recognizer.gestures
+}
+
+// Run feeds events into the recognizer until the channel is closed
+func (recognizer *GestureRecognizer) Run(events <-chan Event) {
+
+	ticker := time.NewTicker(GestureTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				close(recognizer.gestures)
+				return
+			}
+			for _, gesture := range recognizer.Process(event) {
+				recognizer.gestures <- gesture
+			}
+		case now := <-ticker.C:
+			for _, gesture := range recognizer.Tick(now) {
+				recognizer.gestures <- gesture
+			}
+		}
+	}
+}
+
+// ContactCount returns the number of fingers currently down
+func (recognizer *GestureRecognizer) ContactCount() int {
+	return len(recognizer.contacts)
+}
+
+// Process updates the tracked contacts with an event and returns the gestures it completes
+func (recognizer *GestureRecognizer) Process(event Event) []Gesture {
+
+	position := fyne.NewPos(event.X, event.Y)
+
+	switch event.Status {
+	case InitialTouch, StreamTouch:
+		current, tracked := recognizer.contacts[event.ID]
+		if !tracked {
+			recognizer.contacts[event.ID] = &contact{id: event.ID, start: position, last: position, down: event.Time}
+			recognizer.resetPair()
+			return []Gesture{{Type: GestureDown, Position: position, Fingers: len(recognizer.contacts), Time: event.Time}}
+		}
+		if len(recognizer.contacts) == 1 {
+			return recognizer.singleMove(current, position, event.Time)
+		}
+		if !recognizer.paired {
+			// measure the pair before this move so the first pinch step is not lost
+			recognizer.multiMove(event.Time)
+		}
+		current.last = position
+		return recognizer.multiMove(event.Time)
+	case UnTouch:
+		current, tracked := recognizer.contacts[event.ID]
+		if !tracked {
+			return nil
+		}
+		delete(recognizer.contacts, event.ID)
+		recognizer.resetPair()
+
+		for _, remaining := range recognizer.contacts {
+			remaining.moved = true
+		}
+
+		if current.moved && len(recognizer.contacts) == 0 {
+			return []Gesture{{Type: GesturePanEnd, Position: current.last, Fingers: 1, Time: event.Time}}
+		}
+
+		if current.moved || current.longPressed || current.multi || event.Time.Sub(current.down) >= recognizer.LongPressDuration {
+			return nil
+		}
+		return recognizer.tap(position, event.Time)
+	}
+
+	return nil
+}
+
+// Tick reports long presses for contacts that have been held still long enough
+func (recognizer *GestureRecognizer) Tick(now time.Time) []Gesture {
+
+	var gestures []Gesture
+
+	if len(recognizer.contacts) != 1 {
+		return gestures
+	}
+
+	for _, current := range recognizer.contacts {
+		if current.moved || current.longPressed || current.multi || now.Sub(current.down) < recognizer.LongPressDuration {
+			continue
+		}
+		current.longPressed = true
+		gestures = append(gestures, Gesture{Type: GestureLongPress, Position: current.last, Fingers: 1, Time: now})
+	}
+
+	return gestures
+}
+
+func (recognizer *GestureRecognizer) singleMove(current *contact, position fyne.Position, now time.Time) []Gesture {
+
+	if !current.moved {
+		if geometry.Distance(current.start, position) < recognizer.TapSlop {
+			return nil
+		}
+		current.moved = true
+	}
+
+	delta := fyne.NewDelta(position.X-current.last.X, position.Y-current.last.Y)
+	current.last = position
+
+	if delta.DX == 0 && delta.DY == 0 {
+		return nil
+	}
+
+	return []Gesture{{Type: GesturePan, Position: position, Delta: delta, Fingers: 1, Time: now}}
+}
+
+func (recognizer *GestureRecognizer) multiMove(now time.Time) []Gesture {
+
+	first, second := recognizer.pair()
+	first.multi, second.multi = true, true
+	first.moved, second.moved = true, true
+
+	pairDistance := geometry.Distance(first.last, second.last)
+	pairAngle := float32(math.Atan2(float64(second.last.Y-first.last.Y), float64(second.last.X-first.last.X)))
+	pairCenter := fyne.NewPos((first.last.X+second.last.X)/2, (first.last.Y+second.last.Y)/2)
+	fingers := len(recognizer.contacts)
+
+	if !recognizer.paired || recognizer.pairIDs != [2]int{first.id, second.id} {
+		recognizer.pairDistance, recognizer.pairAngle, recognizer.pairCenter = pairDistance, pairAngle, pairCenter
+		recognizer.pairIDs = [2]int{first.id, second.id}
+		recognizer.paired = true
+		return nil
+	}
+
+	var gestures []Gesture
+
+	if recognizer.pairDistance > 0 && pairDistance != recognizer.pairDistance {
+		gestures = append(gestures, Gesture{Type: GesturePinch, Position: pairCenter, Scale: pairDistance / recognizer.pairDistance, Fingers: fingers, Time: now})
+	}
+
+	if rotation := normalizeAngle(pairAngle - recognizer.pairAngle); rotation != 0 {
+		gestures = append(gestures, Gesture{Type: GestureRotate, Position: pairCenter, Rotation: rotation, Fingers: fingers, Time: now})
+	}
+
+	if delta := fyne.NewDelta(pairCenter.X-recognizer.pairCenter.X, pairCenter.Y-recognizer.pairCenter.Y); delta.DX != 0 || delta.DY != 0 {
+		gestures = append(gestures, Gesture{Type: GesturePan, Position: pairCenter, Delta: delta, Fingers: fingers, Time: now})
+	}
+
+	recognizer.pairDistance, recognizer.pairAngle, recognizer.pairCenter = pairDistance, pairAngle, pairCenter
+
+	return gestures
+}
+
+func (recognizer *GestureRecognizer) tap(position fyne.Position, now time.Time) []Gesture {
+
+	if !recognizer.lastTapTime.IsZero() && now.Sub(recognizer.lastTapTime) <= recognizer.DoubleTapInterval && geometry.Distance(recognizer.lastTap, position) <= recognizer.DoubleTapDistance {
+		recognizer.lastTapTime = time.Time{}
+		return []Gesture{{Type: GestureDoubleTap, Position: position, Fingers: 1, Time: now}}
+	}
+
+	recognizer.lastTap = position
+	recognizer.lastTapTime = now
+
+	return nil
+}
+
+// pair returns the two contacts that have been down the longest, which drive pinch and rotate
+func (recognizer *GestureRecognizer) pair() (*contact, *contact) {
+
+	contacts := make([]*contact, 0, len(recognizer.contacts))
+	for _, current := range recognizer.contacts {
+		contacts = append(contacts, current)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].down.Equal(contacts[j].down) {
+			return contacts[i].id < contacts[j].id
+		}
+		return contacts[i].down.Before(contacts[j].down)
+	})
+
+	return contacts[0], contacts[1]
+}
+
+func (recognizer *GestureRecognizer) resetPair() {
+	recognizer.paired = false
+}
+
+func normalizeAngle(angle float32) float32 {
+	for angle > math.Pi {
+		angle -= 2 * math.Pi
+	}
+	for angle < -math.Pi {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
@@ -0,0 +1,52 @@
+package touch
+
+import (
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// Miniature is a contact that has stayed still long enough to be taken for a mini-figure standing on the table
+// rather than a finger. It keeps being tracked while the mini slides around until it is lifted.
+type Miniature struct {
+	ID       int
+	Position fyne.Position
+	Since    time.Time
+}
+
+// Miniatures returns the minis currently standing on the table, in the order their contacts were numbered
+func (rejector *Rejector) Miniatures() []Miniature {
+	rejector.mutex.Lock()
+	defer rejector.mutex.Unlock()
+
+	var miniatures []Miniature
+	for id, current := range rejector.contacts {
+		if current.learned {
+			miniatures = append(miniatures, Miniature{ID: id, Position: current.origin, Since: current.since})
+		}
+	}
+	sort.Slice(miniatures, func(i, j int) bool {
+		return miniatures[i].ID < miniatures[j].ID
+	})
+
+	return miniatures
+}
+
+// slideMiniature moves the zone of a learned contact along with it, returning true if it moved
+func (rejector *Rejector) slideMiniature(id int, current *rejectContact, position fyne.Position) bool {
+	if geometry.Distance(current.origin, position) <= rejector.StationaryTolerance {
+		return false
+	}
+
+	current.origin = position
+	for i, zone := range rejector.zones {
+		if zone.Learned && zone.contactID == id {
+			rejector.zones[i].Polygon = geometry.CirclePolygon(position, rejector.LearnedZoneRadius, learnedZoneCorners)
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,94 @@
+package touch
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestMiniaturesAreDetectedAmongFingers(t *testing.T) {
+	recording, err := ReadRecording(bytes.NewReader(mustRead(t, "testdata/miniature.jsonl")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the mini stands still from the start, slides after 3.5s and is lifted at 5s
+	before := func(offset time.Duration) []Event {
+		var events []Event
+		for _, event := range recording {
+			if event.Time.Sub(RecordingEpoch) < offset {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	rejector := NewRejector()
+	accepted := rejector.Replay(before(3400 * time.Millisecond))
+	miniatures := rejector.Miniatures()
+	if len(miniatures) != 1 || miniatures[0].ID != 0 || miniatures[0].Position.X < 495 || miniatures[0].Position.X > 505 {
+		t.Fatalf("expected the resting contact to be a miniature, got %+v", miniatures)
+	}
+	if last := lastEvent(accepted, 0); last.Status != UnTouch {
+		t.Errorf("expected the gestures to be told the mini's contact lifted, got %+v", last)
+	}
+	if count(accepted, 1) != count(recording, 1) {
+		t.Errorf("expected every event of the finger to pass, got %d of %d", count(accepted, 1), count(recording, 1))
+	}
+	if count(accepted, 2) != 0 {
+		t.Error("expected a touch on the mini to be rejected")
+	}
+
+	accepted = rejector.Replay(recording[len(before(3400*time.Millisecond)):])
+	if count(accepted, 3) != 2 {
+		t.Error("expected a touch where the mini stood before it slid away to pass")
+	}
+	if miniatures = rejector.Miniatures(); len(miniatures) != 0 {
+		t.Errorf("expected no miniatures once it was lifted, got %+v", miniatures)
+	}
+	if zones := rejector.Zones(); len(zones) != 0 {
+		t.Errorf("expected the mini's zone to be removed, got %d zones", len(zones))
+	}
+}
+
+func TestMiniatureFollowsWhenSlid(t *testing.T) {
+	rejector := NewRejector()
+	start := time.Now()
+
+	rejector.Filter(Event{ID: 4, X: 100, Y: 100, Status: InitialTouch, Time: start})
+	rejector.Tick(start.Add(rejector.StationaryDuration))
+	rejector.Filter(Event{ID: 4, X: 103, Y: 100, Status: StreamTouch, Time: start.Add(rejector.StationaryDuration + time.Second)})
+	if miniatures := rejector.Miniatures(); len(miniatures) != 1 || miniatures[0].Position.X != 100 {
+		t.Fatalf("expected a small wobble to leave the miniature in place, got %+v", miniatures)
+	}
+
+	rejector.Filter(Event{ID: 4, X: 200, Y: 150, Status: StreamTouch, Time: start.Add(rejector.StationaryDuration + 2*time.Second)})
+	if miniatures := rejector.Miniatures(); len(miniatures) != 1 || miniatures[0].Position.X != 200 || miniatures[0].Position.Y != 150 {
+		t.Fatalf("expected the miniature to follow its contact, got %+v", miniatures)
+	}
+	if zones := rejector.Zones(); len(zones) != 1 || !zones[0].Polygon.Contains(fyne.NewPos(200, 150)) {
+		t.Error("expected the learned zone to move with the miniature")
+	}
+}
+
+func lastEvent(events []Event, id int) Event {
+	var last Event
+	for _, event := range events {
+		if event.ID == id {
+			last = event
+		}
+	}
+	return last
+}
+
+func count(events []Event, id int) int {
+	total := 0
+	for _, event := range events {
+		if event.ID == id {
+			total++
+		}
+	}
+	return total
+}
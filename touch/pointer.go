@@ -0,0 +1,120 @@
+package touch
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// PointerSource turns Fyne mouse and single-finger pointer input into touch events.
+// Its Surface has to be placed over the map so it can receive the pointer.
+type PointerSource struct {
+	Surface *PointerSurface
+
+	events  chan Event
+	mutex   sync.Mutex
+	running bool
+}
+
+// PointerSurface is a transparent widget that reports pointer presses and drags to its PointerSource
+type PointerSurface struct {
+	widget.BaseWidget
+
+	source   *PointerSource
+	down     bool
+	position fyne.Position
+}
+
+// NewPointerSource creates a pointer source and the surface that feeds it
+func NewPointerSource() *PointerSource {
+	source := &PointerSource{events: make(chan Event, EventBufferSize)}
+
+	surface := &PointerSurface{source: source}
+	surface.ExtendBaseWidget(surface)
+	source.Surface = surface
+
+	return source
+}
+
+// Start begins forwarding pointer input
+func (source *PointerSource) Start() error {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.running = true
+
+	return nil
+}
+
+// Stop discards pointer input until Start is called again
+func (source *PointerSource) Stop() {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.running = false
+}
+
+// Events returns the channel touch events are delivered on
+func (source *PointerSource) Events() <-chan Event {
+	return source.events
+}
+
+func (source *PointerSource) send(status Status, position fyne.Position) {
+	source.mutex.Lock()
+	running := source.running
+	source.mutex.Unlock()
+
+	if !running {
+		return
+	}
+
+	select {
+	case source.events <- Event{X: position.X, Y: position.Y, Status: status, Time: time.Now()}:
+	default:
+		// The consumer is behind, dropping a pointer sample is better than blocking the UI thread
+	}
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (surface *PointerSurface) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(canvas.NewRectangle(nil))
+}
+
+// MouseDown starts a contact when the pointer is pressed
+func (surface *PointerSurface) MouseDown(event *desktop.MouseEvent) {
+	surface.down = true
+	surface.position = event.AbsolutePosition
+	surface.source.send(InitialTouch, event.AbsolutePosition)
+}
+
+// MouseUp ends the contact when the pointer is released without dragging
+func (surface *PointerSurface) MouseUp(event *desktop.MouseEvent) {
+	if !surface.down {
+		return
+	}
+	surface.down = false
+	surface.source.send(UnTouch, event.AbsolutePosition)
+}
+
+// Dragged streams the contact while the pointer moves
+func (surface *PointerSurface) Dragged(event *fyne.DragEvent) {
+	if !surface.down {
+		surface.down = true
+		surface.source.send(InitialTouch, event.AbsolutePosition.Subtract(event.Dragged))
+	}
+	surface.position = event.AbsolutePosition
+	surface.source.send(StreamTouch, event.AbsolutePosition)
+}
+
+// DragEnd ends the contact at the last dragged position
+func (surface *PointerSurface) DragEnd() {
+	if !surface.down {
+		return
+	}
+	surface.down = false
+	surface.source.send(UnTouch, surface.position)
+}
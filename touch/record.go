@@ -0,0 +1,246 @@
+package touch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// RecordedEvent is one line of a touch recording, Offset is the time since the first event in milliseconds
+type RecordedEvent struct {
+	Offset int64   `json:"t"`
+	ID     int     `json:"id"`
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Status Status  `json:"s"`
+}
+
+// RecordingEpoch is the start time given to events read back from a recording, so replays are deterministic
+var RecordingEpoch = time.Unix(0, 0)
+
+// Recorder wraps a TouchSource and writes every event it produces as JSON lines
+type Recorder struct {
+	source TouchSource
+	writer io.WriteCloser
+	events chan Event
+	start  time.Time
+	mutex  sync.Mutex
+}
+
+// NewRecorder records the events of source to writer while passing them through unchanged
+func NewRecorder(source TouchSource, writer io.WriteCloser) *Recorder {
+	return &Recorder{source: source, writer: writer, events: make(chan Event, EventBufferSize)}
+}
+
+// CreateRecorder records the events of source to a new file at path
+func CreateRecorder(source TouchSource, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecorder(source, file), nil
+}
+
+// Source returns the wrapped TouchSource
+func (recorder *Recorder) Source() TouchSource {
+	return recorder.source
+}
+
+// Start starts the wrapped source and begins recording
+func (recorder *Recorder) Start() error {
+	if err := recorder.source.Start(); err != nil {
+		return err
+	}
+
+	go recorder.record()
+
+	return nil
+}
+
+// Stop stops the wrapped source and closes the recording
+func (recorder *Recorder) Stop() {
+	recorder.source.Stop()
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if recorder.writer != nil {
+		if err := recorder.writer.Close(); err != nil {
+			fmt.Println("Closing touch recording : ", err)
+		}
+		recorder.writer = nil
+	}
+}
+
+// Events returns the channel touch events are delivered on
+func (recorder *Recorder) Events() <-chan Event {
+	return recorder.events
+}
+
+func (recorder *Recorder) record() {
+
+	for event := range recorder.source.Events() {
+		if err := recorder.write(event); err != nil {
+			fmt.Println("Writing touch recording : ", err)
+		}
+		recorder.events <- event
+	}
+	close(recorder.events)
+}
+
+func (recorder *Recorder) write(event Event) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if recorder.writer == nil {
+		return nil
+	}
+	if recorder.start.IsZero() {
+		recorder.start = event.Time
+	}
+
+	line, err := json.Marshal(RecordedEvent{Offset: event.Time.Sub(recorder.start).Milliseconds(), ID: event.ID, X: event.X, Y: event.Y, Status: event.Status})
+	if err != nil {
+		return err
+	}
+
+	_, err = recorder.writer.Write(append(line, '\n'))
+
+	return err
+}
+
+// ReadRecording parses a JSON lines touch recording, timestamping events from RecordingEpoch
+func ReadRecording(reader io.Reader) ([]Event, error) {
+
+	var events []Event
+	scanner := bufio.NewScanner(reader)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var recorded RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil {
+			return nil, fmt.Errorf("touch recording line %d: %w", line, err)
+		}
+
+		events = append(events, Event{ID: recorded.ID, X: recorded.X, Y: recorded.Y, Status: recorded.Status, Time: RecordingEpoch.Add(time.Duration(recorded.Offset) * time.Millisecond)})
+	}
+
+	return events, scanner.Err()
+}
+
+// LoadRecording reads the touch recording at path
+func LoadRecording(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadRecording(file)
+}
+
+// ReplaySource plays a recording back as a TouchSource.
+// In realtime mode the original pacing is kept and events are stamped with the current time,
+// otherwise events are delivered as fast as they are consumed with their recorded times.
+type ReplaySource struct {
+	Realtime bool
+
+	recording []Event
+	events    chan Event
+	stop      chan struct{}
+	once      sync.Once
+}
+
+// NewReplaySource creates a source that replays the given events once
+func NewReplaySource(recording []Event, realtime bool) *ReplaySource {
+	return &ReplaySource{Realtime: realtime, recording: recording, events: make(chan Event, EventBufferSize), stop: make(chan struct{})}
+}
+
+// Start begins the replay, the events channel is closed when it finishes
+func (replay *ReplaySource) Start() error {
+	go replay.play()
+
+	return nil
+}
+
+// Stop ends the replay early
+func (replay *ReplaySource) Stop() {
+	replay.once.Do(func() {
+		close(replay.stop)
+	})
+}
+
+// Events returns the channel touch events are delivered on
+func (replay *ReplaySource) Events() <-chan Event {
+	return replay.events
+}
+
+func (replay *ReplaySource) play() {
+
+	defer close(replay.events)
+
+	if len(replay.recording) == 0 {
+		return
+	}
+
+	started := time.Now()
+	first := replay.recording[0].Time
+
+	for _, event := range replay.recording {
+		if replay.Realtime {
+			wait := event.Time.Sub(first) - time.Since(started)
+			if wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-replay.stop:
+					return
+				}
+			}
+			event.Time = time.Now()
+		}
+
+		select {
+		case replay.events <- event:
+		case <-replay.stop:
+			return
+		}
+	}
+}
+
+// Replay feeds a recording through the recognizer using the recorded times instead of a ticker,
+// so the same recording always produces the same gestures
+func (recognizer *GestureRecognizer) Replay(recording []Event) []Gesture {
+
+	var gestures []Gesture
+
+	for _, event := range recording {
+		gestures = append(gestures, recognizer.Tick(event.Time)...)
+		gestures = append(gestures, recognizer.Process(event)...)
+	}
+
+	return gestures
+}
+
+// Replay feeds a recording through the rejector using the recorded times instead of a ticker,
+// returning the events that would have reached the gestures
+func (rejector *Rejector) Replay(recording []Event) []Event {
+
+	var accepted []Event
+
+	for _, event := range recording {
+		accepted = append(accepted, rejector.Tick(event.Time)...)
+		accepted = append(accepted, rejector.Filter(event)...)
+	}
+
+	return accepted
+}
@@ -0,0 +1,80 @@
+package touch
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	start := time.Now()
+	events := []Event{
+		{ID: 0, X: 100, Y: 200, Status: InitialTouch, Time: start},
+		{ID: 0, X: 110, Y: 205, Status: StreamTouch, Time: start.Add(16 * time.Millisecond)},
+		{ID: 1, X: 400, Y: 300, Status: InitialTouch, Time: start.Add(20 * time.Millisecond)},
+		{ID: 0, X: 110, Y: 205, Status: UnTouch, Time: start.Add(40 * time.Millisecond)},
+	}
+
+	buffer := nopCloser{&bytes.Buffer{}}
+	recorder := NewRecorder(NewReplaySource(events, false), buffer)
+	if err := recorder.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for range recorder.Events() {
+	}
+	recorder.Stop()
+
+	recording, err := ReadRecording(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording) != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), len(recording))
+	}
+
+	for i, event := range recording {
+		expected := events[i]
+		if event.ID != expected.ID || event.X != expected.X || event.Y != expected.Y || event.Status != expected.Status {
+			t.Errorf("event %d: expected %+v, got %+v", i, expected, event)
+		}
+		if offset := event.Time.Sub(RecordingEpoch); offset != expected.Time.Sub(start) {
+			t.Errorf("event %d: expected offset %v, got %v", i, expected.Time.Sub(start), offset)
+		}
+	}
+}
+
+func TestReplayIsDeterministic(t *testing.T) {
+	recording, err := ReadRecording(bytes.NewReader(mustRead(t, "testdata/pinch.jsonl")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := NewGestureRecognizer().Replay(recording)
+	second := NewGestureRecognizer().Replay(recording)
+
+	if len(first) == 0 || len(first) != len(second) {
+		t.Fatalf("expected matching gesture streams, got %d and %d gestures", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("gesture %d differs: %+v and %+v", i, first[i], second[i])
+		}
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
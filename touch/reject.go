@@ -0,0 +1,281 @@
+package touch
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+const DefaultStationaryDuration time.Duration = 3 * time.Second
+const DefaultStationaryTolerance float32 = 6
+const DefaultLearnedZoneRadius float32 = 45
+const learnedZoneCorners int = 16
+
+// Zone is an area of the screen where new contacts are ignored.
+// Learned zones are created around stationary contacts and removed when that contact lifts.
+type Zone struct {
+	Polygon   geometry.Polygon
+	Learned   bool
+	contactID int
+}
+
+// Clipper is implemented by backends that can reject touches in rectangles on the device itself
+type Clipper interface {
+	SetClipRectangles(rectangles []geometry.Polygon) error
+}
+
+type rejectContact struct {
+	origin   fyne.Position
+	since    time.Time
+	rejected bool
+	learned  bool
+}
+
+// Rejector filters out contacts that start inside a rejection zone, so resting minis and forearms don't reach the gestures.
+// With LearnStationary set, contacts that stay still for StationaryDuration are dropped and taken for miniatures,
+// with a learned zone that follows them until they lift.
+type Rejector struct {
+	LearnStationary     bool
+	StationaryDuration  time.Duration
+	StationaryTolerance float32
+	LearnedZoneRadius   float32
+
+	// OnZonesChanged is called whenever zones are added or removed, including learned ones
+	OnZonesChanged func()
+
+	zones    []Zone
+	contacts map[int]*rejectContact
+	paused   bool
+	mutex    sync.Mutex
+}
+
+// NewRejector creates a rejector with no zones that learns stationary contacts
+func NewRejector() *Rejector {
+	return &Rejector{
+		LearnStationary:     true,
+		StationaryDuration:  DefaultStationaryDuration,
+		StationaryTolerance: DefaultStationaryTolerance,
+		LearnedZoneRadius:   DefaultLearnedZoneRadius,
+		contacts:            make(map[int]*rejectContact),
+	}
+}
+
+// AddZone starts rejecting contacts that begin inside polygon
+func (rejector *Rejector) AddZone(polygon geometry.Polygon) {
+	rejector.mutex.Lock()
+	rejector.zones = append(rejector.zones, Zone{Polygon: polygon})
+	rejector.mutex.Unlock()
+
+	rejector.zonesChanged()
+}
+
+// RemoveZoneAt removes the zones containing position and returns true if any were removed
+func (rejector *Rejector) RemoveZoneAt(position fyne.Position) bool {
+	rejector.mutex.Lock()
+	var kept []Zone
+	for _, zone := range rejector.zones {
+		if !zone.Polygon.Contains(position) {
+			kept = append(kept, zone)
+		}
+	}
+	removed := len(kept) != len(rejector.zones)
+	rejector.zones = kept
+	rejector.mutex.Unlock()
+
+	if removed {
+		rejector.zonesChanged()
+	}
+
+	return removed
+}
+
+// SetPaused lets new contacts through even inside zones and stops learning stationary ones, so the GM can tap
+// and draw over the zones while editing them. Miniatures already learned stay rejected.
+func (rejector *Rejector) SetPaused(paused bool) {
+	rejector.mutex.Lock()
+	rejector.paused = paused
+	rejector.mutex.Unlock()
+}
+
+// ClearZones removes every zone
+func (rejector *Rejector) ClearZones() {
+	rejector.mutex.Lock()
+	rejector.zones = nil
+	rejector.mutex.Unlock()
+
+	rejector.zonesChanged()
+}
+
+// Zones returns a copy of the current zones
+func (rejector *Rejector) Zones() []Zone {
+	rejector.mutex.Lock()
+	defer rejector.mutex.Unlock()
+
+	return append([]Zone(nil), rejector.zones...)
+}
+
+// ClipRectangles returns the rectangular zones drawn by the GM, which a Clipper can reject in hardware.
+// There are none while paused, so touches in the zones reach the software again.
+func (rejector *Rejector) ClipRectangles() []geometry.Polygon {
+	rejector.mutex.Lock()
+	defer rejector.mutex.Unlock()
+
+	if rejector.paused {
+		return nil
+	}
+
+	var rectangles []geometry.Polygon
+	for _, zone := range rejector.zones {
+		if !zone.Learned && zone.Polygon.IsRect() {
+			rectangles = append(rectangles, zone.Polygon)
+		}
+	}
+
+	return rectangles
+}
+
+// Run filters events until the channel is closed
+func (rejector *Rejector) Run(events <-chan Event) <-chan Event {
+
+	filtered := make(chan Event, EventBufferSize)
+
+	go func() {
+		defer close(filtered)
+
+		ticker := time.NewTicker(GestureTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				for _, accepted := range rejector.Filter(event) {
+					filtered <- accepted
+				}
+			case now := <-ticker.C:
+				for _, accepted := range rejector.Tick(now) {
+					filtered <- accepted
+				}
+			}
+		}
+	}()
+
+	return filtered
+}
+
+// Filter returns the events that should be passed on for a single incoming event.
+// When a contact is learned as stationary an UnTouch is returned for it so gestures stop tracking it.
+func (rejector *Rejector) Filter(event Event) []Event {
+
+	rejector.mutex.Lock()
+
+	position := fyne.NewPos(event.X, event.Y)
+	current, tracked := rejector.contacts[event.ID]
+	changed := false
+
+	if !tracked {
+		if event.Status == UnTouch {
+			rejector.mutex.Unlock()
+			return nil
+		}
+		current = &rejectContact{origin: position, since: event.Time, rejected: !rejector.paused && rejector.inZone(position)}
+		rejector.contacts[event.ID] = current
+	}
+
+	var accepted []Event
+
+	switch {
+	case event.Status == UnTouch:
+		delete(rejector.contacts, event.ID)
+		if current.learned {
+			rejector.removeLearned(event.ID)
+			changed = true
+		}
+		if !current.rejected {
+			accepted = append(accepted, event)
+		}
+	case current.learned:
+		// a mini sliding across the table keeps its zone under it
+		changed = rejector.slideMiniature(event.ID, current, position)
+	case current.rejected:
+	default:
+		if geometry.Distance(current.origin, position) > rejector.StationaryTolerance {
+			current.origin = position
+			current.since = event.Time
+		}
+		if rejector.LearnStationary && !rejector.paused && event.Time.Sub(current.since) >= rejector.StationaryDuration {
+			rejector.learn(event.ID, current)
+			changed = true
+			event.Status = UnTouch
+		}
+		accepted = append(accepted, event)
+	}
+
+	rejector.mutex.Unlock()
+
+	if changed {
+		rejector.zonesChanged()
+	}
+
+	return accepted
+}
+
+// Tick learns contacts that have stayed still without sending any new events
+func (rejector *Rejector) Tick(now time.Time) []Event {
+
+	var accepted []Event
+
+	rejector.mutex.Lock()
+	if rejector.LearnStationary && !rejector.paused {
+		for id, current := range rejector.contacts {
+			if current.rejected || now.Sub(current.since) < rejector.StationaryDuration {
+				continue
+			}
+			rejector.learn(id, current)
+			accepted = append(accepted, Event{ID: id, X: current.origin.X, Y: current.origin.Y, Status: UnTouch, Time: now})
+		}
+	}
+	rejector.mutex.Unlock()
+
+	if len(accepted) > 0 {
+		rejector.zonesChanged()
+	}
+
+	return accepted
+}
+
+func (rejector *Rejector) learn(id int, current *rejectContact) {
+	current.rejected, current.learned = true, true
+	rejector.zones = append(rejector.zones, Zone{Polygon: geometry.CirclePolygon(current.origin, rejector.LearnedZoneRadius, learnedZoneCorners), Learned: true, contactID: id})
+}
+
+func (rejector *Rejector) inZone(position fyne.Position) bool {
+	for _, zone := range rejector.zones {
+		if zone.Polygon.Contains(position) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rejector *Rejector) removeLearned(contactID int) {
+	var kept []Zone
+	for _, zone := range rejector.zones {
+		if !zone.Learned || zone.contactID != contactID {
+			kept = append(kept, zone)
+		}
+	}
+	rejector.zones = kept
+}
+
+func (rejector *Rejector) zonesChanged() {
+	if rejector.OnZonesChanged != nil {
+		rejector.OnZonesChanged()
+	}
+}
@@ -0,0 +1,148 @@
+package touch
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+func TestRejectorFilter(t *testing.T) {
+	start := time.Now()
+	at := func(id int, x float32, y float32, status Status, seconds float64) Event {
+		return Event{ID: id, X: x, Y: y, Status: status, Time: start.Add(time.Duration(seconds * float64(time.Second)))}
+	}
+	zone := geometry.RectPolygon(fyne.NewPos(0, 0), fyne.NewPos(200, 200))
+
+	tests := []struct {
+		name   string
+		paused bool
+		events []Event
+		// passed is how many events of the contact should reach the gestures, learned whether it ends as a mini
+		passed  int
+		lastUp  bool
+		learned bool
+	}{
+		{
+			name:   "outside the zone",
+			events: []Event{at(1, 300, 300, InitialTouch, 0), at(1, 350, 300, StreamTouch, 0.1), at(1, 400, 300, UnTouch, 0.2)},
+			passed: 3,
+			lastUp: true,
+		},
+		{
+			name:   "inside the zone",
+			events: []Event{at(1, 100, 100, InitialTouch, 0), at(1, 120, 100, StreamTouch, 0.1), at(1, 120, 100, UnTouch, 0.2)},
+		},
+		{
+			name:   "starts inside the zone and leaves it",
+			events: []Event{at(1, 150, 150, InitialTouch, 0), at(1, 250, 150, StreamTouch, 0.1), at(1, 400, 150, StreamTouch, 0.2), at(1, 400, 150, UnTouch, 0.3)},
+		},
+		{
+			name:   "starts outside the zone and enters it",
+			events: []Event{at(1, 300, 150, InitialTouch, 0), at(1, 150, 150, StreamTouch, 0.1), at(1, 150, 150, UnTouch, 0.2)},
+			passed: 3,
+			lastUp: true,
+		},
+		{
+			name:    "stationary outside the zone",
+			events:  []Event{at(1, 300, 300, InitialTouch, 0), at(1, 302, 301, StreamTouch, 1), at(1, 301, 300, StreamTouch, 3.5), at(1, 301, 300, StreamTouch, 4)},
+			passed:  3,
+			lastUp:  true,
+			learned: true,
+		},
+		{
+			name:   "moving slowly outside the zone",
+			events: []Event{at(1, 300, 300, InitialTouch, 0), at(1, 320, 300, StreamTouch, 2), at(1, 340, 300, StreamTouch, 4), at(1, 360, 300, StreamTouch, 6)},
+			passed: 4,
+		},
+		{
+			name:   "inside the zone while paused",
+			paused: true,
+			events: []Event{at(1, 100, 100, InitialTouch, 0), at(1, 100, 100, StreamTouch, 4), at(1, 100, 100, UnTouch, 5)},
+			passed: 3,
+			lastUp: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rejector := NewRejector()
+			rejector.AddZone(zone)
+			rejector.SetPaused(test.paused)
+
+			var accepted []Event
+			for _, event := range test.events {
+				accepted = append(accepted, rejector.Filter(event)...)
+			}
+
+			if len(accepted) != test.passed {
+				t.Errorf("expected %d events to pass, got %+v", test.passed, accepted)
+			}
+			if test.passed > 0 && (lastEvent(accepted, 1).Status == UnTouch) != test.lastUp {
+				t.Errorf("expected the last event to lift the contact: %v, got %+v", test.lastUp, lastEvent(accepted, 1))
+			}
+			if learned := len(rejector.Miniatures()) == 1; learned != test.learned {
+				t.Errorf("expected the contact to be learned: %v, got %+v", test.learned, rejector.Miniatures())
+			}
+		})
+	}
+}
+
+func TestRejectorTick(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name    string
+		paused  bool
+		touch   fyne.Position
+		after   time.Duration
+		learned bool
+	}{
+		{name: "too soon", touch: fyne.NewPos(300, 300), after: time.Second},
+		{name: "stationary", touch: fyne.NewPos(300, 300), after: DefaultStationaryDuration, learned: true},
+		{name: "inside a zone", touch: fyne.NewPos(100, 100), after: DefaultStationaryDuration},
+		{name: "paused", paused: true, touch: fyne.NewPos(300, 300), after: DefaultStationaryDuration},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rejector := NewRejector()
+			rejector.AddZone(geometry.RectPolygon(fyne.NewPos(0, 0), fyne.NewPos(200, 200)))
+			rejector.SetPaused(test.paused)
+
+			rejector.Filter(Event{ID: 7, X: test.touch.X, Y: test.touch.Y, Status: InitialTouch, Time: start})
+			accepted := rejector.Tick(start.Add(test.after))
+
+			if test.learned {
+				if len(accepted) != 1 || accepted[0].ID != 7 || accepted[0].Status != UnTouch {
+					t.Errorf("expected the gestures to be told the contact lifted, got %+v", accepted)
+				}
+				if zones := rejector.Zones(); len(zones) != 2 || !zones[1].Learned || !zones[1].Polygon.Contains(test.touch) {
+					t.Errorf("expected a learned zone around the contact, got %+v", zones)
+				}
+				if passed := rejector.Filter(Event{ID: 8, X: test.touch.X + 10, Y: test.touch.Y, Status: InitialTouch, Time: start.Add(test.after)}); len(passed) != 0 {
+					t.Error("expected a new touch on the learned zone to be rejected")
+				}
+			} else if len(accepted) != 0 || len(rejector.Miniatures()) != 0 {
+				t.Errorf("expected the contact not to be learned, got %+v", accepted)
+			}
+		})
+	}
+}
+
+func TestPausedRejectorHasNoClipRectangles(t *testing.T) {
+	rejector := NewRejector()
+	rejector.AddZone(geometry.RectPolygon(fyne.NewPos(0, 0), fyne.NewPos(200, 200)))
+
+	rejector.SetPaused(true)
+	if rectangles := rejector.ClipRectangles(); len(rectangles) != 0 {
+		t.Errorf("expected no clip rectangles while paused, got %v", rectangles)
+	}
+
+	rejector.SetPaused(false)
+	if rectangles := rejector.ClipRectangles(); len(rectangles) != 1 {
+		t.Errorf("expected the zone as a clip rectangle again, got %v", rectangles)
+	}
+}
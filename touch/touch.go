@@ -0,0 +1,65 @@
+package touch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status mirrors the Elo TOUCH_STATUS values so every backend reports contacts the same way
+type Status int
+
+const (
+	InitialTouch Status = 1
+	StreamTouch  Status = 2
+	UnTouch      Status = 4
+)
+
+// Event is a single contact update in screen pixels
+type Event struct {
+	ID     int
+	X      float32
+	Y      float32
+	Status Status
+	Time   time.Time
+}
+
+// TouchSource is a backend that produces touch events from an input device
+type TouchSource interface {
+	Start() error
+	Stop()
+	Events() <-chan Event
+}
+
+const (
+	BackendAuto    string = "auto"
+	BackendElo     string = "elo"
+	BackendEvdev   string = "evdev"
+	BackendPointer string = "pointer"
+)
+
+// EventBufferSize is the number of events a source can queue before it blocks
+const EventBufferSize int = 64
+
+// NewSource creates the touch backend with the given name.
+// "auto" tries the Elo driver, then evdev, and falls back to the Fyne pointer.
+func NewSource(backend string, screenWidth int, screenHeight int) (TouchSource, error) {
+	switch strings.ToLower(backend) {
+	case BackendElo:
+		return NewEloSource(0)
+	case BackendEvdev:
+		return NewEvdevSource("", screenWidth, screenHeight)
+	case BackendPointer:
+		return NewPointerSource(), nil
+	case BackendAuto, "":
+		if source, err := NewEloSource(0); err == nil {
+			return source, nil
+		}
+		if source, err := NewEvdevSource("", screenWidth, screenHeight); err == nil {
+			return source, nil
+		}
+		return NewPointerSource(), nil
+	}
+
+	return nil, fmt.Errorf("unknown touch backend %q", backend)
+}
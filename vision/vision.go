@@ -0,0 +1,140 @@
+package vision
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/fog"
+	"github.com/JonCSykes/DragonTable/geometry"
+)
+
+// rangeCorners is how many rays round out the edge of a limited sight range
+const rangeCorners int = 64
+
+// cornerAngle is how far past a wall's end the extra rays are cast to see what lies behind it
+const cornerAngle float64 = 1e-4
+
+// Visible returns the polygon of everything that can be seen from origin, radius limits how far when above 0.
+// Rays are cast at every wall end in range and just either side of it, so the polygon's corners follow the walls exactly.
+func (walls *Walls) Visible(origin fyne.Position, radius float32) geometry.Polygon {
+	limit := math.Hypot(float64(walls.Width), float64(walls.Height))
+	if radius > 0 {
+		limit = float64(radius)
+	}
+
+	var angles []float64
+	addCorner := func(point fyne.Position) {
+		distance := math.Hypot(float64(point.X-origin.X), float64(point.Y-origin.Y))
+		if distance > limit || distance == 0 {
+			return
+		}
+
+		angle := math.Atan2(float64(point.Y-origin.Y), float64(point.X-origin.X))
+		angles = append(angles, angle-cornerAngle, angle, angle+cornerAngle)
+	}
+
+	for _, segment := range walls.Segments {
+		addCorner(segment.A)
+		addCorner(segment.B)
+	}
+	if radius > 0 {
+		for i := 0; i < rangeCorners; i++ {
+			angles = append(angles, 2*math.Pi*float64(i)/float64(rangeCorners)-math.Pi)
+		}
+	}
+
+	sort.Float64s(angles)
+
+	polygon := make(geometry.Polygon, 0, len(angles))
+	for _, angle := range angles {
+		directionX, directionY := math.Cos(angle), math.Sin(angle)
+		distance := walls.Cast(origin, directionX, directionY, limit)
+		polygon = append(polygon, fyne.NewPos(origin.X+float32(directionX*distance), origin.Y+float32(directionY*distance)))
+	}
+
+	return polygon
+}
+
+// Vision is what a group of viewers can see of a map right now, along with everything they have seen before
+type Vision struct {
+	Walls *Walls
+	// Range is how far the viewers can see in map pixels, 0 for as far as the walls allow
+	Range float32
+	// Visible is revealed where any viewer can see now, Explored where any viewer has seen
+	Visible  *fog.Mask
+	Explored *fog.Mask
+
+	viewers []fyne.Position
+	// tokenViewers are the tokens that see, kept apart from the viewers placed by hand
+	tokenViewers []fyne.Position
+	polygons     []geometry.Polygon
+	mutex        sync.Mutex
+}
+
+// New starts line of sight on a map with nothing seen yet
+func New(walls *Walls, mapWidth int, mapHeight int) *Vision {
+	return &Vision{Walls: walls, Visible: fog.NewMask(mapWidth, mapHeight), Explored: fog.NewMask(mapWidth, mapHeight)}
+}
+
+// Viewers returns the positions sight is worked out from
+func (vision *Vision) Viewers() []fyne.Position {
+	vision.mutex.Lock()
+	defer vision.mutex.Unlock()
+
+	return append([]fyne.Position(nil), vision.viewers...)
+}
+
+// SetViewers moves the viewers and works out what they can see
+func (vision *Vision) SetViewers(viewers []fyne.Position) {
+	vision.mutex.Lock()
+	vision.viewers = append([]fyne.Position(nil), viewers...)
+	vision.mutex.Unlock()
+
+	vision.Update()
+}
+
+// SetTokenViewers moves the tokens that see and works out what the viewers can see
+func (vision *Vision) SetTokenViewers(viewers []fyne.Position) {
+	vision.mutex.Lock()
+	vision.tokenViewers = append([]fyne.Position(nil), viewers...)
+	vision.mutex.Unlock()
+
+	vision.Update()
+}
+
+// SetWalls changes the walls, when a door is opened or closed, and works out what the viewers can see
+func (vision *Vision) SetWalls(walls *Walls) {
+	vision.mutex.Lock()
+	vision.Walls = walls
+	vision.mutex.Unlock()
+
+	vision.Update()
+}
+
+// Update works out what the viewers can see and adds it to what they have explored
+func (vision *Vision) Update() {
+	vision.mutex.Lock()
+	defer vision.mutex.Unlock()
+
+	vision.polygons = vision.polygons[:0]
+	for _, viewer := range append(append([]fyne.Position(nil), vision.viewers...), vision.tokenViewers...) {
+		vision.polygons = append(vision.polygons, vision.Walls.Visible(viewer, vision.Range))
+	}
+
+	vision.Visible.Fill(false)
+	for _, polygon := range vision.polygons {
+		vision.Visible.Polygon(polygon, true)
+		vision.Explored.Polygon(polygon, true)
+	}
+}
+
+// Polygons returns the areas each viewer can see
+func (vision *Vision) Polygons() []geometry.Polygon {
+	vision.mutex.Lock()
+	defer vision.mutex.Unlock()
+
+	return append([]geometry.Polygon(nil), vision.polygons...)
+}
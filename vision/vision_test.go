@@ -0,0 +1,103 @@
+package vision
+
+import (
+	"math"
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+func TestCastStopsAtNearestWall(t *testing.T) {
+	walls := NewWalls([]Segment{
+		{A: fyne.NewPos(500, 0), B: fyne.NewPos(500, 1000)},
+		{A: fyne.NewPos(300, 400), B: fyne.NewPos(300, 600)},
+	}, 1000, 1000)
+
+	if distance := walls.Cast(fyne.NewPos(100, 500), 1, 0, 5000); math.Abs(distance-200) > 0.01 {
+		t.Errorf("expected the nearer wall 200 away, got %.2f", distance)
+	}
+	if distance := walls.Cast(fyne.NewPos(100, 100), 1, 0, 5000); math.Abs(distance-400) > 0.01 {
+		t.Errorf("expected the far wall 400 away, got %.2f", distance)
+	}
+	if distance := walls.Cast(fyne.NewPos(100, 100), -1, 0, 5000); math.Abs(distance-100) > 0.01 {
+		t.Errorf("expected the map edge 100 away, got %.2f", distance)
+	}
+	if distance := walls.Cast(fyne.NewPos(100, 100), 1, 0, 50); distance != 50 {
+		t.Errorf("expected the cast to stop at its limit, got %.2f", distance)
+	}
+}
+
+func TestVisibleHidesWhatIsBehindWalls(t *testing.T) {
+	// a wall across the middle of the map with a doorway
+	scene := &mapFile.Scene{
+		Walls: [][]fyne.Position{{fyne.NewPos(0, 500), fyne.NewPos(400, 500)}, {fyne.NewPos(600, 500), fyne.NewPos(1000, 500)}},
+		Doors: []mapFile.Door{{Bounds: [2]fyne.Position{fyne.NewPos(400, 500), fyne.NewPos(600, 500)}, Closed: true}},
+	}
+	viewer := fyne.NewPos(500, 250)
+
+	closed := SceneWalls(scene, nil, 1000, 1000).Visible(viewer, 0)
+	if !closed.Contains(fyne.NewPos(100, 100)) || closed.Contains(fyne.NewPos(500, 750)) {
+		t.Error("expected a closed door to hide the other side of the wall")
+	}
+
+	open := SceneWalls(scene, []bool{false}, 1000, 1000).Visible(viewer, 0)
+	if !open.Contains(fyne.NewPos(500, 750)) {
+		t.Error("expected to see through the open door")
+	}
+	if open.Contains(fyne.NewPos(100, 750)) {
+		t.Error("expected the wall beside the door to still block sight")
+	}
+
+	limited := SceneWalls(scene, []bool{false}, 1000, 1000).Visible(viewer, 100)
+	if limited.Contains(fyne.NewPos(500, 400)) || !limited.Contains(fyne.NewPos(500, 300)) {
+		t.Error("expected sight to end at its range")
+	}
+}
+
+func TestVisionRemembersExploredAreas(t *testing.T) {
+	walls := NewWalls([]Segment{{A: fyne.NewPos(500, 0), B: fyne.NewPos(500, 1000)}}, 1000, 1000)
+	vision := New(walls, 1000, 1000)
+
+	vision.SetViewers([]fyne.Position{fyne.NewPos(250, 500)})
+	vision.SetViewers([]fyne.Position{fyne.NewPos(750, 500)})
+
+	left, right := fyne.NewPos(100, 500), fyne.NewPos(900, 500)
+	if vision.Visible.IsFogged(right) || !vision.Visible.IsFogged(left) {
+		t.Error("expected only the viewer's side of the wall to be visible")
+	}
+	if vision.Explored.IsFogged(left) || vision.Explored.IsFogged(right) {
+		t.Error("expected both sides to be explored")
+	}
+}
+
+func TestTokensSeeAlongsideViewers(t *testing.T) {
+	walls := NewWalls([]Segment{{A: fyne.NewPos(500, 0), B: fyne.NewPos(500, 1000)}}, 1000, 1000)
+	vision := New(walls, 1000, 1000)
+
+	vision.SetViewers([]fyne.Position{fyne.NewPos(250, 500)})
+	vision.SetTokenViewers([]fyne.Position{fyne.NewPos(750, 500)})
+
+	if vision.Visible.IsFogged(fyne.NewPos(100, 500)) || vision.Visible.IsFogged(fyne.NewPos(900, 500)) {
+		t.Error("expected the viewer and the token to each see their side of the wall")
+	}
+	if viewers := vision.Viewers(); len(viewers) != 1 {
+		t.Errorf("expected the tokens to be kept apart from the placed viewers, got %v", viewers)
+	}
+}
+
+func BenchmarkVisible(b *testing.B) {
+	// a maze of short walls like a large dungeon
+	var segments []Segment
+	for x := float32(0); x < 4000; x += 100 {
+		for y := float32(0); y < 4000; y += 100 {
+			segments = append(segments, Segment{A: fyne.NewPos(x+10, y+10), B: fyne.NewPos(x+60, y+10)})
+		}
+	}
+	walls := NewWalls(segments, 4000, 4000)
+
+	for i := 0; i < b.N; i++ {
+		walls.Visible(fyne.NewPos(2000, 2050), 1000)
+	}
+}
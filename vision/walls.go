@@ -0,0 +1,194 @@
+package vision
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/JonCSykes/DragonTable/mapFile"
+)
+
+// wallCellsAcross is roughly how many index cells the longer side of a map is split into
+const wallCellsAcross float32 = 64
+
+// Segment is one straight piece of wall between two map positions
+type Segment struct {
+	A fyne.Position
+	B fyne.Position
+}
+
+// Walls are the segments that block sight on a map, indexed by the cells of a coarse grid so a ray only
+// has to be tested against the walls in the cells it passes through. Casting rays changes the walls, so each
+// Vision or lighting Layer needs walls of its own
+type Walls struct {
+	Width    float32
+	Height   float32
+	Segments []Segment
+
+	cellSize float32
+	columns  int
+	rows     int
+	cells    [][]int
+	// checked stops a segment spanning several cells being tested twice by the same ray
+	checked []int
+	ray     int
+}
+
+// NewWalls indexes the segments of a map of the given size, the map's edges are added as walls
+func NewWalls(segments []Segment, width float32, height float32) *Walls {
+	corners := []fyne.Position{{X: 0, Y: 0}, {X: width, Y: 0}, {X: width, Y: height}, {X: 0, Y: height}}
+	for i := range corners {
+		segments = append(segments, Segment{A: corners[i], B: corners[(i+1)%len(corners)]})
+	}
+
+	walls := &Walls{Width: width, Height: height, Segments: segments, checked: make([]int, len(segments))}
+	walls.cellSize = fyne.Max(fyne.Max(width, height)/wallCellsAcross, 1)
+	walls.columns = int(width/walls.cellSize) + 1
+	walls.rows = int(height/walls.cellSize) + 1
+	walls.cells = make([][]int, walls.columns*walls.rows)
+
+	for index, segment := range segments {
+		walls.addSegment(index, segment)
+	}
+
+	return walls
+}
+
+// SceneWalls returns the walls of an imported map, with the doors that are closed. closedDoors overrides
+// the doors' state in the scene when it has an entry for every door.
+func SceneWalls(scene *mapFile.Scene, closedDoors []bool, width float32, height float32) *Walls {
+	var segments []Segment
+
+	if scene != nil {
+		for _, wall := range scene.Walls {
+			for i := 1; i < len(wall); i++ {
+				segments = append(segments, Segment{A: wall[i-1], B: wall[i]})
+			}
+		}
+
+		for i, door := range scene.Doors {
+			closed := door.Closed
+			if len(closedDoors) == len(scene.Doors) {
+				closed = closedDoors[i]
+			}
+			if closed {
+				segments = append(segments, Segment{A: door.Bounds[0], B: door.Bounds[1]})
+			}
+		}
+	}
+
+	return NewWalls(segments, width, height)
+}
+
+// addSegment puts a segment in every cell its bounding box covers
+func (walls *Walls) addSegment(index int, segment Segment) {
+	fromColumn, fromRow := walls.cell(fyne.NewPos(fyne.Min(segment.A.X, segment.B.X), fyne.Min(segment.A.Y, segment.B.Y)))
+	toColumn, toRow := walls.cell(fyne.NewPos(fyne.Max(segment.A.X, segment.B.X), fyne.Max(segment.A.Y, segment.B.Y)))
+
+	for row := fromRow; row <= toRow; row++ {
+		for column := fromColumn; column <= toColumn; column++ {
+			walls.cells[row*walls.columns+column] = append(walls.cells[row*walls.columns+column], index)
+		}
+	}
+}
+
+// cell returns the column and row of the index cell holding a position, clamped to the map
+func (walls *Walls) cell(position fyne.Position) (int, int) {
+	column := int(math.Floor(float64(position.X / walls.cellSize)))
+	row := int(math.Floor(float64(position.Y / walls.cellSize)))
+
+	return clamp(column, 0, walls.columns-1), clamp(row, 0, walls.rows-1)
+}
+
+// Cast returns the distance along the direction from origin to the nearest wall, or limit if nothing is nearer
+func (walls *Walls) Cast(origin fyne.Position, directionX float64, directionY float64, limit float64) float64 {
+	walls.ray++
+	nearest := limit
+
+	column, row := walls.cell(origin)
+	cellSize := float64(walls.cellSize)
+
+	// walk the cells along the ray, stepping into whichever neighbour the ray reaches first
+	stepColumn, stepRow := 1, 1
+	nextX, nextY := math.Inf(1), math.Inf(1)
+	deltaX, deltaY := math.Inf(1), math.Inf(1)
+	if directionX > 0 {
+		nextX = (float64(column+1)*cellSize - float64(origin.X)) / directionX
+		deltaX = cellSize / directionX
+	} else if directionX < 0 {
+		stepColumn = -1
+		nextX = (float64(column)*cellSize - float64(origin.X)) / directionX
+		deltaX = -cellSize / directionX
+	}
+	if directionY > 0 {
+		nextY = (float64(row+1)*cellSize - float64(origin.Y)) / directionY
+		deltaY = cellSize / directionY
+	} else if directionY < 0 {
+		stepRow = -1
+		nextY = (float64(row)*cellSize - float64(origin.Y)) / directionY
+		deltaY = -cellSize / directionY
+	}
+
+	entered := 0.0
+	for entered <= nearest {
+		for _, index := range walls.cells[row*walls.columns+column] {
+			if walls.checked[index] == walls.ray {
+				continue
+			}
+			walls.checked[index] = walls.ray
+
+			if distance, hit := intersect(origin, directionX, directionY, walls.Segments[index]); hit && distance < nearest {
+				nearest = distance
+			}
+		}
+
+		if nextX < nextY {
+			column += stepColumn
+			entered = nextX
+			nextX += deltaX
+		} else {
+			row += stepRow
+			entered = nextY
+			nextY += deltaY
+		}
+
+		if column < 0 || column >= walls.columns || row < 0 || row >= walls.rows {
+			break
+		}
+	}
+
+	return nearest
+}
+
+// intersect returns how far along the ray it meets the segment
+func intersect(origin fyne.Position, directionX float64, directionY float64, segment Segment) (float64, bool) {
+	segmentX := float64(segment.B.X - segment.A.X)
+	segmentY := float64(segment.B.Y - segment.A.Y)
+
+	denominator := directionX*segmentY - directionY*segmentX
+	if math.Abs(denominator) < 1e-12 {
+		return 0, false
+	}
+
+	toStartX := float64(segment.A.X - origin.X)
+	toStartY := float64(segment.A.Y - origin.Y)
+	distance := (toStartX*segmentY - toStartY*segmentX) / denominator
+	along := (toStartX*directionY - toStartY*directionX) / denominator
+
+	if distance < 0 || along < 0 || along > 1 {
+		return 0, false
+	}
+
+	return distance, true
+}
+
+func clamp(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+
+	return value
+}
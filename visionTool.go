@@ -0,0 +1,366 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/JonCSykes/DragonTable/geometry"
+	"github.com/JonCSykes/DragonTable/mapFile"
+	"github.com/JonCSykes/DragonTable/mapView"
+	"github.com/JonCSykes/DragonTable/touch"
+	"github.com/JonCSykes/DragonTable/vision"
+)
+
+const VisionPanelWidth float32 = 320
+const VisionPanelHeight float32 = 300
+
+// VisionViewerRadius is the size of the markers drawn for viewers while the vision tool is open
+const VisionViewerRadius float32 = 20
+
+// VisionTapDistance is how far a finger can move and still count as a tap, and how close a tap has to be to a door
+const VisionTapDistance float32 = 20
+
+// MaxVisionRange is the furthest the viewers can be set to see, in grid squares
+const MaxVisionRange float64 = 60
+
+var VisionOverlay *fyne.Container
+
+var visionViewerColor = color.NRGBA{R: 255, G: 200, B: 60, A: 255}
+var visionOpenDoorColor = color.NRGBA{R: 80, G: 220, B: 120, A: 255}
+var visionClosedDoorColor = color.NRGBA{R: 230, G: 70, B: 70, A: 255}
+
+// visionDrag is a finger on the table, either moving a viewer or about to tap
+type visionDrag struct {
+	viewer int
+	start  fyne.Position
+	moved  bool
+}
+
+// VisionTool lets the GM place the viewers line of sight is worked out from, and open and close the map's doors.
+// A tap adds a viewer, or opens or closes the door under it, tapping a viewer removes it and dragging one moves it.
+type VisionTool struct {
+	OnDeactivate func()
+
+	panel   fyne.CanvasObject
+	preview *fyne.Container
+	drags   map[int]*visionDrag
+}
+
+// NewVisionTool starts placing viewers on the map on the table
+func NewVisionTool(onDeactivate func()) *VisionTool {
+	return &VisionTool{
+		OnDeactivate: onDeactivate,
+		preview:      container.NewWithoutLayout(),
+		drags:        make(map[int]*visionDrag),
+	}
+}
+
+// HandleTouch adds, moves and removes viewers and toggles doors
+func (tool *VisionTool) HandleTouch(event touch.Event) {
+	position := fyne.NewPos(event.X, event.Y)
+	sight := TableView.Vision
+
+	if event.Status == touch.InitialTouch && touchesObject(tool.panel, position) {
+		return
+	}
+	if sight == nil {
+		return
+	}
+
+	drag, dragging := tool.drags[event.ID]
+	switch event.Status {
+	case touch.InitialTouch:
+		tool.drags[event.ID] = &visionDrag{viewer: tool.viewerAt(position), start: position}
+	case touch.StreamTouch:
+		if !dragging {
+			return
+		}
+		if geometry.Distance(drag.start, position) > VisionTapDistance {
+			drag.moved = true
+		}
+		if drag.moved && drag.viewer >= 0 {
+			viewers := sight.Viewers()
+			if drag.viewer < len(viewers) {
+				viewers[drag.viewer] = clampToMap(ToolView().ScreenToMap(position))
+				sight.SetViewers(viewers)
+				RedrawVision()
+				tool.showMarkers()
+			}
+		}
+	case touch.UnTouch:
+		if !dragging {
+			return
+		}
+		delete(tool.drags, event.ID)
+
+		if !drag.moved {
+			tool.tap(sight, drag, position)
+		}
+		SaveVision()
+	}
+}
+
+func (tool *VisionTool) tap(sight *vision.Vision, drag *visionDrag, position fyne.Position) {
+	viewers := sight.Viewers()
+
+	if drag.viewer >= 0 && drag.viewer < len(viewers) {
+		sight.SetViewers(append(viewers[:drag.viewer], viewers[drag.viewer+1:]...))
+	} else if door := tool.doorAt(position); door >= 0 {
+		ToggleDoor(door)
+	} else {
+		sight.SetViewers(append(viewers, clampToMap(ToolView().ScreenToMap(position))))
+	}
+
+	RedrawVision()
+	tool.showMarkers()
+}
+
+// viewerAt returns the index of the viewer under a screen position, -1 when there is none
+func (tool *VisionTool) viewerAt(position fyne.Position) int {
+	for i, viewer := range TableView.Vision.Viewers() {
+		if geometry.Distance(ToolView().MapToScreen(viewer), position) <= VisionViewerRadius*1.5 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// doorAt returns the index of the door of the map's scene under a screen position, -1 when there is none
+func (tool *VisionTool) doorAt(position fyne.Position) int {
+	file := TableView.CurrentMapFile
+	if file == nil || file.Scene == nil {
+		return -1
+	}
+
+	for i, door := range file.Scene.Doors {
+		if geometry.SegmentDistance(position, ToolView().MapToScreen(door.Bounds[0]), ToolView().MapToScreen(door.Bounds[1])) <= VisionTapDistance {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// showMarkers draws the viewers and the doors, open or closed, over the table
+func (tool *VisionTool) showMarkers() {
+	var objects []fyne.CanvasObject
+
+	if file := TableView.CurrentMapFile; file != nil && file.Scene != nil {
+		closed := doorStates(file)
+		for i, door := range file.Scene.Doors {
+			line := canvas.NewLine(visionOpenDoorColor)
+			if closed[i] {
+				line.StrokeColor = visionClosedDoorColor
+			}
+			line.StrokeWidth = 4
+			line.Position1, line.Position2 = ToolView().MapToScreen(door.Bounds[0]), ToolView().MapToScreen(door.Bounds[1])
+			objects = append(objects, line)
+		}
+	}
+
+	if TableView.Vision != nil {
+		for _, viewer := range TableView.Vision.Viewers() {
+			marker := canvas.NewCircle(color.Transparent)
+			marker.StrokeColor = visionViewerColor
+			marker.StrokeWidth = 4
+			marker.Move(ToolView().MapToScreen(viewer).Subtract(fyne.NewPos(VisionViewerRadius, VisionViewerRadius)))
+			marker.Resize(fyne.NewSize(VisionViewerRadius*2, VisionViewerRadius*2))
+			objects = append(objects, marker)
+		}
+	}
+
+	tool.preview.Objects = objects
+	tool.preview.Refresh()
+}
+
+// Deactivate closes the vision panel
+func (tool *VisionTool) Deactivate() {
+	tool.drags = make(map[int]*visionDrag)
+	tool.preview.Objects = nil
+	tool.preview.Refresh()
+
+	if tool.OnDeactivate != nil {
+		tool.OnDeactivate()
+	}
+}
+
+// doorStates returns whether each door of the map's scene is closed, as the GM left them or as the map was drawn
+func doorStates(file *mapFile.MapFile) []bool {
+	if file.Scene == nil {
+		return nil
+	}
+	if len(file.Metadata.Doors) == len(file.Scene.Doors) {
+		return file.Metadata.Doors
+	}
+
+	closed := make([]bool, len(file.Scene.Doors))
+	for i, door := range file.Scene.Doors {
+		closed[i] = door.Closed
+	}
+
+	return closed
+}
+
+// ToggleDoor opens or closes a door of the map on the table and works out again what the viewers can see and where the light reaches
+func ToggleDoor(door int) {
+	file := TableView.CurrentMapFile
+	if file == nil || file.Scene == nil || door < 0 || door >= len(file.Scene.Doors) {
+		return
+	}
+
+	closed := doorStates(file)
+	closed[door] = !closed[door]
+	file.Metadata.Doors = closed
+
+	// sight and light are worked out on different goroutines, so they get walls of their own
+	if TableView.Vision != nil {
+		TableView.Vision.SetWalls(vision.SceneWalls(file.Scene, closed, float32(file.Width), float32(file.Height)))
+		RedrawVision()
+	}
+	if TableView.Lighting != nil {
+		TableView.Lighting.SetWalls(vision.SceneWalls(file.Scene, closed, float32(file.Width), float32(file.Height)))
+		RedrawLighting()
+	}
+}
+
+// SetVision hides what the viewers can't see on the table, nil turns line of sight off
+func SetVision(sight *vision.Vision) {
+	TableView.SetVision(sight)
+	syncGMView()
+}
+
+// RedrawVision shows changes to what the viewers can see on the table and the GM screen
+func RedrawVision() {
+	TableView.RedrawVision()
+
+	if GMView != nil {
+		GMView.RedrawVision()
+	}
+}
+
+// SaveVision stores the viewers, what they have explored and the state of the doors next to the map on the table
+func SaveVision() {
+	file := TableView.CurrentMapFile
+	if file == nil {
+		return
+	}
+
+	file.Metadata.Vision = nil
+	file.Metadata.Explored = nil
+	if sight := TableView.Vision; sight != nil {
+		file.Metadata.Vision = &mapFile.VisionSettings{Viewers: sight.Viewers(), Range: sight.Range}
+
+		data, encodeError := sight.Explored.Encode()
+		if encodeError != nil {
+			fmt.Println(encodeError)
+			return
+		}
+		file.Metadata.Explored = data
+	}
+
+	if saveError := file.SaveMetadata(); saveError != nil {
+		fmt.Println(saveError)
+	}
+}
+
+// ShowVisionTool opens the vision panel for the map on the table, turning line of sight on for it
+func ShowVisionTool(onDeactivate func()) bool {
+	if TableView.CurrentMapFile == nil || TableView.CurrentMap.Hidden {
+		fmt.Println("No map to set up line of sight for")
+		return false
+	}
+
+	tool := NewVisionTool(nil)
+	VisionOverlay = BuildVisionOverlay(tool)
+	AddToolOverlay(VisionOverlay)
+	tool.showMarkers()
+
+	tool.OnDeactivate = func() {
+		RemoveToolOverlay(VisionOverlay)
+		if onDeactivate != nil {
+			onDeactivate()
+		}
+	}
+	SetActiveTool(tool)
+
+	return true
+}
+
+func BuildVisionOverlay(tool *VisionTool) *fyne.Container {
+
+	file := TableView.CurrentMapFile
+
+	if TableView.Vision == nil {
+		// a map starts with nothing seen the first time the GM turns line of sight on
+		file.Metadata.Vision = &mapFile.VisionSettings{}
+		file.Metadata.Explored = nil
+		SetVision(mapView.LoadVision(file))
+		SaveVision()
+	}
+
+	cellSize := float64(TableView.Grid().CellSize())
+	rangeLabel := widget.NewLabel("")
+	showRange := func(squares float64) {
+		if squares == 0 {
+			rangeLabel.SetText("Sight range: unlimited")
+		} else {
+			rangeLabel.SetText(fmt.Sprintf("Sight range: %.0f squares", squares))
+		}
+	}
+
+	rangeSlider := widget.NewSlider(0, MaxVisionRange)
+	if cellSize > 0 {
+		rangeSlider.SetValue(float64(TableView.Vision.Range) / cellSize)
+	}
+	showRange(rangeSlider.Value)
+	rangeSlider.OnChanged = func(squares float64) {
+		showRange(squares)
+		if TableView.Vision == nil {
+			return
+		}
+		TableView.Vision.Range = float32(squares * cellSize)
+		TableView.Vision.Update()
+		RedrawVision()
+		SaveVision()
+	}
+
+	forgetButton := widget.NewButton("Forget Explored", func() {
+		if TableView.Vision == nil {
+			return
+		}
+		TableView.Vision.Explored.Fill(false)
+		TableView.Vision.Update()
+		RedrawVision()
+		SaveVision()
+	})
+
+	offButton := widget.NewButton("Turn Off", func() {
+		SetVision(nil)
+		SaveVision()
+		SetActiveTool(nil)
+	})
+
+	doneButton := widget.NewButton("Done", func() {
+		SetActiveTool(nil)
+	})
+	doneButton.Importance = widget.HighImportance
+
+	panel := container.NewVBox(
+		widget.NewLabel("Tap to add a viewer, tap a viewer to remove\nit and drag it to move it. Tap a door to\nopen or close it."),
+		rangeLabel,
+		rangeSlider,
+		forgetButton,
+		container.NewGridWithColumns(2, offButton, doneButton),
+	)
+	panel.Resize(fyne.NewSize(VisionPanelWidth, VisionPanelHeight))
+	panel.Move(ToolPanelPosition(VisionPanelHeight))
+	tool.panel = panel
+
+	return container.NewWithoutLayout(tool.preview, panel)
+}
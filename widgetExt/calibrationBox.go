@@ -0,0 +1,96 @@
+package widgetExt
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// CalibrationBoxMinSize is the smallest the box can be dragged to
+const CalibrationBoxMinSize float32 = 20
+
+const calibrationHandleSize float32 = 36
+
+// CalibrationBox widget is an outlined box whose size is changed by dragging, used to match a physical object on the screen
+type CalibrationBox struct {
+	widget.BaseWidget
+
+	OnChanged func(size fyne.Size) `json:"-"`
+}
+
+type calibrationBoxRenderer struct {
+	box     *CalibrationBox
+	outline *canvas.Rectangle
+	handle  *canvas.Circle
+	objects []fyne.CanvasObject
+}
+
+// NewCalibrationBox creates a calibration box with the given starting size
+func NewCalibrationBox(size fyne.Size, changed func(size fyne.Size)) *CalibrationBox {
+	box := &CalibrationBox{OnChanged: changed}
+	box.ExtendBaseWidget(box)
+	box.Resize(size)
+
+	return box
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (box *CalibrationBox) CreateRenderer() fyne.WidgetRenderer {
+	box.ExtendBaseWidget(box)
+
+	outline := canvas.NewRectangle(color.NRGBA{R: 255, G: 255, B: 255, A: 40})
+	outline.StrokeColor = theme.PrimaryColor()
+	outline.StrokeWidth = 2
+
+	handle := canvas.NewCircle(theme.PrimaryColor())
+
+	return &calibrationBoxRenderer{box: box, outline: outline, handle: handle, objects: []fyne.CanvasObject{outline, handle}}
+}
+
+// Dragged grows or shrinks the box by the drag movement
+func (box *CalibrationBox) Dragged(event *fyne.DragEvent) {
+	size := box.Size()
+	newSize := fyne.NewSize(fyne.Max(CalibrationBoxMinSize, size.Width+event.Dragged.DX), fyne.Max(CalibrationBoxMinSize, size.Height+event.Dragged.DY))
+
+	box.Resize(newSize)
+
+	if box.OnChanged != nil {
+		box.OnChanged(newSize)
+	}
+}
+
+// DragEnd is called when the drag finishes
+func (box *CalibrationBox) DragEnd() {
+}
+
+// MinSize returns the size that this widget should not shrink below
+func (box *CalibrationBox) MinSize() fyne.Size {
+	return fyne.NewSize(CalibrationBoxMinSize, CalibrationBoxMinSize)
+}
+
+func (r *calibrationBoxRenderer) Destroy() {
+}
+
+func (r *calibrationBoxRenderer) Layout(size fyne.Size) {
+	r.outline.Resize(size)
+	r.handle.Resize(fyne.NewSize(calibrationHandleSize, calibrationHandleSize))
+	r.handle.Move(fyne.NewPos(size.Width-calibrationHandleSize/2, size.Height-calibrationHandleSize/2))
+}
+
+func (r *calibrationBoxRenderer) MinSize() fyne.Size {
+	return r.box.MinSize()
+}
+
+func (r *calibrationBoxRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *calibrationBoxRenderer) Refresh() {
+	r.outline.StrokeColor = theme.PrimaryColor()
+	r.handle.FillColor = theme.PrimaryColor()
+	r.Layout(r.box.Size())
+	canvas.Refresh(r.box)
+}
@@ -0,0 +1,91 @@
+package widgetExt
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var touchKeyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl'", "zxcvbnm,.-"}
+
+// TouchEntry is an Entry that reports when it gets focus, so an on-screen keyboard can be opened for it
+type TouchEntry struct {
+	widget.Entry
+
+	OnFocused func() `json:"-"`
+}
+
+// NewTouchEntry creates a single line TouchEntry
+func NewTouchEntry() *TouchEntry {
+	entry := &TouchEntry{}
+	entry.ExtendBaseWidget(entry)
+
+	return entry
+}
+
+// FocusGained is called by Fyne when the entry is tapped
+func (entry *TouchEntry) FocusGained() {
+	entry.Entry.FocusGained()
+
+	if entry.OnFocused != nil {
+		entry.OnFocused()
+	}
+}
+
+// TouchKeyboard is an on-screen keyboard for tables without a physical one, it types into whatever Target is
+type TouchKeyboard struct {
+	Target  fyne.Focusable
+	OnDone  func() `json:"-"`
+	Content *fyne.Container
+}
+
+// NewTouchKeyboard builds the keys, done is called by the Done key
+func NewTouchKeyboard(done func()) *TouchKeyboard {
+	keyboard := &TouchKeyboard{OnDone: done}
+
+	var rows []fyne.CanvasObject
+	for _, keys := range touchKeyboardRows {
+		var buttons []fyne.CanvasObject
+		for _, key := range keys {
+			buttons = append(buttons, keyboard.keyButton(key))
+		}
+		rows = append(rows, container.NewGridWithColumns(len(buttons), buttons...))
+	}
+
+	backspaceButton := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		keyboard.typeKey(fyne.KeyBackspace)
+	})
+	spaceButton := widget.NewButton("Space", func() {
+		keyboard.typeRune(' ')
+	})
+	doneButton := widget.NewButton("Done", func() {
+		if keyboard.OnDone != nil {
+			keyboard.OnDone()
+		}
+	})
+	doneButton.Importance = widget.HighImportance
+
+	rows = append(rows, container.NewBorder(nil, nil, backspaceButton, doneButton, spaceButton))
+	keyboard.Content = container.NewGridWithRows(len(rows), rows...)
+
+	return keyboard
+}
+
+func (keyboard *TouchKeyboard) keyButton(key rune) *widget.Button {
+	return widget.NewButton(string(key), func() {
+		keyboard.typeRune(key)
+	})
+}
+
+func (keyboard *TouchKeyboard) typeRune(key rune) {
+	if keyboard.Target != nil {
+		keyboard.Target.TypedRune(key)
+	}
+}
+
+func (keyboard *TouchKeyboard) typeKey(name fyne.KeyName) {
+	if keyboard.Target != nil {
+		keyboard.Target.TypedKey(&fyne.KeyEvent{Name: name})
+	}
+}